@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"time"
 )
 
 var (
@@ -32,6 +34,11 @@ var (
 // adapted function reports an error without calling fn.  Otherwise, the
 // adapter calls fn and returns its result.
 //
+// Functions with up to three string parameters, with or without a rest
+// parameter, are called directly. Other supported functions are called using
+// reflection, which is slower and allocates on each call. See also [Adapt1],
+// which checks its argument types at compile time.
+//
 // Adapt will panic if fn is not a function of a supported type.
 func Adapt(fn any) func(*Env) error {
 	r, err := checkAdapt(fn)
@@ -42,15 +49,49 @@ func Adapt(fn any) func(*Env) error {
 }
 
 func checkAdapt(fn any) (func(*Env) error, error) {
-	// Case 1: The function accepts no arguments.
-	if fz, ok := fn.(func(*Env) error); ok {
+	// Handle the common signatures directly, so that calls to the adapted
+	// function do not pay the cost of reflection.
+	switch f := fn.(type) {
+	case func(*Env) error:
 		return func(env *Env) error {
 			if len(env.Args) != 0 {
 				return env.Usagef("extra arguments after command %q: %q",
 					env.Command.Name, env.Args)
 			}
-			return fz(env)
+			return f(env)
 		}, nil
+	case func(*Env, string) error:
+		return fixedArgs(1, func(env *Env) error { return f(env, env.Args[0]) }), nil
+	case func(*Env, string, string) error:
+		return fixedArgs(2, func(env *Env) error { return f(env, env.Args[0], env.Args[1]) }), nil
+	case func(*Env, string, string, string) error:
+		return fixedArgs(3, func(env *Env) error {
+			return f(env, env.Args[0], env.Args[1], env.Args[2])
+		}), nil
+	case func(*Env, ...string) error:
+		return restArgs(0, func(env *Env) error { return f(env, env.Args...) }), nil
+	case func(*Env, []string) error:
+		return restArgs(0, func(env *Env) error { return f(env, env.Args) }), nil
+	case func(*Env, string, ...string) error:
+		return restArgs(1, func(env *Env) error { return f(env, env.Args[0], env.Args[1:]...) }), nil
+	case func(*Env, string, []string) error:
+		return restArgs(1, func(env *Env) error { return f(env, env.Args[0], env.Args[1:]) }), nil
+	case func(*Env, string, string, ...string) error:
+		return restArgs(2, func(env *Env) error {
+			return f(env, env.Args[0], env.Args[1], env.Args[2:]...)
+		}), nil
+	case func(*Env, string, string, []string) error:
+		return restArgs(2, func(env *Env) error {
+			return f(env, env.Args[0], env.Args[1], env.Args[2:])
+		}), nil
+	case func(*Env, string, string, string, ...string) error:
+		return restArgs(3, func(env *Env) error {
+			return f(env, env.Args[0], env.Args[1], env.Args[2], env.Args[3:]...)
+		}), nil
+	case func(*Env, string, string, string, []string) error:
+		return restArgs(3, func(env *Env) error {
+			return f(env, env.Args[0], env.Args[1], env.Args[2], env.Args[3:])
+		}), nil
 	}
 
 	// Require that fn has the form func(*Env, ...) error.
@@ -87,27 +128,42 @@ func checkAdapt(fn any) (func(*Env) error, error) {
 		call = fv.CallSlice
 	}
 
-	// Case 2: A variadic function, or one with a rest slice.
+	// A variadic function, or one with a rest slice.
 	if hasRest {
-		return func(env *Env) error {
-			if len(env.Args) < argc-1 {
-				return env.Usagef("wrong number of arguments for %q: got %d, want at least %d",
-					env.Command.Name, len(env.Args), argc-1)
-			}
+		return restArgs(argc-1, func(env *Env) error {
 			args := append(packValues(env, argc-1), reflect.ValueOf(env.Args[argc-1:]))
 			return unpackError(call(args))
-		}, nil
+		}), nil
 	}
 
-	// Case 3: A fixed-positional function.
+	// A fixed-positional function.
+	return fixedArgs(argc, func(env *Env) error {
+		return unpackError(call(packValues(env, argc)))
+	}), nil
+}
+
+// fixedArgs returns a function that calls run if the environment has exactly
+// n arguments, and otherwise reports a usage error.
+func fixedArgs(n int, run func(*Env) error) func(*Env) error {
 	return func(env *Env) error {
-		if len(env.Args) != argc {
+		if len(env.Args) != n {
 			return env.Usagef("wrong number of arguments for %q: got %d, want %d",
-				env.Command.Name, len(env.Args), argc)
+				env.Command.Name, len(env.Args), n)
 		}
-		args := packValues(env, argc)
-		return unpackError(call(args))
-	}, nil
+		return run(env)
+	}
+}
+
+// restArgs returns a function that calls run if the environment has at least
+// n arguments, and otherwise reports a usage error.
+func restArgs(n int, run func(*Env) error) func(*Env) error {
+	return func(env *Env) error {
+		if len(env.Args) < n {
+			return env.Usagef("wrong number of arguments for %q: got %d, want at least %d",
+				env.Command.Name, len(env.Args), n)
+		}
+		return run(env)
+	}
 }
 
 func packValues(env *Env, n int) []reflect.Value {
@@ -125,3 +181,98 @@ func unpackError(outs []reflect.Value) error {
 	}
 	return nil
 }
+
+// Arg is the set of types supported for the parameters of functions adapted
+// by [Adapt1], [Adapt2], and [Adapt3].
+type Arg interface {
+	string | bool | int | int64 | uint | uint64 | float64 | time.Duration
+}
+
+// Adapt1 adapts a function with one typed parameter to the type signature of
+// a Run function. The adapted function reports a usage error if it is not
+// given exactly one argument, or if the argument is not a valid value of type
+// A. Otherwise, it calls fn with the converted argument and returns its
+// result. For example:
+//
+//	Run: command.Adapt1(func(env *command.Env, n int) error { ... })
+//
+// Unlike [Adapt], the signature of fn is checked at compile time, and no
+// reflection is used.
+func Adapt1[A Arg](fn func(*Env, A) error) func(*Env) error {
+	return fixedArgs(1, func(env *Env) error {
+		a, err := parseArg[A](env, 0)
+		if err != nil {
+			return err
+		}
+		return fn(env, a)
+	})
+}
+
+// Adapt2 is as [Adapt1], for a function with two typed parameters.
+func Adapt2[A, B Arg](fn func(*Env, A, B) error) func(*Env) error {
+	return fixedArgs(2, func(env *Env) error {
+		a, err := parseArg[A](env, 0)
+		if err != nil {
+			return err
+		}
+		b, err := parseArg[B](env, 1)
+		if err != nil {
+			return err
+		}
+		return fn(env, a, b)
+	})
+}
+
+// Adapt3 is as [Adapt1], for a function with three typed parameters.
+func Adapt3[A, B, C Arg](fn func(*Env, A, B, C) error) func(*Env) error {
+	return fixedArgs(3, func(env *Env) error {
+		a, err := parseArg[A](env, 0)
+		if err != nil {
+			return err
+		}
+		b, err := parseArg[B](env, 1)
+		if err != nil {
+			return err
+		}
+		c, err := parseArg[C](env, 2)
+		if err != nil {
+			return err
+		}
+		return fn(env, a, b, c)
+	})
+}
+
+// parseArg converts the argument at offset i of env.Args to a value of type
+// T, or reports a usage error.
+func parseArg[T Arg](env *Env, i int) (T, error) {
+	var v T
+	s := env.Args[i]
+	var err error
+	switch p := any(&v).(type) {
+	case *string:
+		*p = s
+	case *bool:
+		*p, err = strconv.ParseBool(s)
+	case *int:
+		var n int64
+		n, err = strconv.ParseInt(s, 0, strconv.IntSize)
+		*p = int(n)
+	case *int64:
+		*p, err = strconv.ParseInt(s, 0, 64)
+	case *uint:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, strconv.IntSize)
+		*p = uint(u)
+	case *uint64:
+		*p, err = strconv.ParseUint(s, 0, 64)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *time.Duration:
+		*p, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return v, env.Usagef("invalid argument %d for %q: %q is not a valid %T",
+			i+1, env.Command.Name, s, v)
+	}
+	return v, nil
+}
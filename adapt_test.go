@@ -3,8 +3,12 @@
 package command_test
 
 import (
+	"errors"
 	"flag"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/creachadair/command"
 	"github.com/creachadair/mds/mtest"
@@ -55,6 +59,124 @@ func TestAdapt(t *testing.T) {
 	}
 }
 
+func TestAdaptArgs(t *testing.T) {
+	var got []string
+	save := func(args ...string) error { got = args; return nil }
+	tests := []struct {
+		name string
+		fn   any
+		args []string
+		want []string
+	}{
+		{"One", func(_ *command.Env, a string) error { return save(a) },
+			[]string{"a"}, []string{"a"}},
+		{"Three", func(_ *command.Env, a, b, c string) error { return save(a, b, c) },
+			[]string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"Var", func(_ *command.Env, rest ...string) error { return save(rest...) },
+			[]string{"a", "b"}, []string{"a", "b"}},
+		{"OneRest", func(_ *command.Env, a string, rest []string) error { return save(append([]string{a, "|"}, rest...)...) },
+			[]string{"a", "b", "c"}, []string{"a", "|", "b", "c"}},
+		{"ThreeVar", func(_ *command.Env, a, b, c string, rest ...string) error {
+			return save(a, b, c, "|", strings.Join(rest, ","))
+		},
+			[]string{"a", "b", "c", "d", "e"}, []string{"a", "b", "c", "|", "d,e"}},
+
+		// These signatures use reflection.
+		{"Four", func(_ *command.Env, a, b, c, d string) error { return save(a, b, c, d) },
+			[]string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d"}},
+		{"FourRest", func(_ *command.Env, a, b, c, d string, rest []string) error {
+			return save(a, b, c, d, "|", strings.Join(rest, ","))
+		},
+			[]string{"a", "b", "c", "d", "e", "f"}, []string{"a", "b", "c", "d", "|", "e,f"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			c := &command.C{Name: "test", Run: command.Adapt(tc.fn)}
+			if err := command.Run(c.NewEnv(nil), tc.args); err != nil {
+				t.Fatalf("Run %q: unexpected error: %v", tc.args, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Arguments (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdaptTyped(t *testing.T) {
+	var got []any
+	one := command.Adapt1(func(_ *command.Env, n int) error { got = []any{n}; return nil })
+	two := command.Adapt2(func(_ *command.Env, s string, ok bool) error { got = []any{s, ok}; return nil })
+	three := command.Adapt3(func(_ *command.Env, d time.Duration, f float64, u uint64) error {
+		got = []any{d, f, u}
+		return nil
+	})
+
+	tests := []struct {
+		name string
+		run  func(*command.Env) error
+		args []string
+		want []any // nil for a usage error
+	}{
+		{"OneInt", one, []string{"25"}, []any{25}},
+		{"OneHex", one, []string{"0x10"}, []any{16}},
+		{"OneBad", one, []string{"x"}, nil},
+		{"OneNone", one, nil, nil},
+		{"OneExtra", one, []string{"1", "2"}, nil},
+		{"Two", two, []string{"a", "true"}, []any{"a", true}},
+		{"TwoBad", two, []string{"a", "maybe"}, nil},
+		{"Three", three, []string{"5s", "1.5", "3"}, []any{5 * time.Second, 1.5, uint64(3)}},
+		{"ThreeBad", three, []string{"5s", "1.5", "-3"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			c := &command.C{Name: "test", Run: tc.run}
+			env := c.NewEnv(nil)
+			env.Log = io.Discard
+			err := command.Run(env, tc.args)
+			if tc.want == nil {
+				var uerr command.UsageError
+				if !errors.As(err, &uerr) {
+					t.Errorf("Run %q: got %v, want usage error", tc.args, err)
+				}
+				if got != nil {
+					t.Errorf("Run %q: function was called with %v", tc.args, got)
+				}
+			} else if err != nil {
+				t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			} else if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Arguments (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkAdapt(b *testing.B) {
+	c := &command.C{Name: "test"}
+	env := c.NewEnv(nil)
+	env.Args = []string{"a", "b", "c", "d"}
+
+	b.Run("Direct", func(b *testing.B) {
+		run := command.Adapt(func(_ *command.Env, a, b string, rest ...string) error { return nil })
+		b.ReportAllocs()
+		for range b.N {
+			if err := run(env); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Reflect", func(b *testing.B) {
+		run := command.Adapt(func(_ *command.Env, a, b, c, d string) error { return nil })
+		b.ReportAllocs()
+		for range b.N {
+			if err := run(env); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func TestAdaptErrors(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,205 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheCommand constructs a standardized "cache" command with subcommands to
+// report on and prune the contents of the specified cache directories.  The
+// caller is free to edit the resulting command, each call returns a separate
+// value.
+//
+// The "cache info" subcommand prints the number of files and total size of
+// each directory to stdout. The "cache clean" subcommand removes the contents
+// of each directory, optionally restricted to files older than a given age or
+// in excess of a given total size.
+func CacheCommand(dirs ...string) *C {
+	var olderThan time.Duration
+	var maxSize byteSize
+	var dryRun bool
+	return &C{
+		Name:  "cache",
+		Usage: "info\nclean [--older-than d] [--max-size n] [--dry-run]",
+		Help:  `Report on or clean up cached data for this program.`,
+
+		Commands: []*C{
+			{
+				Name: "info",
+				Help: "Print the file count and size of each cache directory.",
+				Run: Adapt(func(env *Env) error {
+					for _, dir := range dirs {
+						files, err := listCache(dir)
+						if err != nil {
+							return err
+						}
+						var total int64
+						for _, f := range files {
+							total += f.size
+						}
+						fmt.Fprintf(env.Stdout(), "%s\t%d files\t%s\n", dir, len(files), byteSize(total))
+					}
+					return nil
+				}),
+			},
+			{
+				Name: "clean",
+				Help: `Remove cached files.
+
+By default, all files in the cache directories are removed.
+With --older-than, only files last modified before the given age are removed.
+With --max-size, the oldest files in each directory are removed until the
+total size of the directory is no more than the given size.`,
+
+				SetFlags: func(_ *Env, fs *flag.FlagSet) {
+					fs.DurationVar(&olderThan, "older-than", 0, "Remove only files older than this")
+					fs.Var(&maxSize, "max-size", "Remove the oldest files until each directory is no larger than this")
+					fs.BoolVar(&dryRun, "dry-run", false, "Print the files that would be removed, but do not remove them")
+				},
+				Run: Adapt(func(env *Env) error {
+					for _, dir := range dirs {
+						files, err := listCache(dir)
+						if err != nil {
+							return err
+						}
+						var errs []error
+						for _, f := range selectForClean(files, env.Now().Add(-olderThan), olderThan > 0, int64(maxSize)) {
+							if dryRun {
+								fmt.Fprintln(env.Stdout(), f.path)
+							} else if err := os.Remove(f.path); err != nil {
+								errs = append(errs, err)
+							}
+						}
+						if !dryRun {
+							pruneEmptyDirs(dir)
+						}
+						if err := errors.Join(errs...); err != nil {
+							return fmt.Errorf("cleaning %q: %w", dir, err)
+						}
+					}
+					return nil
+				}),
+			},
+		},
+	}
+}
+
+// A cacheFile is a regular file found in a cache directory.
+type cacheFile struct {
+	path  string
+	size  int64
+	mtime time.Time
+}
+
+// listCache returns the regular files under dir, ordered from oldest to
+// newest by modification time. A nonexistent directory is treated as empty.
+func listCache(dir string) ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, cacheFile{path: path, size: fi.Size(), mtime: fi.ModTime()})
+		return nil
+	})
+	slices.SortStableFunc(files, func(a, b cacheFile) int {
+		return a.mtime.Compare(b.mtime)
+	})
+	return files, err
+}
+
+// selectForClean returns the files that should be removed from files, which
+// must be ordered from oldest to newest. If useAge is true, only files older
+// than cutoff are selected.  If maxSize > 0, only enough of the oldest files
+// are selected to bring the total size to at most maxSize.
+func selectForClean(files []cacheFile, cutoff time.Time, useAge bool, maxSize int64) []cacheFile {
+	if !useAge && maxSize <= 0 {
+		return files
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	var out []cacheFile
+	for _, f := range files {
+		old := useAge && f.mtime.Before(cutoff)
+		big := maxSize > 0 && total > maxSize
+		if old || big {
+			out = append(out, f)
+			total -= f.size
+		}
+	}
+	return out
+}
+
+// pruneEmptyDirs removes empty directories below dir, but not dir itself.
+// Errors are ignored, since a directory may legitimately be in use.
+func pruneEmptyDirs(dir string) {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != dir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// Remove deeper paths first, so that parents may become empty.
+	slices.SortFunc(dirs, func(a, b string) int { return cmp.Compare(b, a) })
+	for _, d := range dirs {
+		os.Remove(d) // fails if not empty
+	}
+}
+
+// byteSize is a [flag.Value] for a size in bytes, with an optional unit suffix
+// (K, M, G, T, for powers of 1024).
+type byteSize int64
+
+var sizeUnits = []string{"", "K", "M", "G", "T"}
+
+func (b byteSize) String() string {
+	v, u := float64(b), 0
+	for v >= 1024 && u+1 < len(sizeUnits) {
+		v /= 1024
+		u++
+	}
+	if u == 0 {
+		return strconv.FormatInt(int64(b), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + sizeUnits[u]
+}
+
+func (b *byteSize) Set(s string) error {
+	num := strings.TrimSuffix(strings.ToUpper(s), "B")
+	scale := int64(1)
+	for i := len(sizeUnits) - 1; i > 0; i-- {
+		if t, ok := strings.CutSuffix(num, sizeUnits[i]); ok {
+			num, scale = t, 1<<(10*i)
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return fmt.Errorf("invalid size %q", s)
+	}
+	*b = byteSize(v * float64(scale))
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestCacheClean(t *testing.T) {
+	// Populate a cache directory with files of known size and age.
+	// The files are named in order from oldest to newest.
+	setup := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		now := time.Now()
+		for i, name := range []string{"a", "sub/b", "sub/c", "d"} {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			age := now.Add(-time.Duration(4-i) * time.Hour)
+			if err := os.Chtimes(path, age, age); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	remaining := func(t *testing.T, dir string) []string {
+		t.Helper()
+		var out []string
+		filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+			if rel, _ := filepath.Rel(dir, path); rel != "." {
+				out = append(out, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		return out
+	}
+
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"clean", nil},
+		{"clean --dry-run", []string{"a", "d", "sub", "sub/b", "sub/c"}},
+		{"clean --older-than 150m", []string{"d", "sub", "sub/c"}},
+		{"clean --max-size 25", []string{"d", "sub", "sub/c"}},
+		{"clean --max-size 1k", []string{"a", "d", "sub", "sub/b", "sub/c"}},
+		{"clean --older-than 3h30m --max-size 10", []string{"d"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.args, func(t *testing.T) {
+			dir := setup(t)
+			cmd := command.CacheCommand(dir, filepath.Join(dir, "nonexistent"))
+			if err := command.Run(cmd.NewEnv(nil), strings.Fields(tc.args)); err != nil {
+				t.Fatalf("Run: unexpected error: %v", err)
+			}
+			if got := remaining(t, dir); !slices.Equal(got, tc.want) {
+				t.Errorf("Remaining files: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"time"
+)
+
+// SignalError is the cancellation cause reported when the context of a
+// command is canceled because the process received a signal (see
+// Env.HandleSignals). It satisfies errors.Is(err, context.Canceled).
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (s SignalError) Error() string { return fmt.Sprintf("received signal: %v", s.Signal) }
+
+func (s SignalError) Unwrap() error { return context.Canceled }
+
+// signalNames are the conventional names of common signals, by number.
+// These numbers are the same on all platforms that define them.
+var signalNames = map[int64]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 9: "SIGKILL", 15: "SIGTERM",
+}
+
+// signalNumber returns the number of sig, if it has one.
+func signalNumber(sig os.Signal) (int64, bool) {
+	if v := reflect.ValueOf(sig); v.CanInt() {
+		return v.Int(), true
+	}
+	return 0, false
+}
+
+// HandleSignals arranges for each call to [Run] using e to cancel the context
+// of the command when the process receives any of the specified signals, and
+// returns e. The cancellation cause is a [SignalError]. Once a signal has been
+// received, the default handling of the signals is restored, so that a second
+// signal terminates the program as usual. If no signals are specified, no
+// signals are handled. The setting is inherited by the descendants of e.
+//
+// For example, to cancel on an interrupt (Ctrl-C):
+//
+//	env := root.NewEnv(nil).HandleSignals(os.Interrupt)
+func (e *Env) HandleSignals(sigs ...os.Signal) *Env { e.signals = sigs; return e }
+
+// notifySignals gives e a new context that is canceled when the process
+// receives one of the signals handled by e, and returns a function that stops
+// handling the signals and restores the previous context of e.
+func (e *Env) notifySignals() func() {
+	oldCtx, oldCancel := e.ctx, e.cancel
+	e.SetContext(e.Context())
+	cancel := e.cancel
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, e.signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			cancel(SignalError{Signal: sig})
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+		cancel(nil)
+		e.ctx, e.cancel = oldCtx, oldCancel
+	}
+}
+
+// CanceledError is the error reported by [Run] when a command fails because
+// its context was canceled, for example by a signal (see Env.HandleSignals),
+// a timeout (see C.Timeout), or a call to Env.Cancel. Its message describes
+// the cause of the cancellation for the user, for example:
+//
+//	interrupted by SIGINT
+//	timed out after 30s
+//
+// A CanceledError wraps both the error reported by the command and the cause.
+type CanceledError struct {
+	Err   error // the error reported by the command
+	Cause error // the cause of the cancellation
+}
+
+func (c CanceledError) Error() string {
+	var serr SignalError
+	var terr timeoutError
+	switch {
+	case errors.As(c.Cause, &serr):
+		if n, ok := signalNumber(serr.Signal); ok && signalNames[n] != "" {
+			return "interrupted by " + signalNames[n]
+		}
+		return fmt.Sprintf("interrupted by signal: %v", serr.Signal)
+	case errors.As(c.Cause, &terr):
+		return fmt.Sprintf("timed out after %v", time.Duration(terr))
+	case errors.Is(c.Cause, context.DeadlineExceeded):
+		return "timed out"
+	case c.Cause == context.Canceled:
+		return "canceled"
+	default:
+		return fmt.Sprintf("canceled: %v", c.Cause)
+	}
+}
+
+func (c CanceledError) Unwrap() []error { return []error{c.Err, c.Cause} }
+
+// ExitCode returns the conventional exit status for c. For a signal, this is
+// 128 plus the signal number, or 130 (as for SIGINT) if the signal has no
+// number. For a timeout, it is 124, matching the timeout(1) utility.
+// Otherwise it is 1.
+func (c CanceledError) ExitCode() int {
+	var serr SignalError
+	switch {
+	case errors.As(c.Cause, &serr):
+		if n, ok := signalNumber(serr.Signal); ok {
+			return 128 + int(n)
+		}
+		return 130
+	case errors.Is(c.Cause, context.DeadlineExceeded):
+		return 124
+	default:
+		return 1
+	}
+}
+
+// canceledError returns err translated into a [CanceledError] if it reports
+// that the context of a command was canceled; otherwise it returns err.
+// The cause is taken from err if possible, and otherwise from the context of
+// e.
+func (e *Env) canceledError(err error) error {
+	if err == nil || !(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return err
+	} else if errors.As(err, new(CanceledError)) {
+		return err
+	}
+	var serr SignalError
+	var terr timeoutError
+	var cause error
+	switch {
+	case errors.As(err, &serr):
+		cause = serr
+	case errors.As(err, &terr):
+		cause = terr
+	default:
+		cause = context.Cause(e.Context())
+		if cause == nil {
+			cause = err
+		}
+	}
+	return CanceledError{Err: err, Cause: cause}
+}
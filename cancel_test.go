@@ -0,0 +1,111 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestCanceledError(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	var cancel func(*command.Env)
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:    "wait",
+			Timeout: time.Hour,
+			Run: func(env *command.Env) error {
+				if cancel != nil {
+					cancel(env)
+				}
+				<-env.Context().Done()
+				return env.Context().Err()
+			},
+		}, {
+			Name: "fail",
+			Run:  func(*command.Env) error { return errQuota },
+		}},
+	}
+	tests := []struct {
+		name     string
+		cmd      string
+		cancel   func(*command.Env)
+		timeout  time.Duration
+		wantMsg  string
+		wantCode int
+	}{
+		{"Signal", "wait", func(env *command.Env) {
+			env.Cancel(command.SignalError{Signal: os.Interrupt})
+		}, time.Hour, "interrupted by SIGINT", 130},
+		{"Timeout", "wait", nil, time.Millisecond, "timed out after 1ms", 124},
+		{"Cancel", "wait", func(env *command.Env) { env.Cancel(errQuota) }, time.Hour, "canceled: quota exceeded", 1},
+		{"Other", "fail", nil, time.Hour, "", 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cancel = tc.cancel
+			root.Commands[0].Timeout = tc.timeout
+			env := root.NewEnv(nil).SetContext(context.Background())
+			err := command.Run(env, []string{tc.cmd})
+
+			var cerr command.CanceledError
+			if tc.wantMsg == "" {
+				if errors.As(err, &cerr) {
+					t.Errorf("Run: got %v, want a plain error", err)
+				}
+				return
+			}
+			if !errors.As(err, &cerr) {
+				t.Fatalf("Run: got %[1]T %[1]v, want CanceledError", err)
+			}
+			if got := err.Error(); got != tc.wantMsg {
+				t.Errorf("Error: got %q, want %q", got, tc.wantMsg)
+			}
+			if got := cerr.ExitCode(); got != tc.wantCode {
+				t.Errorf("ExitCode: got %d, want %d", got, tc.wantCode)
+			}
+			if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Run: got %v, want a context error", err)
+			}
+		})
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Sending an interrupt is not supported on Windows")
+	}
+	root := &command.C{
+		Name: "tool",
+		Run: func(env *command.Env) error {
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			if err := p.Signal(os.Interrupt); err != nil {
+				return err
+			}
+			<-env.Context().Done()
+			return env.Context().Err()
+		},
+	}
+	env := root.NewEnv(nil).HandleSignals(os.Interrupt)
+	err := command.Run(env, nil)
+	var serr command.SignalError
+	if !errors.As(err, &serr) || serr.Signal != os.Interrupt {
+		t.Errorf("Run: got %v, want %v", err, os.Interrupt)
+	}
+	if got, want := err.Error(), "interrupted by SIGINT"; got != want {
+		t.Errorf("Error: got %q, want %q", got, want)
+	}
+	if env.Context().Err() != nil {
+		t.Error("Context of env was not restored")
+	}
+}
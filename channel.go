@@ -0,0 +1,69 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import "os"
+
+// A Channel describes how an invocation of a command arrived, so that
+// commands can adapt their output and interactivity to it (see Env.Channel).
+type Channel int
+
+const (
+	// ChannelAuto means the channel is determined from the standard input of
+	// the process: ChannelTerminal if it is a terminal, otherwise ChannelPipe.
+	// This is the default.
+	ChannelAuto Channel = iota
+
+	// ChannelTerminal is an invocation from a shell by an interactive user.
+	ChannelTerminal
+
+	// ChannelPipe is an invocation whose input is not a terminal, such as a
+	// script or a shell pipeline.
+	ChannelPipe
+
+	// ChannelREPL is an invocation from an interactive read-eval-print loop
+	// hosted by the program.
+	ChannelREPL
+
+	// ChannelEmbedded is an invocation by a program that embeds the command
+	// tree, such as a server handling requests.
+	ChannelEmbedded
+
+	// ChannelCompletion is an invocation to compute shell completions.
+	ChannelCompletion
+)
+
+var channelNames = [...]string{"auto", "terminal", "pipe", "repl", "embedded", "completion"}
+
+// String returns a human-readable name for c.
+func (c Channel) String() string {
+	if c >= 0 && int(c) < len(channelNames) {
+		return channelNames[c]
+	}
+	return "unknown"
+}
+
+// Interactive reports whether c permits interaction with a user, that is,
+// whether it is ChannelTerminal or ChannelREPL.
+func (c Channel) Interactive() bool { return c == ChannelTerminal || c == ChannelREPL }
+
+// SetChannel sets the channel by which the invocation of e arrived, and
+// returns e. The setting is inherited by the descendants of e. Entry points
+// other than a command-line program, such as a REPL, a server, or a
+// completion handler, should set the channel before calling [Run].
+func (e *Env) SetChannel(c Channel) *Env { e.channel = c; return e }
+
+// Channel reports the channel by which the invocation of e arrived (see
+// SetChannel). The result is never ChannelAuto: If no channel has been set,
+// Channel reports ChannelTerminal if [os.Stdin] is a terminal, and otherwise
+// ChannelPipe.
+func (e *Env) Channel() Channel {
+	switch {
+	case e.channel != ChannelAuto:
+		return e.channel
+	case isTerminal(os.Stdin):
+		return ChannelTerminal
+	default:
+		return ChannelPipe
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestChannel(t *testing.T) {
+	var got command.Channel
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "sub",
+			Run:  func(env *command.Env) error { got = env.Channel(); return nil },
+		}},
+	}
+
+	// By default, the channel is determined from stdin.
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	defer func(old *os.File) { os.Stdin = old }(os.Stdin)
+	os.Stdin = f
+
+	if err := command.Run(root.NewEnv(nil), []string{"sub"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if got != command.ChannelPipe {
+		t.Errorf("Default channel: got %v, want %v", got, command.ChannelPipe)
+	}
+
+	// A channel set on the root is inherited.
+	for _, ch := range []command.Channel{command.ChannelREPL, command.ChannelEmbedded, command.ChannelCompletion} {
+		if err := command.Run(root.NewEnv(nil).SetChannel(ch), []string{"sub"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		} else if got != ch {
+			t.Errorf("Channel: got %v, want %v", got, ch)
+		}
+	}
+
+	for _, tc := range []struct {
+		ch          command.Channel
+		name        string
+		interactive bool
+	}{
+		{command.ChannelTerminal, "terminal", true},
+		{command.ChannelPipe, "pipe", false},
+		{command.ChannelREPL, "repl", true},
+		{command.ChannelEmbedded, "embedded", false},
+		{command.ChannelCompletion, "completion", false},
+		{command.Channel(100), "unknown", false},
+	} {
+		if got := tc.ch.String(); got != tc.name {
+			t.Errorf("String(%d): got %q, want %q", tc.ch, got, tc.name)
+		}
+		if got := tc.ch.Interactive(); got != tc.interactive {
+			t.Errorf("%v.Interactive(): got %v, want %v", tc.ch, got, tc.interactive)
+		}
+	}
+
+	// The chooser does not prompt on a non-interactive channel.
+	env := root.NewEnv(nil).SetChannel(command.ChannelEmbedded)
+	if _, err := (command.NumberedChooser{}).Choose(env, nil); !errors.Is(err, command.ErrNotInteractive) {
+		t.Errorf("Choose: got %v, want %v", err, command.ErrNotInteractive)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotInteractive is reported by a [Chooser] that cannot interact with the
+// user, for example because the input is not a terminal.
+var ErrNotInteractive = errors.New("not interactive")
+
+// A Chooser interactively selects one of a list of options. When a Chooser is
+// set on an [Env] (see SetChooser), a command that has subcommands but no Run
+// function, invoked with no arguments, uses it to prompt the user to choose
+// one of its subcommands, instead of printing help.
+//
+// Implementations may present a numbered list (see [NumberedChooser]), or
+// delegate to an external tool such as a fuzzy finder.
+type Chooser interface {
+	// Choose prompts the user to select one of the given options, and returns
+	// the Name of the selected option. If the user declines to choose, or
+	// interaction is not possible, Choose reports an error. If the error is
+	// [ErrNotInteractive], the command falls back to printing help.
+	Choose(env *Env, options []HelpInfo) (string, error)
+}
+
+// SetChooser sets the chooser for e and returns e. If c == nil, interactive
+// selection is disabled (the default). The setting is inherited by the
+// descendants of e.
+func (e *Env) SetChooser(c Chooser) *Env { e.chooser = c; return e }
+
+// NumberedChooser is a [Chooser] that prints a numbered list of options and
+// reads the number or name of the selected option from a line of input.
+type NumberedChooser struct {
+	// In is the source of input. If nil, input is read from [os.Stdin], and
+	// the chooser reports [ErrNotInteractive] if it is not a terminal, or if
+	// the channel of the invocation is not interactive (see Env.Channel).
+	In io.Reader
+
+	// Out is where the list and prompt are written. If nil, they are written
+	// to the environment passed to Choose.
+	Out io.Writer
+}
+
+// Choose implements the [Chooser] interface.
+func (n NumberedChooser) Choose(env *Env, options []HelpInfo) (string, error) {
+	in, out := n.In, n.Out
+	if in == nil {
+		if !isTerminal(os.Stdin) || !env.Channel().Interactive() {
+			return "", ErrNotInteractive
+		}
+		in = os.Stdin
+	}
+	if out == nil {
+		out = env
+	}
+	for i, opt := range options {
+		fmt.Fprintf(out, "%3d. %s", i+1, opt.Name)
+		if opt.Synopsis != "" {
+			fmt.Fprint(out, " - ", opt.Synopsis)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprint(out, "Choose a command: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		if err == nil || err == io.EOF {
+			err = errors.New("no command chosen")
+		}
+		return "", err
+	}
+	if i, err := strconv.Atoi(line); err == nil && i >= 1 && i <= len(options) {
+		return options[i-1].Name, nil
+	}
+	for _, opt := range options {
+		if opt.Name == line {
+			return opt.Name, nil
+		}
+	}
+	return "", fmt.Errorf("invalid choice %q", line)
+}
+
+// chooseSubcommand prompts the user to choose a runnable subcommand of
+// env.Command using the chooser of env. It returns nil without error if
+// no chooser is set or interaction is not possible.
+func (e *Env) chooseSubcommand() (*C, error) {
+	if e.chooser == nil || !e.Command.HasRunnableSubcommands() {
+		return nil, nil
+	}
+	var opts []HelpInfo
+	for _, sub := range e.Command.Commands {
+		if sub.Runnable() && sub.Supported() && !sub.Unlisted {
+			opts = append(opts, sub.helpInfo(e.newChild(sub, nil), e.hflag))
+		}
+	}
+	name, err := e.chooser.Choose(e, opts)
+	if errors.Is(err, ErrNotInteractive) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if sub := e.Command.FindSubcommand(name); sub != nil {
+		return sub, nil
+	}
+	return nil, fmt.Errorf("unknown command %q", name)
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestChooser(t *testing.T) {
+	var ran string
+	runAs := func(name string) func(*command.Env) error {
+		return func(*command.Env) error { ran = name; return nil }
+	}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{Name: "status", Help: "Show status.", Run: runAs("status")},
+			{Name: "hidden", Unlisted: true, Run: runAs("hidden")},
+			{Name: "sync", Help: "Synchronize.", Run: runAs("sync")},
+			{Name: "topic", Help: "Not a command."},
+		},
+	}
+
+	tests := []struct {
+		input, want string
+		wantErr     error
+	}{
+		{"1\n", "status", nil},
+		{"2\n", "sync", nil},
+		{"sync\n", "sync", nil},
+		{"3\n", "", errors.New("invalid choice")},
+		{"hidden\n", "", errors.New("invalid choice")},
+		{"", "", errors.New("no command chosen")},
+	}
+	for _, tc := range tests {
+		ran = ""
+		var out strings.Builder
+		env := root.NewEnv(nil).SetChooser(command.NumberedChooser{
+			In: strings.NewReader(tc.input), Out: &out,
+		})
+		err := command.Run(env, nil)
+		if tc.wantErr == nil && err != nil {
+			t.Errorf("Input %q: unexpected error: %v", tc.input, err)
+		} else if tc.wantErr != nil && (err == nil || !strings.Contains(err.Error(), tc.wantErr.Error())) {
+			t.Errorf("Input %q: got error %v, want %v", tc.input, err, tc.wantErr)
+		}
+		if ran != tc.want {
+			t.Errorf("Input %q: ran %q, want %q", tc.input, ran, tc.want)
+		}
+		if want := "  1. status - Show status.\n  2. sync - Synchronize.\n"; !strings.HasPrefix(out.String(), want) {
+			t.Errorf("Chooser output: got %q, want prefix %q", out.String(), want)
+		}
+	}
+
+	// Without a chooser, or without interaction, the command prints help.
+	for _, env := range []*command.Env{
+		root.NewEnv(nil),
+		root.NewEnv(nil).SetChooser(notInteractive{}),
+	} {
+		env.Log = io.Discard
+		if err := command.Run(env, nil); !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Run: got %v, want %v", err, command.ErrRequestHelp)
+		}
+	}
+}
+
+type notInteractive struct{}
+
+func (notInteractive) Choose(*command.Env, []command.HelpInfo) (string, error) {
+	return "", command.ErrNotInteractive
+}
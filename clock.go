@@ -0,0 +1,64 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"context"
+	"time"
+)
+
+// A Clock is a source of time for an [Env] (see Env.SetClock).
+type Clock interface {
+	// Now reports the current time.
+	Now() time.Time
+
+	// AfterFunc arranges to call f in its own goroutine once duration d has
+	// elapsed, and returns a function that cancels the call. The cancel
+	// function reports whether it stopped the call before it happened.
+	AfterFunc(d time.Duration, f func()) (cancel func() bool)
+}
+
+// SetClock sets the clock of e to c, and returns e. If c == nil, it restores
+// the default, the system clock. The setting is inherited by the descendants
+// of e.
+//
+// The clock is used by package features that depend on time, such as command
+// timeouts (see C.Timeout), event timestamps (see Env.Event), and the elapsed
+// times reported by summaries and timing, so that tests can control them (see
+// the commandtest package). Commands should use Env.Now rather than
+// [time.Now] for the same reason.
+func (e *Env) SetClock(c Clock) *Env { e.clock = c; return e }
+
+// Clock returns the clock of e (see SetClock).
+func (e *Env) Clock() Clock {
+	if e.clock == nil {
+		return systemClock{}
+	}
+	return e.clock
+}
+
+// Now reports the current time according to the clock of e (see SetClock).
+func (e *Env) Now() time.Time { return e.Clock().Now() }
+
+// Since reports the time elapsed since t according to the clock of e.
+func (e *Env) Since(t time.Time) time.Duration { return e.Now().Sub(t) }
+
+// systemClock implements Clock using the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// withTimeoutCause returns a copy of ctx that is canceled with the given
+// cause after d has elapsed according to the clock of e.
+func (e *Env) withTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	if e.clock == nil {
+		return context.WithTimeoutCause(ctx, d, cause)
+	}
+	tctx, cancel := context.WithCancelCause(ctx)
+	stop := e.clock.AfterFunc(d, func() { cancel(cause) })
+	return tctx, func() { stop(); cancel(context.Canceled) }
+}
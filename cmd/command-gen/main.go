@@ -0,0 +1,349 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+// Program command-gen generates command definitions for the methods of a Go
+// interface or struct type, for use with the command package.
+//
+// Usage:
+//
+//	//go:generate go run github.com/creachadair/command/cmd/command-gen -type Admin
+//
+// This reads the Go files in the current directory, finds the named type, and
+// writes a file (by default, the lower-cased type name with "_commands.go"
+// appended) that defines a function returning a subcommand for each exported
+// method of the type. For a type T, the function is:
+//
+//	func TCommands(v T) []*command.C   // if T is an interface
+//	func TCommands(v *T) []*command.C  // if T is a struct
+//
+// A method is included if its parameters are an optional leading
+// context.Context followed by string parameters, optionally ending with a
+// variadic ...string or a []string, and it returns either error or (R, error)
+// for some type R. Other methods are skipped with a warning.  When a method
+// returns a result, the generated command prints it with fmt.Println.
+//
+// Each command is named for its method, converted to lower case with words
+// separated by hyphens ("ListUsers" becomes "list-users"). Its usage lists the
+// parameter names, and its help is the doc comment of the method.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	typeName = flag.String("type", "", "Name of the type whose methods to convert (required)")
+	output   = flag.String("output", "", "Output file name (default <type>_commands.go)")
+)
+
+func main() {
+	flag.Parse()
+	if *typeName == "" {
+		log.Fatal("You must provide a -type name")
+	}
+	src, err := parseDir(".")
+	if err != nil {
+		log.Fatalf("Parsing: %v", err)
+	}
+	code, warnings, err := generate(src, *typeName)
+	for _, w := range warnings {
+		log.Printf("Warning: %s", w)
+	}
+	if err != nil {
+		log.Fatalf("Generating: %v", err)
+	}
+	out := *output
+	if out == "" {
+		out = strings.ToLower(*typeName) + "_commands.go"
+	}
+	if err := os.WriteFile(out, code, 0644); err != nil {
+		log.Fatalf("Writing output: %v", err)
+	}
+}
+
+// parseDir parses the non-test Go files in dir.
+func parseDir(dir string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// A method describes a method to convert into a command.
+type method struct {
+	name    string   // the Go method name
+	doc     string   // the doc comment text
+	ctx     bool     // whether the first parameter is a context.Context
+	params  []string // the names of the string parameters
+	rest    string   // the name of the rest parameter, if any
+	isVar   bool     // whether the rest parameter is variadic
+	results int      // the number of results (1 or 2)
+}
+
+// generate returns the source of a file defining commands for the methods of
+// the named type declared in files. It also returns warnings for methods
+// that were skipped.
+func generate(files []*ast.File, name string) ([]byte, []string, error) {
+	if len(files) == 0 {
+		return nil, nil, errors.New("no Go files found")
+	}
+	pkg := files[0].Name.Name
+
+	var isStruct, found bool
+	var methods []method
+	var warnings []string
+	add := func(fname string, doc *ast.CommentGroup, ft *ast.FuncType) {
+		if !ast.IsExported(fname) {
+			return
+		}
+		m, err := checkMethod(fname, doc, ft)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipping %s.%s: %v", name, fname, err))
+			return
+		}
+		methods = append(methods, m)
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok || ts.Name.Name != name {
+						continue
+					}
+					found = true
+					switch t := ts.Type.(type) {
+					case *ast.InterfaceType:
+						for _, fld := range t.Methods.List {
+							if ft, ok := fld.Type.(*ast.FuncType); ok && len(fld.Names) == 1 {
+								add(fld.Names[0].Name, fld.Doc, ft)
+							}
+						}
+					case *ast.StructType:
+						isStruct = true
+					default:
+						return nil, nil, fmt.Errorf("type %s is not an interface or struct", name)
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && len(d.Recv.List) == 1 && receiverName(d.Recv.List[0].Type) == name {
+					add(d.Name.Name, d.Doc, d.Type)
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("type %s not found", name)
+	}
+	if len(methods) == 0 {
+		return nil, warnings, fmt.Errorf("type %s has no methods that can be converted", name)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by command-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	needFmt := false
+	for _, m := range methods {
+		needFmt = needFmt || m.results == 2
+	}
+	fmt.Fprintln(&buf, "import (")
+	if needFmt {
+		fmt.Fprintln(&buf, `"fmt"`)
+	}
+	fmt.Fprint(&buf, "\n\"github.com/creachadair/command\"\n)\n\n")
+
+	recv := name
+	if isStruct {
+		recv = "*" + name
+	}
+	fmt.Fprintf(&buf, "// %sCommands returns commands for the methods of v.\n", name)
+	fmt.Fprintf(&buf, "func %sCommands(v %s) []*command.C {\nreturn []*command.C{\n", name, recv)
+	for _, m := range methods {
+		writeCommand(&buf, m)
+	}
+	fmt.Fprint(&buf, "}\n}\n")
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, warnings, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return code, warnings, nil
+}
+
+// receiverName returns the base type name of a receiver expression.
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// checkMethod reports whether a method with the given signature can be
+// converted into a command, and if so returns its description.
+func checkMethod(name string, doc *ast.CommentGroup, ft *ast.FuncType) (method, error) {
+	m := method{name: name, doc: strings.TrimSpace(doc.Text())}
+
+	var params []*ast.Field
+	if ft.Params != nil {
+		params = ft.Params.List
+	}
+	for i, p := range params {
+		names := fieldNames(p, i)
+		switch t := p.Type.(type) {
+		case *ast.SelectorExpr:
+			if i != 0 || !isContext(t) {
+				return m, errors.New("unsupported parameter type")
+			}
+			m.ctx = true
+			continue
+		case *ast.Ident:
+			if t.Name == "string" {
+				if m.rest != "" {
+					return m, errors.New("parameters follow the rest parameter")
+				}
+				m.params = append(m.params, names...)
+				continue
+			}
+		case *ast.Ellipsis:
+			if id, ok := t.Elt.(*ast.Ident); ok && id.Name == "string" && i == len(params)-1 {
+				m.rest, m.isVar = names[0], true
+				continue
+			}
+		case *ast.ArrayType:
+			if id, ok := t.Elt.(*ast.Ident); ok && id.Name == "string" && t.Len == nil && i == len(params)-1 {
+				m.rest = names[0]
+				continue
+			}
+		}
+		return m, errors.New("unsupported parameter type")
+	}
+
+	var results []*ast.Field
+	if ft.Results != nil {
+		results = ft.Results.List
+	}
+	var nres int
+	for _, r := range results {
+		nres += max(len(r.Names), 1)
+	}
+	if nres == 0 || nres > 2 {
+		return m, errors.New("must return error or (R, error)")
+	}
+	if last, ok := results[len(results)-1].Type.(*ast.Ident); !ok || last.Name != "error" {
+		return m, errors.New("last result must be error")
+	}
+	m.results = nres
+	return m, nil
+}
+
+// fieldNames returns the names of the parameters in p, which is the ith
+// parameter field. Unnamed parameters are given placeholder names, and names
+// that would collide with names used by the generated code are renamed.
+func fieldNames(p *ast.Field, i int) []string {
+	if len(p.Names) == 0 {
+		return []string{fmt.Sprintf("arg%d", i+1)}
+	}
+	var names []string
+	for _, id := range p.Names {
+		switch id.Name {
+		case "_":
+			names = append(names, fmt.Sprintf("arg%d", i+1+len(names)))
+		case "command", "env", "err", "fmt", "out", "v":
+			names = append(names, id.Name+"Arg")
+		default:
+			names = append(names, id.Name)
+		}
+	}
+	return names
+}
+
+func isContext(sel *ast.SelectorExpr) bool {
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == "context" && sel.Sel.Name == "Context"
+}
+
+// writeCommand writes a command literal for m to buf.
+func writeCommand(buf *bytes.Buffer, m method) {
+	var usage, params, args []string
+	for _, p := range m.params {
+		usage = append(usage, "<"+p+">")
+		params = append(params, p)
+		args = append(args, p)
+	}
+	if m.ctx {
+		args = append([]string{"env.Context()"}, args...)
+	}
+	sig := "env *command.Env"
+	if len(params) != 0 {
+		sig += ", " + strings.Join(params, ", ") + " string"
+	}
+	if m.rest != "" {
+		usage = append(usage, "["+m.rest+"...]")
+		if m.isVar {
+			sig += ", " + m.rest + " ...string"
+			args = append(args, m.rest+"...")
+		} else {
+			sig += ", " + m.rest + " []string"
+			args = append(args, m.rest)
+		}
+	}
+	call := fmt.Sprintf("v.%s(%s)", m.name, strings.Join(args, ", "))
+
+	fmt.Fprintf(buf, "{\nName: %q,\n", commandName(m.name))
+	if len(usage) != 0 {
+		fmt.Fprintf(buf, "Usage: %q,\n", strings.Join(usage, " "))
+	}
+	if m.doc != "" {
+		fmt.Fprintf(buf, "Help: %s,\n", strconv.Quote(m.doc))
+	}
+	fmt.Fprintf(buf, "Run: command.Adapt(func(%s) error {\n", sig)
+	if m.results == 2 {
+		fmt.Fprintf(buf, "out, err := %s\nif err != nil {\nreturn err\n}\nfmt.Println(out)\nreturn nil\n", call)
+	} else {
+		fmt.Fprintf(buf, "return %s\n", call)
+	}
+	fmt.Fprint(buf, "}),\n},\n")
+}
+
+// commandName converts a Go identifier to lower case with words separated
+// by hyphens.
+func commandName(s string) string {
+	var sb strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+			sb.WriteByte('-')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const testSource = `package admin
+
+import "context"
+
+// Admin is the administrative interface of a service.
+type Admin interface {
+	// ListUsers prints the users in a group.
+	ListUsers(ctx context.Context, group string) error
+
+	// AddUser adds users to a group.
+	// Each user is added separately.
+	AddUser(group string, users ...string) error
+
+	// GetStatus returns the service status.
+	GetStatus(context.Context) (string, error)
+
+	Tag(env string, names []string) error
+
+	// Count is skipped, since it has an unsupported parameter.
+	Count(n int) error
+
+	unexported() error
+}
+
+type Server struct{}
+
+// Restart restarts the server.
+func (s *Server) Restart(ctx context.Context) error { return nil }
+
+func (Server) Reload(path string) error { return nil }
+
+func (s *Server) Stop() {}
+
+func (s *Server) private() error { return nil }
+`
+
+func parseTestSource(t *testing.T) []*ast.File {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "admin.go", testSource, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return []*ast.File{f}
+}
+
+func TestGenerateInterface(t *testing.T) {
+	code, warnings, err := generate(parseTestSource(t), "Admin")
+	if err != nil {
+		t.Fatalf("generate: unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Admin.Count") {
+		t.Errorf("Warnings: got %q, want one for Count", warnings)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "out.go", code, 0); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	for _, want := range []string{
+		"// Code generated by command-gen. DO NOT EDIT.",
+		"package admin",
+		`"fmt"`,
+		"func AdminCommands(v Admin) []*command.C {",
+		`Name:  "list-users",`,
+		`Usage: "<group>",`,
+		`Help:  "ListUsers prints the users in a group.",`,
+		"func(env *command.Env, group string) error {\n\t\t\t\treturn v.ListUsers(env.Context(), group)",
+		`Usage: "<group> [users...]",`,
+		"return v.AddUser(group, users...)",
+		`"AddUser adds users to a group.\nEach user is added separately."`,
+		"out, err := v.GetStatus(env.Context())",
+		"fmt.Println(out)",
+		"func(env *command.Env, envArg string, names []string) error {",
+		"return v.Tag(envArg, names)",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("Generated code is missing %q:\n%s", want, code)
+		}
+	}
+	if strings.Contains(string(code), "unexported") || strings.Contains(string(code), "v.Count") {
+		t.Errorf("Generated code includes skipped methods:\n%s", code)
+	}
+}
+
+func TestGenerateStruct(t *testing.T) {
+	code, warnings, err := generate(parseTestSource(t), "Server")
+	if err != nil {
+		t.Fatalf("generate: unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Server.Stop") {
+		t.Errorf("Warnings: got %q, want one for Stop", warnings)
+	}
+	for _, want := range []string{
+		"func ServerCommands(v *Server) []*command.C {",
+		"return v.Restart(env.Context())",
+		"return v.Reload(path)",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("Generated code is missing %q:\n%s", want, code)
+		}
+	}
+	if strings.Contains(string(code), `"fmt"`) {
+		t.Errorf("Generated code imports fmt unnecessarily:\n%s", code)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	files := parseTestSource(t)
+	for _, name := range []string{"Nonesuch", "ListUsers"} {
+		if _, _, err := generate(files, name); err == nil {
+			t.Errorf("generate %q: got nil, want error", name)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"List", "list"},
+		{"ListUsers", "list-users"},
+		{"GetHTTPStatus", "get-http-status"},
+		{"ID", "id"},
+	}
+	for _, tc := range tests {
+		if got := commandName(tc.in); got != tc.want {
+			t.Errorf("commandName(%q): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
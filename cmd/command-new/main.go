@@ -0,0 +1,216 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+// Program command-new generates the skeleton of a new command for a command
+// tree built with the command package.
+//
+// Usage:
+//
+//	go run github.com/creachadair/command/cmd/command-new -name list-users
+//
+// This writes two files to the current directory (or the directory given by
+// -dir), named for the command with hyphens replaced by underscores:
+//
+//	list_users.go       defines listUsersCommand, returning a new *command.C
+//	list_users_test.go  a test that runs the command with the commandtest package
+//
+// The command has placeholder Usage and Help text, a SetFlags function, and a
+// Run function wrapped by command.Adapt, each of which should be edited to
+// suit. The package name is taken from the existing Go files in the directory,
+// or may be set with -package. Existing files are not overwritten.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var (
+	cmdName = flag.String("name", "", "Name of the command to generate (required)")
+	pkgName = flag.String("package", "", "Package name (default from existing files, or main)")
+	outDir  = flag.String("dir", ".", "Output directory")
+)
+
+func main() {
+	flag.Parse()
+	if *cmdName == "" {
+		log.Fatal("You must provide a -name for the command")
+	}
+	pkg := *pkgName
+	if pkg == "" {
+		var err error
+		pkg, err = packageName(*outDir)
+		if err != nil {
+			log.Fatalf("Finding package name: %v", err)
+		}
+	}
+	files, err := generate(*cmdName, pkg)
+	if err != nil {
+		log.Fatalf("Generating: %v", err)
+	}
+	for _, f := range files {
+		path := filepath.Join(*outDir, f.name)
+		if _, err := os.Stat(path); err == nil {
+			log.Fatalf("Output file %q already exists", path)
+		}
+	}
+	for _, f := range files {
+		path := filepath.Join(*outDir, f.name)
+		if err := os.WriteFile(path, f.data, 0644); err != nil {
+			log.Fatalf("Writing output: %v", err)
+		}
+		log.Printf("Wrote %s", path)
+	}
+}
+
+// packageName returns the name of the package of the non-test Go files in
+// dir, or "main" if there are none.
+func packageName(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return f.Name.Name, nil
+	}
+	return "main", nil
+}
+
+// A file is a generated output file.
+type file struct {
+	name string
+	data []byte
+}
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// generate returns the files for a command with the given name in package pkg.
+func generate(name, pkg string) ([]file, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid command name %q (use lower-case words separated by hyphens)", name)
+	} else if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	ident := identName(name)
+	data := struct {
+		Package, Name, Ident, Exported string
+	}{Package: pkg, Name: name, Ident: ident, Exported: strings.ToUpper(ident[:1]) + ident[1:]}
+	base := strings.ReplaceAll(name, "-", "_")
+
+	var out []file
+	for _, f := range []struct {
+		name string
+		tmpl *template.Template
+	}{
+		{base + ".go", sourceTemplate},
+		{base + "_test.go", testTemplate},
+	} {
+		var buf bytes.Buffer
+		if err := f.tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		code, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("formatting %s", f.name), err)
+		}
+		out = append(out, file{name: f.name, data: code})
+	}
+	return out, nil
+}
+
+// identName converts a hyphenated command name to a Go identifier in lower
+// camel case, for example "list-users" becomes "listUsers".
+func identName(name string) string {
+	var sb strings.Builder
+	for i, word := range strings.Split(name, "-") {
+		if i > 0 && word != "" {
+			rs := []rune(word)
+			rs[0] = unicode.ToUpper(rs[0])
+			word = string(rs)
+		}
+		sb.WriteString(word)
+	}
+	return sb.String()
+}
+
+var sourceTemplate = template.Must(template.New("source").Parse(`package {{.Package}}
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/creachadair/command"
+)
+
+// {{.Ident}}Flags are the flag values for the {{.Name}} command.
+var {{.Ident}}Flags struct {
+	Verbose bool
+}
+
+// {{.Ident}}Command returns a new {{.Name}} command.
+func {{.Ident}}Command() *command.C {
+	return &command.C{
+		Name:  "{{.Name}}",
+		Usage: "<arg>",
+		Help: ` + "`" + `TODO: Summarize the {{.Name}} command in one line.
+
+TODO: Describe the {{.Name}} command in detail.` + "`" + `,
+
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&{{.Ident}}Flags.Verbose, "verbose", false, "Enable verbose output")
+		},
+
+		Run: command.Adapt(func(env *command.Env, arg string) error {
+			// TODO: Implement the {{.Name}} command.
+			if {{.Ident}}Flags.Verbose {
+				fmt.Fprintf(env, "{{.Name}}: %s\n", arg)
+			}
+			return nil
+		}),
+	}
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`package {{.Package}}
+
+import (
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/commandtest"
+)
+
+func Test{{.Exported}}Command(t *testing.T) {
+	cmd := {{.Ident}}Command()
+	root := &command.C{Name: "test", Commands: []*command.C{cmd}}
+
+	var ran bool
+	commandtest.BeforeRun(t, cmd, commandtest.Func(func() { ran = true }))
+	if err := command.Run(root.NewEnv(nil), []string{"{{.Name}}", "arg"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("The {{.Name}} command did not run")
+	}
+
+	// TODO: Check the results of the {{.Name}} command.
+}
+`))
@@ -0,0 +1,70 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	files, err := generate("list-users", "cli")
+	if err != nil {
+		t.Fatalf("generate: unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0].name != "list_users.go" || files[1].name != "list_users_test.go" {
+		t.Fatalf("generate: got %d files, want list_users.go and list_users_test.go", len(files))
+	}
+	for _, f := range files {
+		pf, err := parser.ParseFile(token.NewFileSet(), f.name, f.data, 0)
+		if err != nil {
+			t.Errorf("Parse %s: %v\n%s", f.name, err, f.data)
+			continue
+		}
+		if pf.Name.Name != "cli" {
+			t.Errorf("Package %s: got %q, want cli", f.name, pf.Name.Name)
+		}
+	}
+	for _, want := range []string{
+		"func listUsersCommand() *command.C {",
+		`Name:  "list-users",`,
+		"Run: command.Adapt(func(env *command.Env, arg string) error {",
+	} {
+		if !strings.Contains(string(files[0].data), want) {
+			t.Errorf("Source is missing %q:\n%s", want, files[0].data)
+		}
+	}
+	if want := "func TestListUsersCommand(t *testing.T) {"; !strings.Contains(string(files[1].data), want) {
+		t.Errorf("Test is missing %q:\n%s", want, files[1].data)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, tc := range []struct{ name, pkg string }{
+		{"", "main"},
+		{"List", "main"},
+		{"list-", "main"},
+		{"list users", "main"},
+		{"list", "not-a-package"},
+	} {
+		if _, err := generate(tc.name, tc.pkg); err == nil {
+			t.Errorf("generate(%q, %q): got nil, want error", tc.name, tc.pkg)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	dir := t.TempDir()
+	if got, err := packageName(dir); err != nil || got != "main" {
+		t.Errorf("Empty directory: got %q, %v; want main", got, err)
+	}
+	os.WriteFile(filepath.Join(dir, "a_test.go"), []byte("package other_test\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("package other\n"), 0644)
+	if got, err := packageName(dir); err != nil || got != "other" {
+		t.Errorf("Package directory: got %q, %v; want other", got, err)
+	}
+}
@@ -22,9 +22,18 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
+	"math/rand/v2"
+	"net/http"
 	"os"
+	"runtime"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 )
 
 // Env is the environment passed to the Run and Init functions of a command.  The
@@ -52,14 +61,149 @@ type Env struct {
 	// parsed.
 	Args []string
 
+	// UnknownFlags are the flag arguments not defined by the command or its
+	// ancestors, in the order they were given, when the unknown flag policy is
+	// UnknownFlagCollect (see Env.SetUnknownFlags).
+	UnknownFlags []string
+
+	// Extra are the flag arguments not defined by the command, in the order
+	// they were given, when the command has CaptureUnknownFlags set.
+	Extra []string
+
 	// Log, if non-nil, is where diagnostic output is written when an Env
 	// is used as an [io.Writer]. If nil, it defaults to [os.Stderr].
 	Log io.Writer // where to write diagnostic output (nil for os.Stderr)
 
 	ctx       context.Context
 	cancel    context.CancelCauseFunc
-	skipMerge bool      // default: merge flags later in the argument list
-	hflag     HelpFlags // default: no unlisted commands, no private flags
+	skipMerge bool                // default: merge flags later in the argument list
+	pflag     bool                // default: standard flag syntax only
+	hflag     HelpFlags           // default: no unlisted commands, no private flags
+	secrets   SecretStore         // default: files under the user config directory
+	client    *http.Client        // default: http.DefaultClient
+	metrics   Metrics             // default: no metrics
+	errTmpl   *template.Template  // default: defaultErrorTemplate
+	noHint    bool                // default: show help hints with errors
+	chooser   Chooser             // default: no interactive selection
+	prog      string              // default: base name of os.Args[0]
+	multi     bool                // invoked as a multi-call program (see RunAs)
+	onSummary func(*Env, Summary) // default: no summary hook
+	unknown   UnknownFlagPolicy   // default: UnknownFlagError
+	helpArgs  []string            // default: defaultHelpTriggers
+	signals   []os.Signal         // default: no signals handled
+	stdout    io.Writer           // default: os.Stdout
+	stdin     io.Reader           // default: os.Stdin
+	onPipe    bool                // default: report broken pipes as errors
+	pipeCode  int                 // exit code for a broken pipe, if onPipe
+	bufMode   BufferMode          // default: BufferAuto
+	usage     bool                // default: do not track usage
+	channel   Channel             // default: ChannelAuto
+	numbers   bool                // default: negative numbers are flags
+	negate    bool                // default: no implicit --no-name flags
+	format    *Format             // default: format values with fmt.Sprint
+	environ   map[string]string   // default: the process environment
+	fsys      fs.FS               // default: the OS file system
+	clock     Clock               // default: the system clock
+	random    rand.Source         // default: the global random source
+	noCache   bool                // default: use cached completions
+	logKeep   int                 // default: no run logs
+	width     func(string) int    // default: DisplayWidth
+	inv       *invocation         // state shared by a single invocation of Run
+}
+
+// invocation records state shared by all the environments that participate
+// in a single call to [Run].
+type invocation struct {
+	mu      sync.Mutex
+	active  bool     // a call to Run is in progress
+	done    bool     // the invocation has completed
+	defers  []func() // deferred cleanup functions, in order of registration
+	tempDir string   // temporary directory, if created
+
+	shutdown []func(error) error // shutdown hooks, in order of registration
+
+	start  time.Time   // when the invocation began
+	timed  bool        // whether to report timings (see TimeFlag)
+	json   bool        // whether to write JSON output (see OutputFlag)
+	phases []phaseTime // timings of completed phases, if timed
+
+	config   *ConfigFile // configuration file, if loaded (see UseConfigFile)
+	warnings []string    // warnings reported by Warnf
+	summary  *Summary    // summary set by SetSummary, if any
+	sumEnv   *Env        // the environment that set the summary
+
+	traceChecked bool // whether tracing has been checked (see Env.Tracing)
+	tracing      bool // whether tracing is enabled
+	brokenPipe   bool // whether a write to Stdout found a broken pipe
+
+	outputs []*outputBuffer        // buffers for primary output (see Env.Stdout)
+	log     atomic.Pointer[runLog] // run log, if enabled (see Env.LogRuns)
+	reached *Env                   // the last environment reached by traversal
+}
+
+// invocation returns the invocation state for e, creating it if necessary.
+func (e *Env) invocation() *invocation {
+	if e.inv == nil || e.inv.done {
+		e.inv = new(invocation)
+	}
+	return e.inv
+}
+
+// finish marks the invocation as completed and calls its deferred functions
+// in last-in, first-out order.
+func (v *invocation) finish() {
+	v.mu.Lock()
+	defers := v.defers
+	v.defers, v.done = nil, true
+	v.mu.Unlock()
+	for i := len(defers) - 1; i >= 0; i-- {
+		defers[i]()
+	}
+}
+
+// stop calls the shutdown hooks of the invocation in last-in, first-out order,
+// passing each the error reported by its predecessor, and returns the result.
+func (v *invocation) stop(err error) error {
+	v.mu.Lock()
+	hooks := v.shutdown
+	v.shutdown = nil
+	v.mu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		err = hooks[i](err)
+	}
+	return err
+}
+
+// Defer registers f to be called when the current invocation of [Run]
+// completes, whether it succeeds, fails, or panics. Deferred functions are
+// called in last-in, first-out order after the command returns.
+//
+// If e is not part of an active call to Run, f is deferred until the next call
+// to Run using e completes.
+func (e *Env) Defer(f func()) {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.defers = append(inv.defers, f)
+}
+
+// TempDir returns the path of a temporary directory for use by the current
+// invocation of [Run]. The directory is created on the first call, and it and
+// its contents are removed automatically when the invocation completes (see
+// Defer). Subsequent calls during the same invocation return the same path.
+func (e *Env) TempDir() (string, error) {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.tempDir == "" {
+		dir, err := os.MkdirTemp("", e.ProgramName()+"-*")
+		if err != nil {
+			return "", err
+		}
+		inv.tempDir = dir
+		inv.defers = append(inv.defers, func() { os.RemoveAll(dir) })
+	}
+	return inv.tempDir, nil
 }
 
 // Context returns the context associated with e. If e does not have its own
@@ -98,6 +242,35 @@ func (e *Env) SetContext(ctx context.Context) *Env {
 	return e
 }
 
+// setTimeout sets a deadline d from now on the context of e.  It returns a
+// function that restores the previous context of e, and translates an error
+// caused by the deadline or other cancellation of the context into a
+// [CanceledError].
+func (e *Env) setTimeout(d time.Duration) func(error) error {
+	cause := timeoutError(d)
+	oldCtx, oldCancel := e.ctx, e.cancel
+	ctx, cancel := e.withTimeoutCause(e.Context(), d, cause)
+	e.SetContext(ctx)
+	return func(err error) error {
+		defer cancel()
+		if errors.Is(err, context.DeadlineExceeded) && context.Cause(ctx) == cause {
+			err = CanceledError{Err: err, Cause: cause}
+		}
+		err = e.canceledError(err) // before the context is restored
+		e.ctx, e.cancel = oldCtx, oldCancel
+		return err
+	}
+}
+
+// timeoutError is the cause reported when a command timeout expires.
+type timeoutError time.Duration
+
+func (t timeoutError) Error() string {
+	return fmt.Sprintf("deadline exceeded after %v", time.Duration(t))
+}
+
+func (timeoutError) Unwrap() error { return context.DeadlineExceeded }
+
 // MergeFlags sets the flag merge option for e and returns e.
 //
 // Setting this option true modifies the flag parsing algorithm for commands
@@ -124,6 +297,97 @@ func (e *Env) SetContext(ctx context.Context) *Env {
 // will shadow the flag for the descendant.
 func (e *Env) MergeFlags(merge bool) *Env { e.skipMerge = !merge; return e }
 
+// BoolNegation sets whether each Boolean flag of a command has an implicit
+// negated form, and returns e. When this is enabled, "--no-name" is accepted
+// as a synonym for "--name=false" for a Boolean flag "name", unless the
+// command defines a flag named "no-name" itself. This allows the user to
+// override a flag whose default is true. The negated forms are not listed in
+// help output. The default is false.
+//
+// Setting the BoolNegation option also applies to all the descendants of e
+// unless the command's Init callback changes the setting.
+func (e *Env) BoolNegation(enable bool) *Env { e.negate = enable; return e }
+
+// NegativeNumbers sets whether arguments that are negative numbers, such as
+// "-5" or "-2.5", are treated as free arguments rather than flags, and returns
+// e. A negative number that is the name of a flag of the command, such as
+// "-1" for a flag named "1", is still a flag. The default is false, so that a
+// negative number is an undefined flag unless it follows "--".
+//
+// Setting the NegativeNumbers option also applies to all the descendants of
+// e unless the command's Init callback changes the setting.
+func (e *Env) NegativeNumbers(enable bool) *Env { e.numbers = enable; return e }
+
+// PflagStyle sets the short flag syntax option for e and returns e.
+//
+// The standard flag package already accepts "--name value", "--name=value",
+// "-n value", and "-n=value" for every flag.  Setting this option true makes
+// flag parsing for commands dispatched through e also accept the short flag
+// forms of the POSIX and pflag conventions:
+//
+//   - A cluster of single-letter Boolean flags, as in "-abc" for "-a -b -c".
+//   - A single-letter flag with an attached value, as in "-ofile" for
+//     "-o file". This may end a cluster, as in "-vofile" for "-v -o file".
+//
+// An argument is rewritten only if it is not itself the name of a flag and
+// every letter up to the first attached value names a single-letter flag of
+// the command. Arguments after "--" are not rewritten.  The default is false.
+//
+// Setting the PflagStyle option also applies to all the descendants of e
+// unless the command's Init callback changes the setting.
+func (e *Env) PflagStyle(enable bool) *Env { e.pflag = enable; return e }
+
+// UnknownFlagPolicy is a setting that controls how a flag argument that is not
+// defined by a command is handled (see Env.SetUnknownFlags).
+type UnknownFlagPolicy int
+
+const (
+	UnknownFlagError   UnknownFlagPolicy = iota // report a usage error (the default)
+	UnknownFlagWarn                             // ignore the flag with a warning
+	UnknownFlagCollect                          // add the flag to Env.UnknownFlags
+)
+
+// SetUnknownFlags sets the policy for flag arguments not defined by a command
+// dispatched through e, and returns e. The default is [UnknownFlagError].
+//
+// With [UnknownFlagWarn], an undefined flag is removed from the arguments and
+// reported with Warnf. With [UnknownFlagCollect], it is removed from the
+// arguments and added to the UnknownFlags field of the environment, where the
+// command may handle it, for example by passing it to a program it wraps.
+//
+// Because the type of an undefined flag is unknown, its value is only kept
+// with it if attached, as in "--name=value". Otherwise, the value is treated
+// as a free argument. A policy applies only to flags that would otherwise be
+// reported as errors: When flags are merged, an undefined flag that follows a
+// subcommand name is left for the subcommand to parse.
+//
+// The setting is inherited by the descendants of e.
+func (e *Env) SetUnknownFlags(p UnknownFlagPolicy) *Env { e.unknown = p; return e }
+
+// SetHelpTriggers sets the arguments that request help for the commands
+// dispatched through e, in addition to the -h and -help flags, and returns e.
+// For example, a program for an audience familiar with Windows conventions
+// might use:
+//
+//	env.SetHelpTriggers("-?", "/?")
+//
+// A trigger requests long help for a command when it is the last argument on
+// the command line, and follows the name of the command and its flags, as in
+// "tool sub -?". A trigger that names a subcommand is dispatched to that
+// subcommand instead.
+//
+// By default, the only trigger is the word "help", so that for example
+// "tool db migrate help" prints help for "tool db migrate", as in git and
+// kubectl. Calling SetHelpTriggers with no arguments disables all triggers.
+// The setting is inherited by the descendants of e.
+func (e *Env) SetHelpTriggers(args ...string) *Env {
+	e.helpArgs = append([]string{}, args...) // non-nil, to replace the default
+	return e
+}
+
+// defaultHelpTriggers are the help triggers used when none are set.
+var defaultHelpTriggers = []string{"help"}
+
 // HelpFlags sets the base help flags for e and returns e.
 //
 // By default, help listings do not include unlisted commands or private flags.
@@ -132,10 +396,11 @@ func (e *Env) HelpFlags(f HelpFlags) *Env { e.hflag = (f &^ IncludeCommands); re
 
 // output returns the log writer for c.
 func (e *Env) output() io.Writer {
-	if e.Log != nil {
-		return e.Log
+	w := e.Log
+	if w == nil {
+		w = os.Stderr
 	}
-	return os.Stderr
+	return e.invocation().teeLog(w)
 }
 
 func (e *Env) newChild(cmd *C, cargs []string) *Env {
@@ -161,24 +426,99 @@ func (e *Env) parseFlags(rawArgs []string) error {
 	}
 	e.Command.Flags.Usage = func() {}
 	e.Command.Flags.SetOutput(io.Discard)
+	restartFlags(&e.Command.Flags)
 	toParse := rawArgs
+	if e.negate {
+		toParse = negateBoolFlags(&e.Command.Flags, toParse)
+	}
+	if e.pflag {
+		toParse = expandShortFlags(&e.Command.Flags, toParse)
+	}
+	implicitHelp := e.implicitHelp()
+	if e.Command.CaptureUnknownFlags {
+		toParse, e.Extra = captureUnknownFlags(&e.Command.Flags, toParse, implicitHelp, e.numbers)
+	}
 	if !e.skipMerge {
-		flags, free, err := splitFlags(&e.Command.Flags, rawArgs)
+		flags, free, err := splitFlags(&e.Command.Flags, toParse)
 		if err != nil {
-			return err
+			return e.Usagef("%v", err)
 		}
 		toParse = joinArgs(flags, free)
 	}
-	err := e.Command.Flags.Parse(toParse)
+	if e.unknown != UnknownFlagError {
+		var unknown []string
+		toParse, unknown = splitUnknownFlags(&e.Command.Flags, toParse, implicitHelp, e.numbers)
+		for _, arg := range unknown {
+			if e.unknown == UnknownFlagWarn {
+				e.Warnf("ignoring unknown flag %q", arg)
+			} else {
+				e.UnknownFlags = append(slices.Clip(e.UnknownFlags), arg)
+			}
+		}
+	}
+	var trigger string
+	if e.helpTrigger(toParse) {
+		trigger, toParse = toParse[len(toParse)-1], toParse[:len(toParse)-1]
+	}
+	if e.numbers {
+		toParse = protectNumbers(&e.Command.Flags, toParse)
+	}
+	if e.Command.FlagParser != nil && implicitHelp && hasHelpFlag(&e.Command.Flags, toParse) {
+		return printLongHelp(e, nil) // the parser may not recognize the flag
+	}
+	args, err := e.Command.parseArgs(toParse)
+	if err == nil && trigger != "" {
+		if len(args) == 0 {
+			return printLongHelp(e, nil) // the trigger is for this command
+		}
+		// The trigger is for a subcommand, or is a free argument.
+		e.Args = append(args, trigger)
+		return nil
+	}
 	if errors.Is(err, flag.ErrHelp) {
+		if !implicitHelp {
+			// The flag package reports an undefined help flag as a request for
+			// help; report it as undefined instead.
+			_, unknown := splitUnknownFlags(&e.Command.Flags, toParse, false, e.numbers)
+			return e.Usagef("flag provided but not defined: %s", unknown[0])
+		}
 		return printLongHelp(e, nil)
 	} else if err != nil {
-		return err
+		return e.Usagef("%v", err)
 	}
-	e.Args = e.Command.Flags.Args()
+	e.Args = args
 	return nil
 }
 
+// helpTrigger reports whether the last of args is a help trigger for e (see
+// Env.SetHelpTriggers), and not the value of a flag or an argument after "--".
+func (e *Env) helpTrigger(args []string) bool {
+	triggers := e.helpArgs
+	if triggers == nil {
+		triggers = defaultHelpTriggers
+	}
+	n := len(args)
+	if n == 0 || !slices.Contains(triggers, args[n-1]) || e.Command.FindSubcommand(args[n-1]) != nil {
+		return false
+	} else if slices.Contains(args[:n-1], "--") {
+		return false
+	}
+	_, _, err := splitFlags(&e.Command.Flags, args[:n-1])
+	return err == nil
+}
+
+// implicitHelp reports whether the -h and -help flags request help for the
+// command of e when the command does not define them, that is, unless the
+// command or one of its ancestors has NoImplicitHelp set.
+func (e *Env) implicitHelp() bool {
+	for cur := e; cur != nil; cur = cur.Parent {
+		if cur.Command.NoImplicitHelp {
+			return false
+		}
+	}
+	return true
+}
+
 // C carries the description and invocation function for a command.
 //
 // To process a command-line, the [Run] function walks through the argument
@@ -192,7 +532,8 @@ func (e *Env) parseFlags(rawArgs []string) error {
 // and/or subcommands.
 //
 // After flags are prepared, before attempting to explore subcommands, the
-// current command's Init hook is called (if set). If Init reports an error, it
+// current command's Init hook is called (if set). For the root command, the
+// Startup hook (if set) is called first. If Init reports an error, it
 // terminates argument traversal and that error is reported back to the
 // user. When CustomFlags is true, Init may handle option processing and update
 // its [Env] parameter as needed before argument processing continues.
@@ -226,6 +567,19 @@ type C struct {
 	// string is printed for long help.
 	Help string
 
+	// If set, this is called to compute the help text for the command, and the
+	// result is used in place of Help. This allows help to include details
+	// only known at runtime, such as the available plugins or formats. The
+	// env is the environment of the command if help is rendered during a call
+	// to [Run], otherwise it is a new root environment for the command.
+	HelpFunc func(env *Env) string
+
+	// If set on the root command, these terms are defined in a generated
+	// "glossary" topic of its "help" subcommand, and the help text of each
+	// command in the tree is followed by the definitions of the terms it
+	// uses. See [Term].
+	Glossary []Term
+
 	// Flags parsed from the raw argument list. This will be initialized before
 	// Init or Run is called.
 	Flags flag.FlagSet
@@ -234,13 +588,106 @@ type C struct {
 	// function is responsible for parsing flags from the argument list.
 	CustomFlags bool
 
+	// If set, this is used to parse the argument list in place of Flags, for
+	// example to use another flag library. Before and after SetFlags is
+	// called, each flag of the parser not defined in Flags is added to Flags,
+	// sharing its value, so that help, flag merging, and the other features
+	// that use Flags apply to the flags of the parser. SetFlags may use Flags
+	// to annotate the flags the parser defines before it is called (see for
+	// example [MarkRequired]). Names that share a value are listed as aliases
+	// (see [Alias]). The flags set by parsing are determined as described for
+	// [FlagChanger]. This has no effect if CustomFlags is true.
+	FlagParser FlagParser
+
+	// If set, this function defines the order in which flags are listed in
+	// help output, as a comparison function for [slices.SortStableFunc]
+	// applied to flags in lexicographic order by name.  If nil, flags are
+	// listed in lexicographic order. See also [FlagsInOrder].
+	FlagOrder func(a, b *flag.Flag) int
+
+	// If set, flags of this command and its descendants that are not set on
+	// the command line are read from environment variables with this prefix
+	// (see Env.SetEnviron), before Init is called. The variable for a flag is
+	// named by the prefix, the names of the commands below this one, and the
+	// name of the flag, joined by underscores, in upper case, with punctuation
+	// replaced by underscores. For example, given the prefix "MYTOOL", the --dry-run flag
+	// of "mytool remote add" is read from MYTOOL_REMOTE_ADD_DRY_RUN.
+	//
+	// A value from the environment takes precedence over a configuration file
+	// (see UseConfigFile). The help for each flag names its variable. A
+	// descendant may set its own prefix, which applies to it and its
+	// descendants instead.
+	FlagEnvPrefix string
+
+	// If true, flag arguments not defined by the command are removed from its
+	// arguments before parsing, and stored in the Extra field of its [Env] in
+	// the order they were given, instead of causing an error. This is useful
+	// for a command that wraps another program, to forward flags intended for
+	// that program. Flags anywhere before "--" are captured, including those
+	// after free arguments. The value of a captured flag is kept with it only
+	// if attached, as in "--name=value".
+	//
+	// This is intended for commands that do not have subcommands, since the
+	// flags of a subcommand are not defined by the command.
+	CaptureUnknownFlags bool
+
+	// If true, the -h and -help flags do not request help for this command
+	// and its subcommands, unless they define those flags themselves. Instead,
+	// they are treated like any other undefined flag. This is useful for a
+	// command that wraps another program, to forward them to that program
+	// (see CaptureUnknownFlags). Help for the command remains available by
+	// other means, such as a "help" subcommand of the root.
+	NoImplicitHelp bool
+
 	// If true, exclude this command from help listings unless it is explicitly
 	// named and requested.
 	Unlisted bool
 
+	// If non-empty, the command is only supported on these operating systems,
+	// named as by runtime.GOOS (for example "linux" or "windows"). On other
+	// systems the command and its subcommands are omitted from help, and an
+	// attempt to run it reports an error wrapping [errors.ErrUnsupported].
+	Platforms []string
+
+	// If positive, the command and its subcommands must complete within this
+	// duration after flags are parsed. The deadline is applied to the context
+	// of the command's environment, and a context error caused by it is
+	// reported as a [CanceledError], "timed out after" the duration.
+	//
+	// See also [TimeoutFlag].
+	Timeout time.Duration
+
+	// If positive, at most this many calls to the Run function of this command
+	// may be active concurrently. This is useful when a command tree is shared
+	// by concurrent callers, as in a server. Additional calls wait until a slot
+	// is available or their context ends, unless FailIfBusy is true.
+	MaxConcurrent int
+
+	// If true, a call that would exceed MaxConcurrent fails immediately with
+	// [ErrBusy] instead of waiting.
+	FailIfBusy bool
+
 	// Perform the action of the command. If nil, calls FailWithUsage.
 	Run func(env *Env) error
 
+	// If set, and this is the root command of a call to [Run], this is called
+	// after its flags are parsed and before its Init function. Unlike Init,
+	// it is not called when the command is reached as a subcommand, and it is
+	// called at most once per process, no matter how many times Run is called.
+	// If it reports an error, execution stops and that error is returned.
+	//
+	// This is intended for process-wide setup, such as configuring logging
+	// or starting a profiling server, that should wrap the entire dispatch.
+	Startup func(env *Env) error
+
+	// If set, this is called once when the call to [Run] in which Startup was
+	// called completes, after the command has finished, with the error it
+	// reported (or nil). The error returned by Shutdown replaces the one
+	// reported by the command. Shutdown is not called if Startup fails.
+	//
+	// Shutdown is called before any functions registered with Defer.
+	Shutdown func(env *Env, err error) error
+
 	// If set, this will be called before flags are parsed, to give the command
 	// an opportunity to set flags.
 	SetFlags func(env *Env, fs *flag.FlagSet)
@@ -253,15 +700,109 @@ type C struct {
 	// will persist through the rest of the invocation.
 	Init func(env *Env) error
 
+	// If set, this is called to write a usage summary for the command to w, in
+	// place of the default rendering. It is used for the synopsis printed when
+	// the command has no Run function, by FailWithUsage, and for the usage
+	// shown with a [UsageError] by RunOrFail. This allows a command to show
+	// information only known at runtime, such as a list of valid targets.
+	// Long help (for example from --help) is not affected.
+	UsageFunc func(env *Env, w io.Writer)
+
 	// Subcommands of this command.
 	Commands []*C
 
-	isFlagSet bool // true if SetFlags was invoked
+	// If set, the name of a subcommand to run when this command has no Run
+	// function and is invoked with no arguments, instead of printing help.
+	// For example, if the root command has Default "status", running the
+	// program with no arguments is the same as running "tool status". Help
+	// listings mark the default subcommand.
+	Default string
+
+	// If set, this is called when the first non-flag argument to the command
+	// matches the name of one of its subcommands, to decide whether to dispatch
+	// to that subcommand (true) or to treat the argument as an ordinary free
+	// argument to this command (false). If nil, a matching subcommand is always
+	// preferred.
+	//
+	// This allows a command that has subcommands to also accept free arguments
+	// that may collide with their names, such as the name of a resource.
+	PreferSubcommand func(name string) bool
+
+	// If set, this returns the known values that the first free argument to the
+	// command may take, such as the names of resources. This is used by Vet to
+	// detect collisions between arguments and subcommand names.
+	ArgValues func() []string
+
+	// If set, this is called to compute shell completion candidates for a
+	// free argument of the command (see Complete). The Args field of env holds
+	// the free arguments before the one being completed. If nil, the values
+	// reported by ArgValues are offered for the first free argument.
+	// See also [CompleteFiles].
+	CompleteArgs Completer
+
+	isFlagSet bool          // true if SetFlags was invoked
+	started   bool          // true if Startup was invoked
+	sem       chan struct{} // concurrency limiter; see MaxConcurrent
 }
 
 // Runnable reports whether the command has any action defined.
 func (c *C) Runnable() bool { return c != nil && (c.Run != nil || c.Init != nil) }
 
+// Supported reports whether c is supported on the current operating system
+// (see the Platforms field).
+func (c *C) Supported() bool {
+	return c != nil && (len(c.Platforms) == 0 || slices.Contains(c.Platforms, runtime.GOOS))
+}
+
+// selectSubcommand reports the subcommand of e.Command that the traversal of
+// the arguments of e continues with, and the arguments to pass to it, as Run
+// does. A runnable subcommand named by the first argument takes precedence;
+// otherwise, if e.Command has no Run function, its Default subcommand is
+// selected. If there is no subcommand to select, sub == nil.
+//
+// If the first argument names a topic that has subcommands but no arguments
+// follow it, selectSubcommand returns the topic with topic == true, and Run
+// shows help for it. If e.Command has no Run function and does not understand
+// its arguments, selectSubcommand prints a diagnostic and reports
+// ErrRequestHelp.
+func (e *Env) selectSubcommand() (sub *C, rest []string, topic bool, _ error) {
+	cmd := e.Command
+	if len(e.Args) != 0 {
+		sub, rest := cmd.FindSubcommand(e.Args[0]), e.Args[1:]
+		if sub != nil && cmd.PreferSubcommand != nil && !cmd.PreferSubcommand(e.Args[0]) {
+			sub = nil // treat the argument as a free argument
+		}
+		hasSub := sub.HasRunnableSubcommands()
+
+		if sub.Runnable() || (hasSub && len(rest) != 0) {
+			return sub, rest, false, nil // a runnable subcommand takes precedence
+		} else if hasSub {
+			return sub, rest, true, nil
+		} else if cmd.Run == nil {
+			fmt.Fprintf(e, "Error: %s command %q not understood\n", cmd.Name, e.Args[0])
+			if hint := e.helpHint(); hint != "" {
+				fmt.Fprintln(e, hint)
+			}
+			return nil, nil, false, ErrRequestHelp
+		}
+		return nil, nil, false, nil
+	}
+	if sub := cmd.FindSubcommand(cmd.Default); cmd.Run == nil && cmd.Default != "" && sub.Runnable() {
+		return sub, nil, false, nil
+	}
+	return nil, nil, false, nil
+}
+
+// unsupportedError is the error reported for a command that is not supported
+// on the current operating system.
+type unsupportedError struct{ env *Env }
+
+func (u unsupportedError) Error() string {
+	return fmt.Sprintf("command %q is not supported on %s", strings.Join(u.env.displayPath(), " "), runtime.GOOS)
+}
+
+func (unsupportedError) Unwrap() error { return errors.ErrUnsupported }
+
 // HasRunnableSubcommands reports whether c has any runnable subcommands.
 func (c *C) HasRunnableSubcommands() bool {
 	if c != nil {
@@ -277,6 +818,34 @@ func (c *C) HasRunnableSubcommands() bool {
 // NewEnv returns a new root context for c with the optional config value.
 func (c *C) NewEnv(config any) *Env { return &Env{Command: c, Config: config} }
 
+// Clone returns a copy of the command tree rooted at c. Each command in the
+// clone is a new value, with a fresh flag set that has not been parsed and
+// none of the bookkeeping recorded by earlier runs, so the clone can be run
+// without disturbing the flag state of c.
+//
+// If a command has a SetFlags function, its clone starts with no flags
+// defined, and the flags are populated by SetFlags when the clone is run.
+// Otherwise, the clone has the same flags as the original. Either way, the
+// variables the flags are bound to are shared: SetFlags typically binds the
+// flags of every clone to the same captured variables. Functions and the
+// closures they capture are also shared, not copied. Clones that share such
+// state must not be run concurrently unless that state is safe for
+// concurrent use.
+func (c *C) Clone() *C {
+	cp := *c // shallow copy
+	cp.Flags = copyFlagSet(&c.Flags, c.SetFlags == nil)
+	cp.isFlagSet = false
+	cp.started = false
+	cp.sem = nil
+	if c.Commands != nil {
+		cp.Commands = make([]*C, len(c.Commands))
+		for i, sub := range c.Commands {
+			cp.Commands[i] = sub.Clone()
+		}
+	}
+	return &cp
+}
+
 // FindSubcommand returns the subcommand of c matching name, or nil.
 func (c *C) FindSubcommand(name string) *C {
 	for _, cmd := range c.Commands {
@@ -287,9 +856,81 @@ func (c *C) FindSubcommand(name string) *C {
 	return nil
 }
 
+// semMu serializes the creation of concurrency limiters for commands.
+var semMu sync.Mutex
+
+// acquire obtains a slot to run c, subject to its concurrency limit, and
+// returns a function to release the slot.
+func (c *C) acquire(ctx context.Context) (func(), error) {
+	if c.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	semMu.Lock()
+	if c.sem == nil || cap(c.sem) != c.MaxConcurrent {
+		c.sem = make(chan struct{}, c.MaxConcurrent)
+	}
+	sem := c.sem
+	semMu.Unlock()
+
+	release := func() { <-sem }
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	default:
+		if c.FailIfBusy {
+			return nil, ErrBusy
+		}
+	}
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// startMu serializes the startup of commands.
+var startMu sync.Mutex
+
+// startup calls the Startup hook of e.Command, if it has not already been
+// called, and registers its Shutdown hook with the current invocation.
+func (e *Env) startup() error {
+	c := e.Command
+	if c.Startup == nil && c.Shutdown == nil {
+		return nil
+	}
+	startMu.Lock()
+	started := c.started
+	c.started = true
+	startMu.Unlock()
+	if started {
+		return nil
+	}
+
+	if c.Startup != nil {
+		done := e.timePhase("startup")
+		err := c.Startup(e)
+		done()
+		if err != nil {
+			return fmt.Errorf("starting %q: %v", c.Name, err)
+		}
+	}
+	if c.Shutdown != nil {
+		inv := e.invocation()
+		inv.mu.Lock()
+		defer inv.mu.Unlock()
+		inv.shutdown = append(inv.shutdown, func(err error) error { return c.Shutdown(e, err) })
+	}
+	return nil
+}
+
 // ErrRequestHelp is returned from Run if the user requested help.
 var ErrRequestHelp = errors.New("help requested")
 
+// ErrBusy is reported by Run if a command has reached its limit of concurrent
+// invocations, and is configured to fail rather than wait.
+var ErrBusy = errors.New("too many concurrent invocations")
+
 // UsageError is the concrete type of errors reported by the Usagef function,
 // indicating an error in the usage of a command.
 type UsageError struct {
@@ -305,6 +946,66 @@ func (e *Env) Usagef(msg string, args ...any) error {
 	return UsageError{Env: e, Message: fmt.Sprintf(msg, args...)}
 }
 
+// IsUsage reports whether err is or wraps a [UsageError].
+func IsUsage(err error) bool {
+	var uerr UsageError
+	return errors.As(err, &uerr)
+}
+
+// IsHelpRequest reports whether err is or wraps [ErrRequestHelp].
+func IsHelpRequest(err error) bool { return errors.Is(err, ErrRequestHelp) }
+
+// ExitCode returns the conventional process exit code for an error reported
+// by [Run], as used by [RunOrFail]:
+//
+//   - 0 if err == nil
+//   - 2 for a [UsageError] or [ErrRequestHelp]
+//   - the ExitCode of a [CanceledError], e.g., 130 for an interrupt
+//   - 141 (128 plus the number of SIGPIPE) for [ErrBrokenPipe]
+//   - 1 for any other error
+//
+// Note that for a broken pipe, RunOrFail instead uses the code given to
+// Env.ExitOnBrokenPipe.
+func ExitCode(err error) int {
+	var cerr CanceledError
+	switch {
+	case err == nil:
+		return 0
+	case IsUsage(err), IsHelpRequest(err):
+		return 2
+	case errors.As(err, &cerr):
+		return cerr.ExitCode()
+	case errors.Is(err, ErrBrokenPipe):
+		return 141
+	default:
+		return 1
+	}
+}
+
+// Warnf reports a non-fatal problem to the user. The formatted message is
+// written to the diagnostic output of e prefixed by "Warning: ", and recorded
+// so that it can be retrieved after the command completes (see Warnings).
+// Unlike an error, a warning does not stop the execution of the command.
+func (e *Env) Warnf(msg string, args ...any) {
+	text := fmt.Sprintf(msg, args...)
+	inv := e.invocation()
+	inv.mu.Lock()
+	inv.warnings = append(inv.warnings, text)
+	inv.mu.Unlock()
+	fmt.Fprintln(e, "Warning:", text)
+}
+
+// Warnings returns the messages reported by Warnf during the current or most
+// recent call to [Run] using e, in the order they were reported.
+func (e *Env) Warnings() []string {
+	if e.inv == nil {
+		return nil
+	}
+	e.inv.mu.Lock()
+	defer e.inv.mu.Unlock()
+	return slices.Clone(e.inv.warnings)
+}
+
 // PanicError is the concrete type of errors reported by the [Run] function
 // when a panic occurs in the Init or Run function of a command during the
 // dispatch process. The caller may capture this error with [errors.As] to
@@ -329,26 +1030,28 @@ func (p PanicError) Stack() string { return string(p.stack) }
 // Value returns the value raised with the panic captured by p.
 func (p PanicError) Value() any { return p.value }
 
-// RunOrFail behaves as Run, but prints a log message and calls [os.Exit] if
-// the command reports an error. If the command succeeds, RunOrFail returns.
+// RunOrFail behaves as Run, but prints an error report to env and calls
+// [os.Exit] if the command reports an error. If the command succeeds,
+// RunOrFail returns.
+//
+// The error report includes the error message and, for a [UsageError], a
+// usage summary for the command that reported it. The format of the report
+// may be customized using the SetErrorTemplate method of env.
 //
 // If a command reports a [UsageError] or [ErrRequestHelp], the exit code is 2.
-// For any other error the exit code is 1.
+// If the command was canceled, the exit code is given by the ExitCode method
+// of the [CanceledError]. If the command failed because of a broken pipe and
+// env handles broken pipes, RunOrFail exits quietly with the code given to
+// Env.ExitOnBrokenPipe. For any other error the exit code is 1. See also
+// [ExitCode].
 func RunOrFail(env *Env, rawArgs []string) {
 	if err := Run(env, rawArgs); err != nil {
-		var uerr UsageError
-		if errors.As(err, &uerr) {
-			log.Printf("Error: %s", uerr.Message)
-			uerr.Env.Command.HelpInfo(env.hflag).WriteUsage(uerr.Env)
-		} else if !errors.Is(err, ErrRequestHelp) {
-			log.Printf("Error: %v", err)
-			var pe PanicError
-			if errors.As(err, &pe) {
-				log.Printf("Stack trace from panic:\n%s", pe.Stack())
-			}
-			os.Exit(1)
+		if env.onPipe && errors.Is(err, ErrBrokenPipe) {
+			os.Exit(env.pipeCode)
+		} else if !IsHelpRequest(err) {
+			env.writeErrorReport(err)
 		}
-		os.Exit(2)
+		os.Exit(ExitCode(err))
 	}
 }
 
@@ -360,8 +1063,99 @@ func RunOrFail(env *Env, rawArgs []string) {
 // help via the --help flag.
 //
 // If the Init or Run function of a command panics, the error reported by Run
-// is a [PanicError].
+// is a [PanicError]. If a command fails because its context was canceled,
+// for example by a signal or a timeout, the error reported by Run is a
+// [CanceledError] describing the cause.
+//
+// When Run returns, the Shutdown hook of the root command (if any) and any
+// functions registered by the command with Defer have been called.
 func Run(env *Env, rawArgs []string) (err error) {
+	// If this is not a nested call within an active invocation, start a new
+	// one and clean it up when the command is finished.
+	if inv := env.invocation(); !inv.active {
+		inv.active, inv.start = true, env.Now()
+		defer inv.finish()
+		if len(env.signals) != 0 {
+			defer env.notifySignals()()
+		}
+		env.openLog()
+		defer func() {
+			err = inv.stop(inv.flushOutput(err))
+			err = env.checkBrokenPipe(env.canceledError(inv.flushOutput(err)))
+			if err == nil {
+				inv.summarize()
+			}
+			inv.writeTimings(env)
+			env.closeLog(rawArgs, err)
+		}()
+	}
+	return run(env, rawArgs)
+}
+
+// RunE behaves as [Run], but also returns the environment of the command
+// selected by the argument traversal, whether or not it succeeded. The caller
+// may use this environment to inspect the command that was resolved, the
+// values of its flags, and its remaining arguments. If the traversal fails,
+// the environment is that of the deepest command it reached.
+func RunE(env *Env, rawArgs []string) (*Env, error) {
+	inv := env.invocation()
+	err := Run(env, rawArgs)
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.reached == nil {
+		return env, err
+	}
+	return inv.reached, err
+}
+
+// prepare performs the steps of the traversal for the command of e that come
+// before its Startup and Init functions, for Run and Resolve. It defines the
+// flags of the command and parses them from rawArgs, records the settings
+// selected by the flags (see TimeFlag and OutputFlag), warns about deprecated
+// flags, applies values from the configuration file and the environment,
+// expands and checks path flags (see PathVar), and checks flag constraints.
+// If secrets is true, it also reads the secret flags
+// whose values name a source (see SecretVar), as Run does but Resolve does
+// not. It returns the timeout for the command (see checkTimeoutFlag).
+func (e *Env) prepare(rawArgs []string, secrets bool) (time.Duration, error) {
+	cmd := e.Command
+	e.Args = rawArgs
+	if !cmd.Supported() {
+		return 0, unsupportedError{e}
+	}
+
+	// If the command defines a flag setter, invoke it.
+	cmd.setFlags(e, &cmd.Flags)
+
+	// Unless this command does custom flag parsing, parse the arguments and
+	// check for errors before passing control to the handler.
+	if err := e.parseFlags(rawArgs); err != nil {
+		return 0, err
+	}
+
+	e.checkTimeFlag()
+	e.checkOutputFlag()
+	timeout := e.checkTimeoutFlag()
+	e.warnDeprecated()
+	if err := e.applyConfig(); err != nil {
+		return 0, err
+	} else if err := e.applyFlagEnv(); err != nil {
+		return 0, err
+	} else if err := e.resolvePaths(); err != nil {
+		return 0, err
+	} else if secrets {
+		if err := e.resolveSecrets(); err != nil {
+			return 0, err
+		}
+	}
+	if err := e.checkFlagRules(); err != nil {
+		return 0, err
+	}
+	return timeout, nil
+}
+
+// run implements the argument traversal for Run.
+func run(env *Env, rawArgs []string) (err error) {
 	defer func() {
 		if x := recover(); x != nil {
 			err = PanicError{env: env, stack: debug.Stack(), value: x}
@@ -369,42 +1163,55 @@ func Run(env *Env, rawArgs []string) (err error) {
 		env.Cancel(err)
 	}()
 	cmd := env.Command
-	env.Args = rawArgs
+	inv := env.invocation()
+	inv.mu.Lock()
+	inv.reached = env
+	inv.mu.Unlock()
+	timeout, err := env.prepare(rawArgs, true)
+	if err != nil {
+		return err
+	}
 
-	// If the command defines a flag setter, invoke it.
-	cmd.setFlags(env, &cmd.Flags)
+	// If this is the root command, give it a chance to set up.
+	if env.Parent == nil {
+		if err := env.startup(); err != nil {
+			return err
+		}
+	}
 
-	// Unless this command does custom flag parsing, parse the arguments and
-	// check for errors before passing control to the handler.
-	if err := env.parseFlags(rawArgs); err != nil {
-		return err
+	// If the command has a timeout, apply it to the remainder of the subtree.
+	if timeout > 0 {
+		stop := env.setTimeout(timeout)
+		defer func() { err = stop(err) }()
 	}
 
 	if cmd.Init != nil {
-		if err := cmd.Init(env); err != nil {
+		done := env.timePhase("init")
+		err := cmd.Init(env)
+		done()
+		if err != nil {
 			return fmt.Errorf("initializing %q: %v", cmd.Name, err)
 		}
 	}
 
 	// Unclaimed (non-flag) arguments may be free arguments for this command, or
 	// may belong to a subcommand.
-	if len(env.Args) != 0 {
-		sub, rest := cmd.FindSubcommand(env.Args[0]), env.Args[1:]
-		hasSub := sub.HasRunnableSubcommands()
-
-		if sub.Runnable() || (hasSub && len(rest) != 0) {
-			// A runnable subcommand takes precedence.
-			return Run(env.newChild(sub, rest), rest)
-		} else if hasSub && len(rest) == 0 {
-			// Show help for a topic subcommand with subcommands of its own.
-			return printLongHelp(env.newChild(sub, rest), nil)
-		} else if cmd.Run == nil {
-			fmt.Fprintf(env, "Error: %s command %q not understood\n", cmd.Name, env.Args[0])
-			return ErrRequestHelp
-		}
+	if sub, rest, topic, err := env.selectSubcommand(); err != nil {
+		return err
+	} else if topic {
+		// Show help for a topic subcommand with subcommands of its own.
+		return printLongHelp(env.newChild(sub, rest), nil)
+	} else if sub != nil {
+		return run(env.newChild(sub, rest), rest)
 	}
 	if cmd.Run == nil {
+		// If the command has subcommands, the user may choose one.
+		if sub, err := env.chooseSubcommand(); err != nil {
+			return err
+		} else if sub != nil {
+			return run(env.newChild(sub, nil), nil)
+		}
 		return printShortHelp(env)
 	}
-	return cmd.Run(env)
+	return env.runCommand()
 }
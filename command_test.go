@@ -3,9 +3,20 @@
 package command_test
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/creachadair/command"
 )
@@ -36,3 +47,446 @@ func TestRun_panic(t *testing.T) {
 	}
 	t.Log("--- Captured panic stack (not a panic in the test, don't worry):\n", got.Stack())
 }
+
+func TestStartupShutdown(t *testing.T) {
+	var log []string
+	logf := func(msg string) { log = append(log, msg) }
+	root := &command.C{
+		Name: "root",
+		Startup: func(env *command.Env) error {
+			logf("startup")
+			env.Defer(func() { logf("defer") })
+			return nil
+		},
+		Shutdown: func(env *command.Env, err error) error {
+			logf("shutdown")
+			return fmt.Errorf("shutdown: %w", err)
+		},
+		Init: func(*command.Env) error { logf("init"); return nil },
+		Commands: []*command.C{{
+			Name:     "sub",
+			Startup:  func(*command.Env) error { logf("sub startup"); return nil },
+			Shutdown: func(*command.Env, error) error { logf("sub shutdown"); return nil },
+			Init:     func(*command.Env) error { logf("sub init"); return nil },
+			Run:      func(*command.Env) error { logf("run"); return errors.New("bad") },
+		}},
+	}
+
+	err := command.Run(root.NewEnv(nil), []string{"sub"})
+	if got, want := fmt.Sprint(err), "shutdown: bad"; got != want {
+		t.Errorf("Run: got error %q, want %q", got, want)
+	}
+	if want := []string{"startup", "init", "sub init", "run", "shutdown", "defer"}; !slices.Equal(log, want) {
+		t.Errorf("First run: got %q, want %q", log, want)
+	}
+
+	// A second call does not repeat startup or shutdown.
+	log = nil
+	if err := command.Run(root.NewEnv(nil), []string{"sub"}); err == nil || err.Error() != "bad" {
+		t.Errorf("Run: got error %v, want bad", err)
+	}
+	if want := []string{"init", "sub init", "run"}; !slices.Equal(log, want) {
+		t.Errorf("Second run: got %q, want %q", log, want)
+	}
+
+	// A failed startup stops execution, and shutdown is not called.
+	log = nil
+	fail := root.Clone()
+	fail.Startup = func(*command.Env) error { return errors.New("no") }
+	if err := command.Run(fail.NewEnv(nil), []string{"sub"}); err == nil || !strings.Contains(err.Error(), "no") {
+		t.Errorf("Run: got error %v, want startup failure", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("Failed startup: got %q, want no calls", log)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	var log []string
+	var tmp string
+	cmd := &command.C{
+		Name: "test",
+		Run: func(env *command.Env) error {
+			env.Defer(func() { log = append(log, "first") })
+			env.Defer(func() { log = append(log, "second") })
+
+			dir, err := env.TempDir()
+			if err != nil {
+				t.Fatalf("TempDir: unexpected error: %v", err)
+			}
+			if again, _ := env.TempDir(); again != dir {
+				t.Errorf("TempDir: got %q, want %q", again, dir)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "x"), []byte("x"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			tmp = dir
+			panic("boo")
+		},
+	}
+	env := cmd.NewEnv(nil)
+	env.Defer(func() { log = append(log, "before") })
+
+	if err := command.Run(env, nil); err == nil {
+		t.Fatal("Run: got nil, want panic error")
+	}
+	if want := []string{"second", "first", "before"}; !slices.Equal(log, want) {
+		t.Errorf("Deferred calls: got %q, want %q", log, want)
+	}
+	if _, err := os.Stat(tmp); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Temp directory %q was not removed: %v", tmp, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	wait := func(env *command.Env) error {
+		<-env.Context().Done()
+		return fmt.Errorf("waiting: %w", env.Context().Err())
+	}
+	cmd := &command.C{
+		Name:     "test",
+		SetFlags: command.TimeoutFlag,
+		Commands: []*command.C{{
+			Name: "sub",
+			Run:  wait,
+		}, {
+			Name:    "fixed",
+			Timeout: time.Millisecond,
+			Run:     wait,
+		}},
+	}
+
+	for _, args := range []string{"--timeout 5ms sub", "fixed"} {
+		err := command.Run(cmd.NewEnv(nil), strings.Fields(args))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run %q: got %v, want %v", args, err, context.DeadlineExceeded)
+		} else if !strings.HasPrefix(err.Error(), "timed out after") {
+			t.Errorf("Run %q: got %q, want timed out after ...", args, err)
+		}
+	}
+
+	// The flag does not modify the command, or carry over to a later run.
+	if cmd.Timeout != 0 {
+		t.Errorf("Timeout: got %v, want 0", cmd.Timeout)
+	}
+	cmd.Commands[0].Run = func(env *command.Env) error {
+		if _, ok := env.Context().Deadline(); ok {
+			return errors.New("unexpected deadline")
+		}
+		return nil
+	}
+	if err := command.Run(cmd.NewEnv(nil), []string{"sub"}); err != nil {
+		t.Errorf("Run without --timeout: %v", err)
+	}
+
+	// Nor does a value given to Resolve.
+	if _, err := command.Resolve(cmd.NewEnv(nil), []string{"--timeout=1h", "sub"}); err != nil {
+		t.Fatalf("Resolve: unexpected error: %v", err)
+	}
+	if err := command.Run(cmd.NewEnv(nil), []string{"sub"}); err != nil {
+		t.Errorf("Run after Resolve: %v", err)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	start, stop := make(chan struct{}), make(chan struct{})
+	cmd := &command.C{
+		Name:          "busy",
+		MaxConcurrent: 1,
+		Run: func(env *command.Env) error {
+			close(start)
+			<-stop
+			return nil
+		},
+	}
+	done := make(chan error)
+	go func() { done <- command.Run(cmd.NewEnv(nil), nil) }()
+	<-start
+
+	// With the only slot occupied, a fail-fast call reports ErrBusy.
+	cmd.FailIfBusy = true
+	if err := command.Run(cmd.NewEnv(nil), nil); !errors.Is(err, command.ErrBusy) {
+		t.Errorf("Run: got %v, want %v", err, command.ErrBusy)
+	}
+
+	// A waiting call gives up when its context ends.
+	cmd.FailIfBusy = false
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := command.Run(cmd.NewEnv(nil).SetContext(ctx), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("Run: unexpected error: %v", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	type config struct{ n int }
+	orig := &command.C{
+		Name: "root",
+		Commands: []*command.C{{
+			Name: "sub",
+			SetFlags: func(env *command.Env, fs *flag.FlagSet) {
+				fs.IntVar(&env.Config.(*config).n, "n", 0, "A number")
+			},
+			Run: func(env *command.Env) error {
+				if got, want := env.Config.(*config).n, len(env.Args); got != want {
+					return fmt.Errorf("n = %d, want %d", got, want)
+				}
+				return nil
+			},
+		}},
+	}
+
+	// Run clones of the tree concurrently with different flag values, each
+	// bound to a separate config. Run with -race to check for sharing.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			args := append([]string{"sub", "-n", strconv.Itoa(i)}, make([]string, i)...)
+			if err := command.Run(orig.Clone().NewEnv(new(config)), args); err != nil {
+				t.Errorf("Run %d: unexpected error: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if orig.Commands[0].Flags.Lookup("n") != nil {
+		t.Error("Running a clone modified the original flag set")
+	}
+}
+
+func TestPreferSubcommand(t *testing.T) {
+	var got string
+	get := &command.C{
+		Name:  "get",
+		Usage: "<name>\nlist",
+		Run: command.Adapt(func(env *command.Env, name string) error {
+			got = "get " + name
+			return nil
+		}),
+		Commands: []*command.C{{
+			Name: "list",
+			Run: func(env *command.Env) error {
+				got = "list " + strings.Join(env.Args, ",")
+				return nil
+			},
+		}},
+	}
+	root := &command.C{Name: "tool", Commands: []*command.C{get}}
+
+	tests := []struct {
+		prefer func(string) bool
+		args   string
+		want   string
+	}{
+		{nil, "get list", "list "},
+		{nil, "get foo", "get foo"},
+
+		// When the hook declines, a resource named "list" can be retrieved.
+		{func(string) bool { return false }, "get list", "get list"},
+		{func(string) bool { return false }, "get foo", "get foo"},
+		{func(string) bool { return true }, "get list", "list "},
+	}
+	for _, tc := range tests {
+		got = ""
+		get.PreferSubcommand = tc.prefer
+		if err := command.Run(root.NewEnv(nil), strings.Fields(tc.args)); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		} else if got != tc.want {
+			t.Errorf("Run %q: got %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	other := "plan9"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+	var ran []string
+	runner := func(env *command.Env) error {
+		ran = append(ran, env.Command.Name)
+		return nil
+	}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{Name: "here", Platforms: []string{other, runtime.GOOS}, Help: "Supported here.", Run: runner},
+			{Name: "elsewhere", Platforms: []string{other}, Help: "Supported elsewhere.", Run: runner},
+			{Name: "anywhere", Help: "Supported anywhere.", Run: runner},
+		},
+	}
+
+	for _, name := range []string{"here", "anywhere"} {
+		if err := command.Run(root.NewEnv(nil), []string{name}); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", name, err)
+		}
+	}
+	err := command.Run(root.NewEnv(nil), []string{"elsewhere"})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Run elsewhere: got %v, want %v", err, errors.ErrUnsupported)
+	} else if want := `command "tool elsewhere" is not supported on ` + runtime.GOOS; err.Error() != want {
+		t.Errorf("Run elsewhere: got %q, want %q", err, want)
+	}
+	if got, want := strings.Join(ran, " "), "here anywhere"; got != want {
+		t.Errorf("Ran: got %q, want %q", got, want)
+	}
+
+	var names []string
+	for _, h := range root.HelpInfo(command.IncludeCommands | command.IncludeUnlisted).Commands {
+		names = append(names, h.Name)
+	}
+	if got, want := strings.Join(names, " "), "here anywhere"; got != want {
+		t.Errorf("Help commands: got %q, want %q", got, want)
+	}
+}
+
+func TestWarnf(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "sub",
+			Run: func(env *command.Env) error {
+				env.Warnf("file %q is deprecated", "old.txt")
+				env.Warnf("ignoring %d entries", 3)
+				return nil
+			},
+		}},
+	}
+	var buf strings.Builder
+	env := root.NewEnv(nil)
+	env.Log = &buf
+	if err := command.Run(env, []string{"sub"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "Warning: file \"old.txt\" is deprecated\nWarning: ignoring 3 entries\n"; got != want {
+		t.Errorf("Output: got %q, want %q", got, want)
+	}
+	want := []string{`file "old.txt" is deprecated`, "ignoring 3 entries"}
+	if got := env.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("Warnings: got %q, want %q", got, want)
+	}
+
+	// A new invocation starts with no warnings.
+	if err := command.Run(env, nil); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := env.Warnings(); len(got) != 0 {
+		t.Errorf("Warnings: got %q, want none", got)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	var ran []string
+	runner := func(env *command.Env) error {
+		ran = append(ran, env.Command.Name+":"+strings.Join(env.Args, ","))
+		return nil
+	}
+	root := &command.C{
+		Name:    "tool",
+		Default: "status",
+		Commands: []*command.C{
+			{Name: "status", Help: "Show status.", Run: runner},
+			{Name: "sync", Help: "Synchronize.", Run: runner},
+		},
+	}
+	for _, args := range [][]string{nil, {"sync", "x"}, {"status", "y"}} {
+		if err := command.Run(root.NewEnv(nil), args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", args, err)
+		}
+	}
+	if got, want := strings.Join(ran, " "), "status: sync:x status:y"; got != want {
+		t.Errorf("Ran: got %q, want %q", got, want)
+	}
+
+	var syn []string
+	for _, h := range root.HelpInfo(command.IncludeCommands).Commands {
+		syn = append(syn, h.Synopsis)
+	}
+	if got, want := strings.Join(syn, "|"), "Show status. (default)|Synchronize."; got != want {
+		t.Errorf("Help synopses: got %q, want %q", got, want)
+	}
+
+	root.Default = "nonesuch"
+	if err := root.Vet(); err == nil {
+		t.Error("Vet: got nil, want error for invalid default")
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	env := (&command.C{Name: "test"}).NewEnv(nil)
+	usage := env.Usagef("bad usage")
+	tests := []struct {
+		err         error
+		usage, help bool
+		code        int
+	}{
+		{nil, false, false, 0},
+		{errors.New("other"), false, false, 1},
+		{usage, true, false, 2},
+		{fmt.Errorf("wrapped: %w", usage), true, false, 2},
+		{command.ErrRequestHelp, false, true, 2},
+		{fmt.Errorf("wrapped: %w", command.ErrRequestHelp), false, true, 2},
+		{command.CanceledError{Err: context.Canceled}, false, false, 1},
+		{command.CanceledError{Err: context.DeadlineExceeded, Cause: context.DeadlineExceeded}, false, false, 124},
+		{command.ErrBrokenPipe, false, false, 141},
+	}
+	for _, tc := range tests {
+		if got := command.IsUsage(tc.err); got != tc.usage {
+			t.Errorf("IsUsage(%v): got %v, want %v", tc.err, got, tc.usage)
+		}
+		if got := command.IsHelpRequest(tc.err); got != tc.help {
+			t.Errorf("IsHelpRequest(%v): got %v, want %v", tc.err, got, tc.help)
+		}
+		if got := command.ExitCode(tc.err); got != tc.code {
+			t.Errorf("ExitCode(%v): got %d, want %d", tc.err, got, tc.code)
+		}
+	}
+}
+
+func TestRunE(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "remote",
+			Commands: []*command.C{{
+				Name: "add",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.Bool("force", false, "Force")
+				},
+				Run: func(*command.Env) error { return nil },
+			}},
+		}},
+	}
+	env := root.NewEnv(nil)
+	env.Log = io.Discard
+
+	got, err := command.RunE(env, []string{"remote", "add", "--force", "origin", "url"})
+	if err != nil {
+		t.Fatalf("RunE: unexpected error: %v", err)
+	}
+	if got.Command.Name != "add" || got.Parent.Command.Name != "remote" {
+		t.Errorf("RunE: resolved %q, want add", got.Command.Name)
+	}
+	if f := got.Command.Flags.Lookup("force"); f == nil || f.Value.String() != "true" {
+		t.Errorf("RunE: flag --force is %v, want true", f)
+	}
+	if !slices.Equal(got.Args, []string{"origin", "url"}) {
+		t.Errorf("RunE: args are %q, want [origin url]", got.Args)
+	}
+
+	// On failure, the result is the deepest environment reached.
+	got, err = command.RunE(env, []string{"remote", "nonesuch"})
+	if err == nil {
+		t.Error("RunE: got nil error, want error")
+	}
+	if got == nil || got.Command.Name != "remote" {
+		t.Errorf("RunE: resolved %v, want remote", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package commandtest
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+// Clock is a [command.Clock] whose time changes only when the test says so,
+// for testing commands whose behavior depends on time (see
+// command.Env.SetClock). A Clock is safe for concurrent use.
+//
+// Unlike the system clock, the functions registered with AfterFunc are called
+// by Advance or Set, synchronously and in order of their due times, so that
+// their effects are complete when Advance or Set returns.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    int
+	timers []*clockTimer
+}
+
+var _ command.Clock = (*Clock)(nil)
+
+type clockTimer struct {
+	due time.Time
+	seq int // tie-breaker, so timers due together fire in order of creation
+	f   func()
+}
+
+// NewClock returns a new [Clock] whose current time is now.
+func NewClock(now time.Time) *Clock { return &Clock{now: now} }
+
+// Now implements part of the [command.Clock] interface.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements part of the [command.Clock] interface.
+func (c *Clock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	t := &clockTimer{due: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		n := len(c.timers)
+		c.timers = slices.DeleteFunc(c.timers, func(u *clockTimer) bool { return u == t })
+		return len(c.timers) != n
+	}
+}
+
+// Advance moves the current time of c forward by d, and calls the functions
+// of any timers that are due.
+func (c *Clock) Advance(d time.Duration) { c.Set(c.Now().Add(d)) }
+
+// Set sets the current time of c to now, and calls the functions of any
+// timers that are due.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	var due []*clockTimer
+	c.timers = slices.DeleteFunc(c.timers, func(t *clockTimer) bool {
+		if t.due.After(now) {
+			return false
+		}
+		due = append(due, t)
+		return true
+	})
+	c.mu.Unlock()
+
+	slices.SortFunc(due, func(a, b *clockTimer) int {
+		if v := a.due.Compare(b.due); v != 0 {
+			return v
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	for _, t := range due {
+		t.f()
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+// Package commandtest provides support for testing programs built with the
+// command package.
+//
+// # Interruptions
+//
+// The [BeforeInit] and [BeforeRun] functions instrument a command so that a
+// [Trigger] fires at a specific point during its execution. Triggers can
+// cancel the context of the command, as a timeout or a signal handler would,
+// so that a test can exercise cancellation and cleanup paths deterministically:
+//
+//	commandtest.BeforeRun(t, cmd, commandtest.Signal(os.Interrupt))
+//	err := command.Run(root.NewEnv(nil), args)
+//	// ... check that err and any cleanup are as expected
+//
+// # Conformance
+//
+// The [Conformance] function checks the command tree of an application for
+// common problems, such as missing help text or flags that reject their own
+// default values, suitable for use as a test in continuous integration.
+//
+// # File systems
+//
+// The [MapFS] type is an in-memory file system that supports writing, for
+// testing commands that use the file system of their environment:
+//
+//	fsys := commandtest.MapFS{"config.txt": {Data: []byte("x=1\n")}}
+//	err := command.Run(root.NewEnv(nil).SetFS(fsys), args)
+//	// ... check the contents of fsys
+//
+// # Time
+//
+// The [Clock] type is a clock controlled by the test, for commands whose
+// behavior depends on time, including command timeouts:
+//
+//	clock := commandtest.NewClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+//	env := root.NewEnv(nil).SetClock(clock)
+//	// ... run the command, and call clock.Advance to move time forward
+package commandtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+// A Trigger is called at a designated point during the execution of a
+// command, with the environment of the command at that point.
+type Trigger func(env *command.Env)
+
+// Cancel returns a [Trigger] that cancels the context of its environment with
+// the specified cause. The cancellation affects the environment and its
+// descendants, but not its ancestors.
+func Cancel(cause error) Trigger {
+	return func(env *command.Env) { env.SetContext(env.Context()).Cancel(cause) }
+}
+
+// Signal returns a [Trigger] that cancels the context of its environment as if
+// the process had received the specified signal. The cancellation cause
+// satisfies errors.Is(err, context.Canceled).
+func Signal(sig os.Signal) Trigger {
+	return Cancel(command.SignalError{Signal: sig})
+}
+
+// SignalError is the cancellation cause reported by a [Signal] trigger. It is
+// the same type reported when a program handles a real signal (see
+// command.Env.HandleSignals).
+type SignalError = command.SignalError
+
+// Func returns a [Trigger] that calls f, ignoring the environment.
+func Func(f func()) Trigger { return func(*command.Env) { f() } }
+
+// BeforeInit instruments c so that t is called after flags for c are parsed,
+// and before its Init function (if any) is called. The original Init function
+// of c is restored when the test governed by tb ends.
+func BeforeInit(tb testing.TB, c *command.C, t Trigger) {
+	tb.Helper()
+	init := c.Init
+	c.Init = func(env *command.Env) error {
+		t(env)
+		if init != nil {
+			return init(env)
+		}
+		return nil
+	}
+	tb.Cleanup(func() { c.Init = init })
+}
+
+// BeforeRun instruments c so that t is called immediately before its Run
+// function is called. It is an error if c does not have a Run function.  The
+// original Run function of c is restored when the test governed by tb ends.
+func BeforeRun(tb testing.TB, c *command.C, t Trigger) {
+	tb.Helper()
+	run := c.Run
+	if run == nil {
+		tb.Fatalf("Command %q does not have a Run function", c.Name)
+	}
+	c.Run = func(env *command.Env) error {
+		t(env)
+		return run(env)
+	}
+	tb.Cleanup(func() { c.Run = run })
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package commandtest_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/commandtest"
+)
+
+func TestInterrupt(t *testing.T) {
+	var cleaned, ran bool
+	sub := &command.C{
+		Name: "sub",
+		Init: func(env *command.Env) error {
+			env.Defer(func() { cleaned = true })
+			return nil
+		},
+		Run: func(env *command.Env) error {
+			ran = true
+			<-env.Context().Done()
+			return context.Cause(env.Context())
+		},
+	}
+	root := &command.C{Name: "root", Commands: []*command.C{sub}}
+
+	t.Run("Signal", func(t *testing.T) {
+		cleaned, ran = false, false
+		commandtest.BeforeRun(t, sub, commandtest.Signal(os.Interrupt))
+		err := command.Run(root.NewEnv(nil), []string{"sub"})
+
+		var serr commandtest.SignalError
+		if !errors.As(err, &serr) || serr.Signal != os.Interrupt {
+			t.Errorf("Run: got %v, want %v", err, os.Interrupt)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run: got %v, want %v", err, context.Canceled)
+		}
+		if !ran || !cleaned {
+			t.Errorf("Run: ran=%v cleaned=%v, want both true", ran, cleaned)
+		}
+	})
+
+	t.Run("BeforeInit", func(t *testing.T) {
+		cleaned, ran = false, false
+		errStop := errors.New("stop")
+		commandtest.BeforeInit(t, sub, commandtest.Cancel(errStop))
+		if err := command.Run(root.NewEnv(nil), []string{"sub"}); !errors.Is(err, errStop) {
+			t.Errorf("Run: got %v, want %v", err, errStop)
+		}
+		if !ran || !cleaned {
+			t.Errorf("Run: ran=%v cleaned=%v, want both true", ran, cleaned)
+		}
+	})
+
+	// Verify that the instrumentation was removed.
+	if sub.Init == nil {
+		t.Error("Init was not restored")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	var started bool
+	root := &command.C{
+		Name: "tool",
+		Help: "A tool for testing conformance.",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.Bool("verbose", false, "Verbose output")
+		},
+		Startup: func(*command.Env) error { started = true; return nil },
+		Commands: []*command.C{{
+			Name: "remote",
+			Help: "Manage remotes.",
+			Commands: []*command.C{{
+				Name: "add",
+				Help: "Add a remote.",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.Duration("timeout", time.Minute, "Timeout")
+					fs.Int("count", 3, "Count")
+					fs.Func("tag", "Tags", func(string) error { return nil })
+					ports, labels := []int{1, 2}, map[string]string{"a": "1", "b": "2"}
+					command.IntsVar(fs, &ports, "port", "Ports")
+					command.MapVar(fs, &labels, "label", "Labels")
+				},
+				Run: func(*command.Env) error { t.Error("Run was called"); return nil },
+			}},
+		}, {
+			Name:        "exec",
+			Help:        "Execute a program.",
+			CustomFlags: true,
+			Run:         func(*command.Env) error { return nil },
+		}, {
+			Name:     "secret",
+			Unlisted: true,
+			Run:      func(*command.Env) error { return nil },
+		}, {
+			Name: "topic",
+			Help: "A help topic.",
+		},
+			command.HelpCommand(nil),
+			command.VersionCommand(),
+		},
+	}
+	commandtest.Conformance(t, root)
+	if started {
+		t.Error("Startup was called")
+	}
+}
+
+func TestMapFS(t *testing.T) {
+	fsys := commandtest.MapFS{"input": {Data: []byte("hello")}}
+	if err := command.MkdirAll(fsys, "a/b", 0700); err != nil {
+		t.Fatalf("MkdirAll: unexpected error: %v", err)
+	}
+	if err := command.WriteFile(fsys, "a/b/c", []byte("world"), 0600); err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+	if err := command.WriteFile(fsys, "input/x", nil, 0600); err == nil {
+		t.Error("WriteFile beneath a file: got nil error, want error")
+	}
+	if err := command.Remove(fsys, "a/b"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Remove non-empty: got %v, want %v", err, fs.ErrExist)
+	}
+	if err := command.Remove(fsys, "input"); err != nil {
+		t.Errorf("Remove: unexpected error: %v", err)
+	}
+	if err := command.Rename(fsys, "a", "d"); err != nil {
+		t.Errorf("Rename: unexpected error: %v", err)
+	} else if err := command.Rename(fsys, "d/b/c", "d/b/e"); err != nil {
+		t.Errorf("Rename: unexpected error: %v", err)
+	}
+	if err := command.Rename(fsys, "nonesuch", "x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Rename missing: got %v, want %v", err, fs.ErrNotExist)
+	}
+	if err := fstest.TestFS(fsys, "d/b/e"); err != nil {
+		t.Errorf("TestFS: %v", err)
+	}
+	if _, err := fs.Stat(fsys, "input"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat removed: got %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := commandtest.NewClock(start)
+
+	var now time.Time
+	cmd := &command.C{
+		Name:    "wait",
+		Timeout: time.Minute,
+		Run: func(env *command.Env) error {
+			now = env.Now()
+			select {
+			case <-env.Context().Done():
+				return env.Context().Err()
+			case <-time.After(10 * time.Second):
+				return errors.New("timeout did not fire")
+			}
+		},
+	}
+	commandtest.BeforeRun(t, cmd, func(env *command.Env) {
+		clock.Advance(30 * time.Second) // not yet due
+		clock.Advance(30 * time.Second) // due
+	})
+	err := command.Run(cmd.NewEnv(nil).SetClock(clock), nil)
+	var cerr command.CanceledError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Run: got %v, want %T", err, cerr)
+	}
+	if got, want := cerr.Error(), "timed out after 1m0s"; got != want {
+		t.Errorf("Error: got %q, want %q", got, want)
+	}
+	if got := cerr.ExitCode(); got != 124 {
+		t.Errorf("ExitCode: got %d, want 124", got)
+	}
+	if want := start.Add(time.Minute); !now.Equal(want) {
+		t.Errorf("Now: got %v, want %v", now, want)
+	}
+
+	// Elapsed times are measured by the clock.
+	var elapsed time.Duration
+	step := &command.C{
+		Name: "step",
+		Run: func(env *command.Env) error {
+			clock.Advance(5 * time.Second)
+			env.SetSummary("done")
+			return nil
+		},
+	}
+	env := step.NewEnv(nil).SetClock(clock).SetSummaryHook(func(_ *command.Env, s command.Summary) {
+		elapsed = s.Elapsed
+	})
+	if err := command.Run(env, nil); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if elapsed != 5*time.Second {
+		t.Errorf("Elapsed: got %v, want 5s", elapsed)
+	}
+
+	// A stopped timer does not fire.
+	fired := false
+	stop := clock.AfterFunc(time.Second, func() { fired = true })
+	if !stop() {
+		t.Error("Stop: got false, want true")
+	}
+	clock.Advance(time.Hour)
+	if fired {
+		t.Error("Stopped timer fired")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package commandtest
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+// Conformance runs a suite of checks on the command tree rooted at root, and
+// reports any problems as errors in t. It is intended as a quality gate for
+// the command tree of an application, for example:
+//
+//	func TestCommands(t *testing.T) {
+//	   commandtest.Conformance(t, newRootCommand())
+//	}
+//
+// Conformance checks that:
+//
+//   - The tree passes the checks of the Vet method of [command.C].
+//   - Help for each command can be rendered via its --help flag, unless the
+//     command has NoImplicitHelp set.
+//   - Each listed command has help text.
+//   - Each command that can be run, or has subcommands that can, is reached
+//     by dispatching its path from the root.
+//   - Each flag accepts its own default value.
+//
+// The checks for each command are run as a subtest named for the path of the
+// command. The checks are performed on clones of the tree (see C.Clone), and
+// do not call the Startup, Init, or Run functions of any command, but flag
+// variables shared by the clones may be modified. Commands that are not
+// supported on the current platform are skipped.
+func Conformance(t *testing.T, root *command.C) {
+	t.Helper()
+	if err := root.Vet(); err != nil {
+		var errs interface{ Unwrap() []error }
+		if errors.As(err, &errs) {
+			for _, e := range errs.Unwrap() {
+				t.Errorf("Vet: %v", e)
+			}
+		} else {
+			t.Errorf("Vet: %v", err)
+		}
+	}
+
+	var walk func(path []string, c *command.C, noHelp bool)
+	walk = func(path []string, c *command.C, noHelp bool) {
+		if !c.Supported() {
+			return
+		}
+		noHelp = noHelp || c.NoImplicitHelp
+		t.Run(strings.Join(append([]string{root.Name}, path...), "/"), func(t *testing.T) {
+			checkCommand(t, root, path, c, noHelp)
+		})
+		for _, sub := range c.Commands {
+			walk(append(slices.Clip(path), sub.Name), sub, noHelp)
+		}
+	}
+	walk(nil, root, false)
+}
+
+// checkCommand performs the conformance checks for command c at the given
+// path below root. If noHelp is true, the --help flag is not checked.
+func checkCommand(t *testing.T, root *command.C, path []string, c *command.C, noHelp bool) {
+	t.Helper()
+	wantPath := append([]string{root.Name}, path...)
+	dispatched := len(path) == 0 || c.Runnable() || c.HasRunnableSubcommands()
+
+	// Reachability: dispatching the path selects the command.
+	clone := root.Clone()
+	if dispatched {
+		ex, err := command.Explain(clone.NewEnv(nil), path)
+		if err != nil {
+			t.Errorf("Dispatch %q: unexpected error: %v", path, err)
+			return
+		} else if !slices.Equal(ex.Path, wantPath) {
+			t.Errorf("Dispatch %q: reached %q, want %q", path, ex.Path, wantPath)
+			return
+		}
+	}
+
+	// Help: the --help flag renders help for the command.
+	if dispatched && !c.CustomFlags && !noHelp {
+		var buf strings.Builder
+		env := root.Clone().NewEnv(nil)
+		env.Log = &buf
+		_, err := command.Explain(env, append(slices.Clip(path), "--help"))
+		if !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Help %q: got error %v, want %v", path, err, command.ErrRequestHelp)
+		} else if buf.Len() == 0 {
+			t.Errorf("Help %q: no help was printed", path)
+		}
+	}
+
+	// Documentation: a listed command has help text.
+	if !c.Unlisted && c.HelpInfo(0).Synopsis == "" {
+		t.Errorf("Command %q has no help text", strings.Join(wantPath, " "))
+	}
+
+	// Flags: each flag accepts its own default value.
+	if !dispatched || c.CustomFlags {
+		return
+	}
+	target := findCommand(clone, path)
+	target.Flags.VisitAll(func(f *flag.Flag) {
+		if f.DefValue == "" {
+			return
+		}
+		arg := "--" + f.Name + "=" + f.DefValue
+		if _, err := command.Explain(root.Clone().NewEnv(nil), append(slices.Clip(path), arg)); err != nil {
+			t.Errorf("Flag %q does not accept its default value %q: %v", f.Name, f.DefValue, err)
+		}
+	})
+}
+
+// findCommand returns the command at path below root.
+func findCommand(root *command.C, path []string) *command.C {
+	cur := root
+	for _, name := range path {
+		cur = cur.FindSubcommand(name)
+	}
+	return cur
+}
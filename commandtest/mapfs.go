@@ -0,0 +1,114 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package commandtest
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"github.com/creachadair/command"
+)
+
+// MapFS is an in-memory [command.WritableFS] for testing commands that use
+// the file system of their environment (see command.Env.SetFS). It extends
+// [fstest.MapFS] with support for writing. A MapFS is not safe for concurrent
+// use by multiple goroutines.
+type MapFS fstest.MapFS
+
+var _ command.WritableFS = MapFS(nil)
+
+// Open implements the [fs.FS] interface.
+func (m MapFS) Open(name string) (fs.File, error) { return fstest.MapFS(m).Open(name) }
+
+// WriteFile implements part of the [command.WritableFS] interface.
+func (m MapFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := m.checkParent("write", name); err != nil {
+		return err
+	} else if f, ok := m[name]; ok && f.Mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrExist}
+	}
+	m[name] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: perm.Perm()}
+	return nil
+}
+
+// MkdirAll implements part of the [command.WritableFS] interface.
+func (m MapFS) MkdirAll(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if f, ok := m[dir]; ok && !f.Mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+		} else if !ok {
+			m[dir] = &fstest.MapFile{Mode: fs.ModeDir | perm.Perm()}
+		}
+	}
+	return nil
+}
+
+// Remove implements part of the [command.WritableFS] interface.
+func (m MapFS) Remove(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	f, ok := m[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if f.Mode.IsDir() {
+		for p := range m {
+			if strings.HasPrefix(p, name+"/") {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+	delete(m, name)
+	return nil
+}
+
+// Rename implements part of the [command.WritableFS] interface.
+func (m MapFS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || oldname == "." {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	} else if err := m.checkParent("rename", newname); err != nil {
+		return err
+	}
+	f, ok := m[oldname]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	} else if g, ok := m[newname]; ok && g.Mode.IsDir() {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	}
+	var moved []string
+	if f.Mode.IsDir() {
+		for p := range m {
+			if strings.HasPrefix(p, oldname+"/") {
+				moved = append(moved, p)
+			}
+		}
+	}
+	delete(m, oldname)
+	m[newname] = f
+	for _, p := range moved {
+		g := m[p]
+		delete(m, p)
+		m[newname+strings.TrimPrefix(p, oldname)] = g
+	}
+	return nil
+}
+
+// checkParent reports an error if name is not a valid path, or if a prefix
+// of name is a file rather than a directory.
+func (m MapFS) checkParent(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if f, ok := m[dir]; ok && !f.Mode.IsDir() {
+			return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	return nil
+}
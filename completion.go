@@ -0,0 +1,379 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A Completer computes shell completion candidates for a partial word typed
+// by the user (see Complete). The env is the environment of the command being
+// completed, whose channel is ChannelCompletion. Candidates that do not begin
+// with word are discarded, so a Completer may report more than necessary.
+type Completer func(env *Env, word string) []string
+
+// CompleteFlag sets c as the completer for the values of the named flag of
+// fs. For example:
+//
+//	fs.StringVar(&config, "config", "", "Configuration file")
+//	command.CompleteFlag(fs, "config", command.CompleteFiles(".yaml", ".yml"))
+//
+// If a flag has no completer, but its value has a method
+//
+//	Complete(env *Env, word string) []string
+//
+// that method is used as its completer, as for flags defined by PathVar.
+// Otherwise its choices are offered if it has any (see FlagChoices).
+// CompleteFlag panics if fs does not define the named flag.
+func CompleteFlag(fs *flag.FlagSet, name string, c Completer) {
+	if info := annotate(fs, name); info.aliasOf != "" {
+		name = info.aliasOf
+	}
+	annotate(fs, name).complete = c
+}
+
+// Complete reports the shell completion candidates for the last of words,
+// given the words before it, when they are the arguments to the command of
+// env. The last word is the partial word being completed, and is "" if the
+// user has not begun typing it. Complete does not run any command.
+//
+// Candidates are computed as follows:
+//
+//   - If the word is the value of a flag, from the completer for the flag (see
+//     CompleteFlag) or its choices.
+//   - If the word begins with "-", from the names of the flags of the command.
+//   - Otherwise, from the names of the listed subcommands of the command, if no
+//     free arguments precede the word, and from its CompleteArgs function, or
+//     for its first free argument its ArgValues function. If usage is tracked
+//     (see Env.TrackUsage), the most used subcommands are listed first.
+func Complete(env *Env, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cp := *env
+	cp.channel = ChannelCompletion
+	cur := &cp
+	cur.Command.setFlags(cur, &cur.Command.Flags)
+
+	var free []string
+	var value *flag.Flag // the flag whose value is the next word, if any
+	var done bool        // whether "--" was seen
+	for _, w := range words[:len(words)-1] {
+		switch {
+		case value != nil:
+			value = nil
+		case done || cur.Command.CustomFlags:
+			free = append(free, w)
+		case w == "--":
+			done = true
+		case len(w) > 1 && w[0] == '-':
+			name, _, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			if f := cur.lookupFlag(name); f != nil && !hasValue && !isBoolFlag(f) {
+				value = f
+			}
+		default:
+			if len(free) == 0 {
+				if sub := cur.Command.FindSubcommand(w); sub.Supported() &&
+					(cur.Command.PreferSubcommand == nil || cur.Command.PreferSubcommand(w)) {
+					cur = cur.newChild(sub, nil)
+					sub.setFlags(cur, &sub.Flags)
+					continue
+				}
+			}
+			free = append(free, w)
+		}
+	}
+	cur.Args = free
+
+	word := words[len(words)-1]
+	var out []string
+	switch {
+	case value != nil:
+		out = cur.flagCandidates(value, word)
+	case !done && !cur.Command.CustomFlags && strings.HasPrefix(word, "-"):
+		if name, val, ok := strings.Cut(strings.TrimLeft(word, "-"), "="); ok {
+			if f := cur.lookupFlag(name); f != nil {
+				prefix := word[:len(word)-len(val)]
+				for _, c := range cur.flagCandidates(f, val) {
+					out = append(out, prefix+c)
+				}
+			}
+			break
+		}
+		visitFlags(&cur.Command.Flags, cur.Command.FlagOrder, func(f *flag.Flag) {
+			if !isHidden(f) && !getFlagInfo(f).deprecated {
+				if len(f.Name) == 1 {
+					out = append(out, "-"+f.Name)
+				} else {
+					out = append(out, "--"+f.Name)
+				}
+			}
+		})
+	default:
+		if len(free) == 0 && !done {
+			for _, sub := range cur.Command.Commands {
+				if sub.Supported() && !sub.Unlisted {
+					out = append(out, sub.Name)
+				}
+			}
+			cur.rankByUsage(out)
+		}
+		if c := cur.Command.CompleteArgs; c != nil {
+			out = append(out, c(cur, word)...)
+		} else if f := cur.Command.ArgValues; f != nil && len(free) == 0 {
+			out = append(out, f()...)
+		}
+	}
+	out = slices.DeleteFunc(out, func(s string) bool { return !strings.HasPrefix(s, word) })
+	return slices.Compact(out)
+}
+
+// lookupFlag returns the flag with the given name for the command of e or its
+// nearest ancestor that defines it, or nil if none does.
+func (e *Env) lookupFlag(name string) *flag.Flag {
+	for cur := e; cur != nil; cur = cur.Parent {
+		if cur.Command.CustomFlags {
+			continue
+		} else if f := cur.Command.Flags.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// flagCandidates returns completion candidates for the value of f.
+func (e *Env) flagCandidates(f *flag.Flag, word string) []string {
+	info := getFlagInfo(f)
+	if info.aliasOf != "" {
+		return e.flagCandidates(e.lookupFlag(info.aliasOf), word)
+	} else if info.complete != nil {
+		return info.complete(e, word)
+	} else if c, ok := unwrapValue(f.Value).(interface {
+		Complete(*Env, string) []string
+	}); ok {
+		return c.Complete(e, word)
+	}
+	return FlagChoices(f)
+}
+
+// CacheCompletions sets whether completers wrapped by CachedCompleter may use
+// cached results, and returns e. The default is true. The setting is
+// inherited by the descendants of e.
+func (e *Env) CacheCompletions(enable bool) *Env { e.noCache = !enable; return e }
+
+// completionCacheDir is the name of the directory in the state directory of
+// the program where cached completions are stored (see CachedCompleter).
+const completionCacheDir = "completions"
+
+// CachedCompleter returns a [Completer] that caches the results of c for the
+// given time to live, in the state directory of the program (see
+// Env.StateDir). This keeps shell completion responsive when c is slow, for
+// example because it lists resources on a remote server:
+//
+//	CompleteArgs: command.CachedCompleter("remotes", 5*time.Minute, listRemotes),
+//
+// The results are cached separately for each command, name, and sequence of
+// free arguments before the word being completed, and c is called with an
+// empty word, so that its results serve for any word. The name distinguishes
+// the completers of a command, and should change if the meaning of the
+// results does. Results are computed again, and the cache refreshed, if they
+// are older than ttl according to the clock of the environment, or if caching
+// is disabled (see Env.CacheCompletions). Failures to read or write the cache
+// are ignored.
+func CachedCompleter(name string, ttl time.Duration, c Completer) Completer {
+	return func(env *Env, word string) []string {
+		key := slices.Concat(env.path()[1:], []string{name}, env.Args)
+		sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+		path := filepath.Join(env.StateDir(), completionCacheDir, hex.EncodeToString(sum[:16])+".json")
+
+		var entry struct {
+			Time   time.Time `json:"time"`
+			Values []string  `json:"values"`
+		}
+		if !env.noCache {
+			if data, err := env.readFile(path); err == nil && json.Unmarshal(data, &entry) == nil {
+				if age := env.Since(entry.Time); age >= 0 && age < ttl {
+					return entry.Values
+				}
+			}
+		}
+		entry.Time, entry.Values = env.Now(), c(env, "")
+		if data, err := json.Marshal(entry); err == nil {
+			env.writeFileAtomic(path, data)
+		}
+		return entry.Values
+	}
+}
+
+// CompleteFiles returns a [Completer] that offers the names of files and
+// directories in the file system of the environment (see Env.FS) that extend
+// the word being completed. Directories are offered with a trailing "/", so
+// that the user can continue into them. If any extensions are given, only
+// files whose names end with one of them are offered, for example:
+//
+//	command.CompleteFiles(".yaml", ".yml")
+//
+// Files whose names begin with "." are offered only if the word being
+// completed does too. A leading "~" in the word refers to the home directory
+// of the user.
+func CompleteFiles(exts ...string) Completer {
+	return func(env *Env, word string) []string { return completePath(env, word, false, exts) }
+}
+
+// CompleteDirs returns a [Completer] that offers only the names of directories,
+// as described for CompleteFiles.
+func CompleteDirs() Completer {
+	return func(env *Env, word string) []string { return completePath(env, word, true, nil) }
+}
+
+// completePath returns the paths in the file system of env that extend word,
+// as described for CompleteFiles.
+func completePath(env *Env, word string, dirsOnly bool, exts []string) []string {
+	dir, base := path.Split(word)
+	fsys := env.FS()
+	root := cleanDir(dir)
+	if strings.HasPrefix(root, "~") {
+		home, err := env.expandPath(root)
+		if err != nil {
+			return nil
+		}
+		root = env.fsName(home)
+	}
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		} else if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&fs.ModeSymlink != 0 {
+			fi, err := fs.Stat(fsys, path.Join(root, name))
+			isDir = err == nil && fi.IsDir()
+		}
+		switch {
+		case isDir:
+			out = append(out, dir+name+"/")
+		case dirsOnly:
+		case len(exts) == 0 || slices.ContainsFunc(exts, func(ext string) bool { return strings.HasSuffix(name, ext) }):
+			out = append(out, dir+name)
+		}
+	}
+	return out
+}
+
+// cleanDir returns the name of dir, the directory portion of a path as
+// reported by path.Split, to pass to a file system.
+func cleanDir(dir string) string {
+	switch dir {
+	case "":
+		return "."
+	case "/":
+		return dir
+	default:
+		return strings.TrimSuffix(dir, "/")
+	}
+}
+
+// CompletionCommand constructs a standardized "completion" command that
+// provides shell completion for the program (see Complete). The caller can
+// safely modify the returned command to customize its behavior.
+//
+// With --bash, the command prints a script that enables completion for the
+// program in bash, for example:
+//
+//	source <(tool completion --bash)
+//
+// Otherwise it prints the completion candidates for its arguments, one per
+// line, as the arguments to the root command, the last being the word to
+// complete. With --line, its argument is instead the text of the command line
+// up to the cursor, including the program name, as bash reports it. With
+// --no-cache, cached completions are not used (see CachedCompleter).
+func CompletionCommand() *C {
+	var bash, line, noCache bool
+	return &C{
+		Name:  "completion",
+		Usage: "--bash\n[--line] [--] [word ...]",
+		Help: `Print shell completions for this program.
+
+With --bash, print a script to enable completion in bash. To use it, add
+this command to your shell startup file:
+
+  source <({{.Prog}} completion --bash)
+
+Otherwise, print the completion candidates for the last of the arguments,
+given as the arguments to {{.Prog}}.`,
+		SetFlags: func(_ *Env, fs *flag.FlagSet) {
+			fs.BoolVar(&bash, "bash", false, "Print a completion script for bash")
+			fs.BoolVar(&line, "line", false, "Complete the command line given as a single argument")
+			fs.BoolVar(&noCache, "no-cache", false, "Do not use cached completions")
+		},
+		Run: func(env *Env) error {
+			root := env
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			if bash {
+				self := strings.Join(env.path()[1:], " ")
+				_, err := fmt.Fprintf(env.Stdout(), bashCompletion, root.ProgramName(), self)
+				return err
+			}
+			words := env.Args
+			var trim string // text the shell treats as a separate word
+			if line {
+				if len(words) != 1 {
+					return env.Usagef("--line requires exactly one argument")
+				}
+				text := words[0]
+				words = strings.Fields(text)
+				if len(words) != 0 {
+					words = words[1:] // the program name
+				}
+				if text == "" || strings.HasSuffix(text, " ") {
+					words = append(words, "")
+				}
+				if n := len(words); n != 0 {
+					if i := strings.LastIndex(words[n-1], "="); i >= 0 {
+						trim = words[n-1][:i+1]
+					}
+				}
+			}
+			if noCache {
+				cp := *root
+				root = cp.CacheCompletions(false)
+			}
+			for _, c := range Complete(root, words) {
+				fmt.Fprintln(env.Stdout(), strings.TrimPrefix(c, trim))
+			}
+			return nil
+		},
+	}
+}
+
+// bashCompletion is the script printed by the completion command for bash.
+// Its arguments are the name of the program and the path of the completion
+// command below the root.
+const bashCompletion = `_%[1]s_complete() {
+  local IFS=$'\n'
+  COMPREPLY=($(%[1]s %[2]s --line -- "${COMP_LINE:0:COMP_POINT}" 2>/dev/null))
+  if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+    compopt -o nospace
+  fi
+}
+complete -F _%[1]s_complete %[1]s
+`
@@ -0,0 +1,182 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/commandtest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComplete(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yaml":           {},
+		"app.json":           {},
+		"apps/prod.yaml":     {},
+		"apps/notes.txt":     {},
+		".hidden.yaml":       {},
+		"configs/base.yml":   {},
+		"configs/dev/x.yaml": {},
+	}
+	var format, config string
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.Bool("verbose", false, "Verbose output")
+			fs.StringVar(&config, "config", "", "Configuration file")
+			command.CompleteFlag(fs, "config", command.CompleteFiles(".yaml", ".yml"))
+			fs.Bool("secret", false, "Hidden")
+			command.HideFlags(fs, "secret")
+		},
+		Commands: []*command.C{{
+			Name: "deploy",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.EnumVar(fs, &format, "format", "Output format", "text", "json")
+				fs.String("dir", "", "Output directory")
+				command.CompleteFlag(fs, "dir", command.CompleteDirs())
+			},
+			CompleteArgs: func(env *command.Env, word string) []string {
+				if len(env.Args) == 0 {
+					return []string{"staging", "production"}
+				}
+				return command.CompleteFiles()(env, word)
+			},
+			Run: func(*command.Env) error { return nil },
+		}, {
+			Name:      "delete",
+			ArgValues: func() []string { return []string{"alpha", "beta"} },
+			Run:       func(*command.Env) error { return nil },
+		}, {
+			Name:     "internal",
+			Unlisted: true,
+		}, command.CompletionCommand()},
+	}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"deploy", "delete", "completion"}},
+		{[]string{"de"}, []string{"deploy", "delete"}},
+		{[]string{"-"}, []string{"--config", "--verbose"}},
+		{[]string{"--verbose", "d"}, []string{"deploy", "delete"}},
+		{[]string{"--config", ""}, []string{"app.yaml", "apps/", "configs/"}},
+		{[]string{"--config", "apps/"}, []string{"apps/prod.yaml"}},
+		{[]string{"--config", "."}, []string{".hidden.yaml"}},
+		{[]string{"--config=conf"}, []string{"--config=configs/"}},
+		{[]string{"--config=configs/"}, []string{"--config=configs/base.yml", "--config=configs/dev/"}},
+		{[]string{"deploy", "--format", ""}, []string{"text", "json"}},
+		{[]string{"deploy", "--format=j"}, []string{"--format=json"}},
+		{[]string{"deploy", "--dir", "a"}, []string{"apps/"}},
+		{[]string{"deploy", "--config", "app."}, []string{"app.yaml"}},
+		{[]string{"deploy", "--"}, []string{"--dir", "--format"}},
+		{[]string{"deploy", "p"}, []string{"production"}},
+		{[]string{"deploy", "staging", "app"}, []string{"app.json", "app.yaml", "apps/"}},
+		{[]string{"deploy", "--", "s"}, []string{"staging"}},
+		{[]string{"delete", ""}, []string{"alpha", "beta"}},
+		{[]string{"delete", "alpha", ""}, nil},
+		{[]string{"nonesuch", ""}, nil},
+	}
+	for _, tc := range tests {
+		env := root.NewEnv(nil).SetFS(fsys)
+		got := command.Complete(env, tc.words)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("Complete %q (-want, +got):\n%s", tc.words, diff)
+		}
+	}
+
+	t.Run("Command", func(t *testing.T) {
+		run := func(args ...string) string {
+			t.Helper()
+			return captureStdout(t, func() {
+				env := root.NewEnv(nil).SetFS(fsys).SetProgramName("tool")
+				if err := command.Run(env, append([]string{"completion"}, args...)); err != nil {
+					t.Fatalf("Run %q: unexpected error: %v", args, err)
+				}
+			})
+		}
+		if got, want := run("--", "deploy", "--format", "t"), "text\n"; got != want {
+			t.Errorf("Complete words: got %q, want %q", got, want)
+		}
+		if got, want := run("--line", "--", "tool deploy --format=j"), "json\n"; got != want {
+			t.Errorf("Complete line: got %q, want %q", got, want)
+		}
+		if got, want := run("--line", "--", "tool de"), "deploy\ndelete\n"; got != want {
+			t.Errorf("Complete line: got %q, want %q", got, want)
+		}
+		if got := run("--bash"); !strings.Contains(got, "complete -F _tool_complete tool\n") ||
+			!strings.Contains(got, "tool completion --line") {
+			t.Errorf("Bash script is not as expected:\n%s", got)
+		}
+	})
+}
+
+func TestCachedCompleter(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	clock := commandtest.NewClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var calls int
+	remotes := []string{"origin", "upstream"}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "fetch",
+			CompleteArgs: command.CachedCompleter("remotes", time.Minute, func(env *command.Env, word string) []string {
+				calls++
+				if word != "" {
+					t.Errorf("Completer called with word %q, want empty", word)
+				}
+				return remotes
+			}),
+			Run: func(*command.Env) error { return nil },
+		}, command.CompletionCommand()},
+	}
+	complete := func(cache bool, words ...string) []string {
+		t.Helper()
+		env := root.NewEnv(nil).SetProgramName("tool").SetClock(clock).CacheCompletions(cache)
+		return command.Complete(env, words)
+	}
+	check := func(got, want []string, wantCalls int) {
+		t.Helper()
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Completions (-want, +got):\n%s", diff)
+		}
+		if calls != wantCalls {
+			t.Errorf("Completer called %d times, want %d", calls, wantCalls)
+		}
+	}
+
+	check(complete(true, "fetch", ""), []string{"origin", "upstream"}, 1)
+	remotes = []string{"origin", "upstream", "fork"}
+	check(complete(true, "fetch", "u"), []string{"upstream"}, 1) // cached
+
+	// Other arguments are cached separately.
+	check(complete(true, "fetch", "origin", ""), []string{"origin", "upstream", "fork"}, 2)
+
+	// Bypassing the cache refreshes it.
+	check(complete(false, "fetch", "f"), []string{"fork"}, 3)
+	remotes = []string{"origin"}
+	check(complete(true, "fetch", ""), []string{"origin", "upstream", "fork"}, 3)
+
+	// Expired entries are refreshed.
+	clock.Advance(time.Minute)
+	check(complete(true, "fetch", ""), []string{"origin"}, 4)
+
+	// The completion command can bypass the cache.
+	remotes = []string{"mirror"}
+	got := captureStdout(t, func() {
+		env := root.NewEnv(nil).SetProgramName("tool").SetClock(clock)
+		if err := command.Run(env, []string{"completion", "--no-cache", "--", "fetch", ""}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+	})
+	if got != "mirror\n" || calls != 5 {
+		t.Errorf("Completion command: got %q after %d calls, want mirror after 5", got, calls)
+	}
+}
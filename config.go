@@ -0,0 +1,453 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigOptions are settings for [UseConfigFile]. A nil *ConfigOptions is
+// ready for use and provides default values.
+type ConfigOptions struct {
+	// The base name of the default configuration file, in the configuration
+	// directory of the program (see Env.ConfigDir). If empty, "config.json".
+	Name string
+
+	// If true, the configuration file is loaded by the first invocation that
+	// uses it, and reused by later invocations instead of being loaded by
+	// each of them. This suits long-lived programs, such as REPLs and
+	// servers, that run many commands. Use [ReloadConfig] to load the file
+	// again, so that later invocations use its new contents.
+	Cache bool
+
+	// If non-nil and Cache is true, the cached file is reloaded when the
+	// process receives this signal, typically syscall.SIGHUP. If reloading
+	// fails, the previous contents remain in use.
+	ReloadSignal os.Signal
+}
+
+func (o *ConfigOptions) name() string {
+	if o == nil || o.Name == "" {
+		return "config.json"
+	}
+	return o.Name
+}
+
+// UseConfigFile adds a --config flag to root that names a configuration file
+// providing default values for flags, and returns root. Any existing SetFlags
+// function of root is preserved.
+//
+// The default path is the file named by opts in the configuration directory
+// of the program (see Env.ConfigDir). When the root command is run, the file
+// is loaded and can be accessed during the invocation by Env.ConfigFile. It is
+// not an error for the default file not to exist, but it is an error if a file
+// named explicitly by --config does not exist.
+//
+// The file contains a JSON object whose members give values for flags by
+// name. Lines whose first non-blank characters are "//" are comments, and are
+// ignored. A member whose value is an object is a section that gives values for
+// the flags of the subcommand with that name, and may itself contain sections
+// for the subcommands of that command. For example:
+//
+//	{
+//	  "verbose": true,
+//	  "remote": {
+//	    "timeout": "30s",
+//	    "add": {"fetch": true}
+//	  }
+//	}
+//
+// Each flag of a command that is not set on the command line takes its value
+// from the section of its command, if present; otherwise from the sections of
+// its parent commands, from innermost to outermost. If the value is an array,
+// each element is set in turn, which suits flags that accumulate values.
+//
+// A long-lived program that runs many commands may cache the file, and reload
+// it explicitly or on a signal (see ConfigOptions). To reset the flag values
+// set from the file between invocations, see Env.SnapshotFlags.
+func UseConfigFile(root *C, opts *ConfigOptions) *C {
+	var cache *configCache
+	if opts != nil && opts.Cache {
+		cache = new(configCache)
+		if opts.ReloadSignal != nil {
+			ch := make(chan os.Signal, 1)
+			signal.Notify(ch, opts.ReloadSignal)
+			go func() {
+				for range ch {
+					cache.reload()
+				}
+			}()
+		}
+	}
+	setFlags := root.SetFlags
+	root.SetFlags = func(env *Env, fs *flag.FlagSet) {
+		if setFlags != nil {
+			setFlags(env, fs)
+		}
+		path := filepath.Join(env.ConfigDir(), opts.name())
+		fs.Var(&configFlag{path: path, cache: cache}, "config", "Configuration file path")
+	}
+	return root
+}
+
+// ReloadConfig loads the configuration file for root again, if root uses a
+// cached configuration file (see ConfigOptions) that has been loaded. Later
+// invocations of root use the new contents of the file. If loading fails, the
+// previous contents remain in use, and ReloadConfig reports the error.
+func ReloadConfig(root *C) error {
+	if f := root.Flags.Lookup("config"); f != nil {
+		if c, ok := unwrapValue(f.Value).(*configFlag); ok && c.cache != nil {
+			return c.cache.reload()
+		}
+	}
+	return nil
+}
+
+// configCache records a configuration file loaded for reuse by more than one
+// invocation (see ConfigOptions).
+type configCache struct {
+	mu        sync.Mutex
+	loaded    bool        // whether the file has been loaded
+	fsys      fs.FS       // the file system of the loaded file
+	path      string      // the path of the loaded file
+	mustExist bool        // whether the file must exist
+	cf        *ConfigFile // the loaded file, or nil if it did not exist
+}
+
+// load returns the cached configuration file for path in fsys, loading it if
+// it has not been loaded.
+func (c *configCache) load(fsys fs.FS, path string, mustExist bool) (*ConfigFile, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && c.path == path && (c.cf != nil || !mustExist) {
+		return c.cf, nil
+	}
+	cf, err := loadConfigFile(fsys, path, mustExist)
+	if err != nil {
+		return nil, err
+	}
+	c.loaded, c.fsys, c.path, c.mustExist, c.cf = true, fsys, path, mustExist, cf
+	return cf, nil
+}
+
+// reload loads the cached configuration file again, if it has been loaded.
+func (c *configCache) reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		return nil
+	}
+	cf, err := loadConfigFile(c.fsys, c.path, c.mustExist)
+	if err != nil {
+		return err
+	}
+	c.cf = cf
+	return nil
+}
+
+// ConfigDir returns the configuration directory for the program, the
+// directory named by Env.ProgramName in the user configuration directory (see
+// [os.UserConfigDir]). On Unix-like systems, this respects $XDG_CONFIG_HOME.
+func (e *Env) ConfigDir() string {
+	dir, _ := os.UserConfigDir() // if unavailable, use a relative path
+	return filepath.Join(dir, e.ProgramName())
+}
+
+// A ConfigFile is a configuration file loaded by the --config flag installed
+// by [UseConfigFile].
+type ConfigFile struct {
+	Path string         // the path of the file
+	Data map[string]any // the contents of the file, with numbers as json.Number
+}
+
+// ConfigFile returns the configuration file loaded for the current invocation
+// of [Run], or nil if none was loaded.
+func (e *Env) ConfigFile() *ConfigFile {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.config
+}
+
+// configFlag is a [flag.Value] for the path of a configuration file.
+type configFlag struct {
+	path  string
+	set   bool         // whether the flag was set explicitly
+	cache *configCache // if non-nil, the file is cached
+}
+
+func (c *configFlag) String() string { return c.path }
+func (c *configFlag) Get() any       { return c.path }
+
+func (c *configFlag) Set(s string) error { c.path, c.set = s, true; return nil }
+
+// applyConfig loads the configuration file for the invocation if the command
+// for e has a --config flag installed by UseConfigFile, then sets each flag of
+// the command not set on the command line from the configuration file.
+func (e *Env) applyConfig() error {
+	if f := e.Command.Flags.Lookup("config"); f != nil {
+		if c, ok := unwrapValue(f.Value).(*configFlag); ok {
+			load := loadConfigFile
+			if c.cache != nil {
+				load = c.cache.load
+			}
+			cf, err := load(e.FS(), e.fsName(c.path), c.set)
+			if err != nil {
+				return err
+			}
+			inv := e.invocation()
+			inv.mu.Lock()
+			inv.config = cf
+			inv.mu.Unlock()
+		}
+	}
+	cf := e.ConfigFile()
+	if cf == nil {
+		return nil
+	}
+
+	sections := e.configScopes(cf)
+	set := explicitFlags(&e.Command.Flags)
+	var errs []error
+	e.Command.Flags.VisitAll(func(f *flag.Flag) {
+		if info := getFlagInfo(f); set[f.Name] || f.Name == "config" || info.aliasOf != "" || info.inherited {
+			return
+		}
+		for _, sec := range sections {
+			v, ok := sec[f.Name]
+			if !ok {
+				continue
+			}
+			if err := setConfigValue(f, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value for flag %q: %w", cf.Path, f.Name, err))
+			}
+			return
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// configScopes returns the sections of cf that apply to the command of e,
+// from innermost to outermost.
+func (e *Env) configScopes(cf *ConfigFile) []map[string]any {
+	sections := []map[string]any{cf.Data}
+	for _, name := range e.path()[1:] {
+		next, ok := sections[0][name].(map[string]any)
+		if !ok {
+			break
+		}
+		sections = append([]map[string]any{next}, sections...)
+	}
+	return sections
+}
+
+// hasConfigValue reports whether the configuration file for the current
+// invocation, if any, gives a value for the named flag of the command of e.
+func (e *Env) hasConfigValue(name string) bool {
+	cf := e.ConfigFile()
+	if cf == nil {
+		return false
+	}
+	for _, sec := range e.configScopes(cf) {
+		if _, ok := sec[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setConfigValue sets the value of f from a configuration value v.
+func setConfigValue(f *flag.Flag, v any) error {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		return errors.New("value is an object")
+	case []any:
+		for _, elt := range t {
+			if err := setConfigValue(f, elt); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return f.Value.Set(fmt.Sprint(t))
+	}
+}
+
+// loadConfigFile reads and decodes the configuration file at path in fsys. If
+// the file does not exist, it returns nil without error unless mustExist is
+// true.
+func loadConfigFile(fsys fs.FS, path string, mustExist bool) (*ConfigFile, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) && !mustExist {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(stripComments(data)))
+	dec.UseNumber()
+	cf := &ConfigFile{Path: path}
+	if err := dec.Decode(&cf.Data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cf, nil
+}
+
+// stripComments returns a copy of data in which the lines whose first
+// non-blank characters are "//" are blanked, so that line positions reported
+// by the decoder are preserved.
+func stripComments(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("//")) {
+			lines[i] = nil
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// WriteConfigExample writes to w an example configuration file for the
+// command tree rooted at root, in the format read by [UseConfigFile]. The
+// example gives each configurable flag its default value, preceded by comments
+// describing its usage, its type, and the commands it affects.
+//
+// A flag is listed in the section of the outermost command that defines it.
+// A subcommand flag with the same name, type, and default value as a flag of
+// an enclosing command is not listed separately, since it takes its value from
+// the enclosing section. Private flags, unlisted commands, and the --config
+// flag itself are omitted.
+func WriteConfigExample(w io.Writer, root *C) error {
+	sec := configSections(root.NewEnv(nil), nil)
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	if sec != nil {
+		sec.write(&buf, "  ")
+	}
+	buf.WriteString("\n}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// A configKey describes a configurable flag for WriteConfigExample.
+type configKey struct {
+	flag    *flag.Flag
+	typ     string   // a description of the type of the flag
+	affects []string // the paths of the commands affected
+}
+
+// A configSection describes the keys and subsections for a command.
+type configSection struct {
+	name string
+	keys []*configKey
+	subs []*configSection
+}
+
+// configSections returns the configuration section for the command of env,
+// or nil if it and its subcommands have no configurable flags. The inherited
+// map gives the nearest enclosing key for each flag name.
+func configSections(env *Env, inherited map[string]*configKey) *configSection {
+	c := env.Command
+	c.setFlags(env, &c.Flags)
+	sec := &configSection{name: c.Name}
+	scope := maps.Clone(inherited)
+	if scope == nil {
+		scope = make(map[string]*configKey)
+	}
+	if !c.CustomFlags {
+		path := strings.Join(env.path(), " ")
+		visitFlags(&c.Flags, c.FlagOrder, func(f *flag.Flag) {
+			if _, ok := unwrapValue(f.Value).(*configFlag); ok {
+				return
+			} else if info := getFlagInfo(f); info.aliasOf != "" || info.inherited || isHidden(f) {
+				return
+			}
+			typ := configType(f)
+			if k := scope[f.Name]; k != nil && k.typ == typ && k.flag.DefValue == f.DefValue {
+				k.affects = append(k.affects, path)
+				return
+			}
+			k := &configKey{flag: f, typ: typ, affects: []string{path}}
+			sec.keys = append(sec.keys, k)
+			scope[f.Name] = k
+		})
+	}
+	for _, sub := range c.Commands {
+		if sub.Unlisted || !sub.Supported() {
+			continue
+		}
+		if s := configSections(env.newChild(sub, nil), scope); s != nil {
+			sec.subs = append(sec.subs, s)
+		}
+	}
+	if len(sec.keys) == 0 && len(sec.subs) == 0 {
+		return nil
+	}
+	return sec
+}
+
+// write renders the contents of s to buf at the given indentation.
+func (s *configSection) write(buf *bytes.Buffer, indent string) {
+	sep := "\n"
+	for _, k := range s.keys {
+		buf.WriteString(sep)
+		sep = ",\n"
+		if u := strings.TrimSpace(k.flag.Usage); u != "" {
+			fmt.Fprintf(buf, "%s// %s\n", indent, u)
+		}
+		fmt.Fprintf(buf, "%s// Type: %s. Affects: %s\n", indent, k.typ, strings.Join(k.affects, ", "))
+		fmt.Fprintf(buf, "%s%s: %s", indent, yamlString(k.flag.Name), configValue(k.flag))
+	}
+	for _, sub := range s.subs {
+		buf.WriteString(sep)
+		sep = ",\n"
+		fmt.Fprintf(buf, "%s%s: {", indent, yamlString(sub.name))
+		sub.write(buf, indent+"  ")
+		fmt.Fprintf(buf, "\n%s}", indent)
+	}
+}
+
+// configType returns a description of the type of value accepted by f.
+func configType(f *flag.Flag) string {
+	g, ok := unwrapValue(f.Value).(flag.Getter)
+	if !ok {
+		return "string"
+	}
+	switch g.Get().(type) {
+	case bool:
+		return "bool"
+	case int, int64, uint, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case time.Duration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// configValue returns the JSON encoding of the default value of f.
+func configValue(f *flag.Flag) string {
+	switch configType(f) {
+	case "bool", "integer", "number":
+		return f.DefValue
+	}
+	if _, ok := unwrapValue(f.Value).(flag.Getter); !ok && f.DefValue == "" {
+		return "[]" // e.g., a flag.Func, which may accumulate values
+	}
+	return yamlString(f.DefValue)
+}
@@ -0,0 +1,243 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestUseConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("AppData", filepath.Join(home, "config"))
+
+	var verbose bool
+	var timeout time.Duration
+	var count int
+	var tags []string
+	var gotFile *command.ConfigFile
+	newRoot := func() *command.C {
+		verbose, timeout, count, tags = false, 0, 0, nil
+		return command.UseConfigFile(&command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.BoolVar(&verbose, "verbose", false, "Verbose output")
+			},
+			Commands: []*command.C{{
+				Name: "remote",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.DurationVar(&timeout, "timeout", 0, "Timeout")
+				},
+				Commands: []*command.C{{
+					Name: "add",
+					SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+						fs.IntVar(&count, "count", 1, "Count")
+						fs.Func("tag", "Tags", func(s string) error { tags = append(tags, s); return nil })
+					},
+					Run: func(env *command.Env) error {
+						gotFile = env.ConfigFile()
+						return nil
+					},
+				}},
+			}},
+		}, nil)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(`{
+  "verbose": true,
+  "timeout": "1m",
+  "count": 5,
+  "remote": {
+    "timeout": "30s",
+    "add": {"tag": ["a", "b"]}
+  }
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Explicit", func(t *testing.T) {
+		root := newRoot()
+		err := command.Run(root.NewEnv(nil), []string{"--config", path, "remote", "add", "--count", "3"})
+		if err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+		if !verbose || timeout != 30*time.Second || count != 3 || strings.Join(tags, ",") != "a,b" {
+			t.Errorf("Flags: verbose=%v timeout=%v count=%d tags=%q", verbose, timeout, count, tags)
+		}
+		if gotFile == nil || gotFile.Path != path {
+			t.Errorf("ConfigFile: got %+v, want path %q", gotFile, path)
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		gotFile = nil
+		root := newRoot()
+		if err := command.Run(root.NewEnv(nil), []string{"remote", "add"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+		if verbose || count != 1 || gotFile != nil {
+			t.Errorf("Flags: verbose=%v count=%d file=%+v", verbose, count, gotFile)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		root := newRoot()
+		missing := filepath.Join(t.TempDir(), "nonesuch.json")
+		if err := command.Run(root.NewEnv(nil), []string{"--config", missing, "remote", "add"}); err == nil {
+			t.Error("Run: got nil, want error for missing config")
+		}
+	})
+
+	t.Run("BadValue", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.json")
+		if err := os.WriteFile(bad, []byte(`{"count": "many"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		root := newRoot()
+		err := command.Run(root.NewEnv(nil), []string{"--config", bad, "remote", "add"})
+		if err == nil || !strings.Contains(err.Error(), `invalid value for flag "count"`) {
+			t.Errorf("Run: got %v, want invalid value error", err)
+		}
+	})
+}
+
+func TestWriteConfigExample(t *testing.T) {
+	root := command.UseConfigFile(&command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.Bool("verbose", false, "Verbose output")
+			fs.Int("secret", 0, "PRIVATE:Not listed")
+		},
+		Commands: []*command.C{{
+			Name: "remote",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.Duration("timeout", time.Minute, "Timeout")
+				fs.Bool("verbose", false, "Verbose output")
+			},
+			Commands: []*command.C{{
+				Name: "add",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.String("name", "origin", "Remote name")
+					fs.Func("tag", "Tags", func(string) error { return nil })
+					fs.Bool("verbose", true, "Verbose output")
+				},
+				Run: func(*command.Env) error { return nil },
+			}},
+		}, {
+			Name: "empty",
+			Run:  func(*command.Env) error { return nil },
+		}},
+	}, nil)
+
+	var buf strings.Builder
+	if err := command.WriteConfigExample(&buf, root); err != nil {
+		t.Fatalf("WriteConfigExample: unexpected error: %v", err)
+	}
+	const want = `{
+  // Verbose output
+  // Type: bool. Affects: tool, tool remote
+  "verbose": false,
+  "remote": {
+    // Timeout
+    // Type: duration. Affects: tool remote
+    "timeout": "1m0s",
+    "add": {
+      // Remote name
+      // Type: string. Affects: tool remote add
+      "name": "origin",
+      // Tags
+      // Type: string. Affects: tool remote add
+      "tag": [],
+      // Verbose output
+      // Type: bool. Affects: tool remote add
+      "verbose": true
+    }
+  }
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteConfigExample: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The example can be loaded as a configuration file.
+	path := filepath.Join(t.TempDir(), "example.json")
+	if err := os.WriteFile(path, []byte(buf.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := command.Run(root.Clone().NewEnv(nil), []string{"--config", path, "remote", "add"}); err != nil {
+		t.Errorf("Run with example config: unexpected error: %v", err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+	path := filepath.Join(home, "tool", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	root := command.UseConfigFile(&command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.IntVar(&count, "count", 1, "Count")
+		},
+		Run: func(*command.Env) error { return nil },
+	}, &command.ConfigOptions{Cache: true})
+
+	env := root.NewEnv(nil).SetProgramName("tool")
+	snap := env.SnapshotFlags()
+	check := func(want int) {
+		t.Helper()
+		if err := env.RestoreFlags(snap); err != nil {
+			t.Fatalf("RestoreFlags: %v", err)
+		}
+		if err := command.Run(env, nil); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		} else if count != want {
+			t.Errorf("Run: count is %d, want %d", count, want)
+		}
+	}
+
+	writeConfig(`{"count": 5}`)
+	check(5)
+
+	// The cached file is used until it is reloaded.
+	writeConfig(`{"count": 7}`)
+	check(5)
+	if err := command.ReloadConfig(root); err != nil {
+		t.Fatalf("ReloadConfig: unexpected error: %v", err)
+	}
+	check(7)
+
+	// If reloading fails, the previous contents remain in use.
+	writeConfig(`{"count": `)
+	if err := command.ReloadConfig(root); err == nil {
+		t.Error("ReloadConfig: got nil error, want error")
+	}
+	check(7)
+
+	// Without values from the file, flags have their defaults.
+	writeConfig(`{}`)
+	if err := command.ReloadConfig(root); err != nil {
+		t.Fatalf("ReloadConfig: unexpected error: %v", err)
+	}
+	check(1)
+}
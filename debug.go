@@ -0,0 +1,376 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugOptions are settings for standard debugging and profiling support.
+// Use its Install method to add hidden flags and hooks for the settings to
+// the root of a command tree:
+//
+//	var debug command.DebugOptions
+//	root := debug.Install(&command.C{
+//	   Name: "tool",
+//	   // ...
+//	})
+//
+// The flags are private (see [IncludePrivateFlags]), so they are not listed
+// in help unless requested.
+type DebugOptions struct {
+	// Addr, if non-empty, is the address at which to serve profiling data
+	// while the program runs, in the format of [runtime/pprof] at the path
+	// "/debug/pprof/". The data can be read with "go tool pprof".
+	Addr string
+
+	// CPUProfile, if non-empty, is the path of a file to which a CPU profile
+	// of the command is written.
+	CPUProfile string
+
+	// MemProfile, if non-empty, is the path of a file to which a heap profile
+	// is written when the command completes.
+	MemProfile string
+
+	srv     *http.Server
+	cpuFile *os.File
+}
+
+// SetFlags registers --debug-addr, --cpuprofile, and --memprofile flags on fs
+// that set the fields of o.
+func (o *DebugOptions) SetFlags(_ *Env, fs *flag.FlagSet) {
+	fs.StringVar(&o.Addr, "debug-addr", o.Addr, "Serve profiling data at this address")
+	fs.StringVar(&o.CPUProfile, "cpuprofile", o.CPUProfile, "Write a CPU profile to this file")
+	fs.StringVar(&o.MemProfile, "memprofile", o.MemProfile, "Write a heap profile to this file")
+	HideFlags(fs, "debug-addr", "cpuprofile", "memprofile")
+}
+
+// Startup starts the debug server and CPU profile requested by o. It is
+// suitable for use as the Startup field of a [C].
+func (o *DebugOptions) Startup(env *Env) error {
+	if o.Addr != "" {
+		lst, err := net.Listen("tcp", o.Addr)
+		if err != nil {
+			return fmt.Errorf("debug server: %w", err)
+		}
+		o.srv = &http.Server{Handler: debugHandler()}
+		go o.srv.Serve(lst)
+		fmt.Fprintf(env, "Serving debug data at http://%s/debug/pprof/\n", lst.Addr())
+	}
+	if o.CPUProfile != "" {
+		f, err := os.Create(o.CPUProfile)
+		if err != nil {
+			return errors.Join(err, o.stopServer())
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return errors.Join(err, o.stopServer())
+		}
+		o.cpuFile = f
+	}
+	return nil
+}
+
+// Shutdown stops the debug server and CPU profile started by Startup, and
+// writes the heap profile requested by o. It is suitable for use as the
+// Shutdown field of a [C]. Errors writing profiles are joined to err.
+func (o *DebugOptions) Shutdown(_ *Env, err error) error {
+	errs := []error{err}
+	if o.cpuFile != nil {
+		pprof.StopCPUProfile()
+		errs = append(errs, o.cpuFile.Close())
+		o.cpuFile = nil
+	}
+	if o.MemProfile != "" {
+		errs = append(errs, writeHeapProfile(o.MemProfile))
+	}
+	errs = append(errs, o.stopServer())
+	return errors.Join(errs...)
+}
+
+// Install adds the flags and hooks of o to root, and returns root. Any
+// existing SetFlags, Startup, and Shutdown functions of root are preserved,
+// and run inside the debugging hooks so that they are profiled.
+func (o *DebugOptions) Install(root *C) *C {
+	setFlags, startup, shutdown := root.SetFlags, root.Startup, root.Shutdown
+	root.SetFlags = func(env *Env, fs *flag.FlagSet) {
+		if setFlags != nil {
+			setFlags(env, fs)
+		}
+		o.SetFlags(env, fs)
+	}
+	root.Startup = func(env *Env) error {
+		if err := o.Startup(env); err != nil {
+			return err
+		}
+		if startup != nil {
+			if err := startup(env); err != nil {
+				return o.Shutdown(env, err)
+			}
+		}
+		return nil
+	}
+	root.Shutdown = func(env *Env, err error) error {
+		if shutdown != nil {
+			err = shutdown(env, err)
+		}
+		return o.Shutdown(env, err)
+	}
+	return root
+}
+
+func (o *DebugOptions) stopServer() error {
+	if o.srv == nil {
+		return nil
+	}
+	defer func() { o.srv = nil }()
+	return o.srv.Close()
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC() // update statistics for the profile
+	return errors.Join(pprof.WriteHeapProfile(f), f.Close())
+}
+
+// debugHandler returns an HTTP handler that serves profiles in the format
+// expected by "go tool pprof". Unlike the net/http/pprof package, it does
+// not register handlers on the default mux.
+func debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+		if name == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			for _, p := range pprof.Profiles() {
+				fmt.Fprintf(w, "%s\t%d\n", p.Name(), p.Count())
+			}
+			fmt.Fprintln(w, "profile\t(CPU profile; ?seconds=n)")
+			return
+		}
+		p := pprof.Lookup(name)
+		if p == nil {
+			http.Error(w, "unknown profile "+strconv.Quote(name), http.StatusNotFound)
+			return
+		}
+		debug, _ := strconv.Atoi(r.FormValue("debug"))
+		if debug > 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		p.WriteTo(w, debug)
+	})
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		sec, err := strconv.Atoi(r.FormValue("seconds"))
+		if err != nil || sec <= 0 {
+			sec = 30
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := pprof.StartCPUProfile(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer pprof.StopCPUProfile()
+		select {
+		case <-time.After(time.Duration(sec) * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	return mux
+}
+
+// traceMarker is the name of the file in the state directory of the program
+// whose presence enables tracing (see Env.Tracing).
+const traceMarker = "trace-enabled"
+
+// Tracing reports whether tracing is enabled for the program. Tracing is
+// turned on and off by the "trace" subcommand of [DebugCommand], and the
+// setting persists in the state directory of the program (see Env.StateDir)
+// until it is changed. The setting is checked once per call to [Run], in the
+// file system of e (see Env.FS).
+func (e *Env) Tracing() bool {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if !inv.traceChecked {
+		_, err := fs.Stat(e.FS(), e.traceMarkerPath())
+		inv.tracing, inv.traceChecked = err == nil, true
+	}
+	return inv.tracing
+}
+
+// traceMarkerPath returns the name of the trace marker file for e, in the file
+// system of e.
+func (e *Env) traceMarkerPath() string {
+	return e.fsName(filepath.Join(e.StateDir(), traceMarker))
+}
+
+// Tracef writes a message to the diagnostic output of e prefixed by "trace: ",
+// if tracing is enabled (see Env.Tracing). Otherwise it does nothing.
+func (e *Env) Tracef(msg string, args ...any) {
+	if e.Tracing() {
+		fmt.Fprintln(e, "trace:", fmt.Sprintf(msg, args...))
+	}
+}
+
+// DebugCommand constructs a standardized "debug" command with subcommands
+// that report on the configuration and structure of the program, for use by
+// developers and support engineers. The command is unlisted, so it does not
+// appear in help unless requested. The caller is free to edit the resulting
+// command, each call returns a separate value.
+//
+// The subcommands are:
+//
+//	config     print the configuration file and flag values in effect
+//	tree       print the command tree, including unlisted commands
+//	help-json  print help for a command and its subcommands as JSON
+//	paths      print the directories used by the program
+//	trace      turn tracing on or off (see Env.Tracing)
+//
+// The debug command should be a subcommand of the root, since its
+// subcommands report on the tree from the root.
+func DebugCommand() *C {
+	return &C{
+		Name:     "debug",
+		Help:     "Report details of the program for debugging.",
+		Unlisted: true,
+		Commands: []*C{{
+			Name: "config",
+			Help: `Print the configuration in effect.
+
+Print the configuration file loaded for the program, if any (see UseConfigFile),
+and the values of the flags of the commands enclosing this one, as JSON.`,
+			Run: Adapt(func(env *Env) error {
+				out := struct {
+					File  *ConfigFile                  `json:"file"`
+					Flags map[string]map[string]string `json:"flags"`
+				}{File: env.ConfigFile(), Flags: make(map[string]map[string]string)}
+				for cur := env.Parent; cur != nil; cur = cur.Parent {
+					vals := make(map[string]string)
+					cur.Command.Flags.VisitAll(func(f *flag.Flag) { vals[f.Name] = f.Value.String() })
+					out.Flags[strings.Join(cur.path(), " ")] = vals
+				}
+				return writeJSON(env.Stdout(), out)
+			}),
+		}, {
+			Name: "tree",
+			Help: "Print the command tree, including unlisted commands.",
+			Run: Adapt(func(env *Env) error {
+				root := debugRoot(env)
+				var walk func(indent string, c *C)
+				walk = func(indent string, c *C) {
+					var tags []string
+					if c.Unlisted {
+						tags = append(tags, "unlisted")
+					}
+					if !c.Supported() {
+						tags = append(tags, "unsupported")
+					}
+					if c.isTopic() && c != root.Command {
+						tags = append(tags, "topic")
+					}
+					line := indent + c.Name
+					if len(tags) != 0 {
+						line += " (" + strings.Join(tags, ", ") + ")"
+					}
+					fmt.Fprintln(env.Stdout(), line)
+					for _, sub := range c.Commands {
+						walk(indent+"  ", sub)
+					}
+				}
+				walk("", root.Command)
+				return nil
+			}),
+		}, {
+			Name:  "help-json",
+			Usage: "[command ...]",
+			Help: `Print help for a command and its subcommands as JSON.
+
+With no arguments, print help for the root command.`,
+			Run: func(env *Env) error {
+				root := debugRoot(env).HelpFlags(IncludeUnlisted | IncludePrivateFlags)
+				target := walkArgs(root, env.Args)
+				if target == nil {
+					return env.Usagef("unknown command %q", strings.Join(env.Args, " "))
+				}
+				return writeJSON(env.Stdout(), target.Command.helpInfo(target, target.hflag|IncludeCommands))
+			},
+		}, {
+			Name: "paths",
+			Help: "Print the directories used by the program.",
+			Run: Adapt(func(env *Env) error {
+				w := env.Stdout()
+				fmt.Fprintf(w, "program\t%s\n", env.ProgramName())
+				fmt.Fprintf(w, "config\t%s\n", env.ConfigDir())
+				fmt.Fprintf(w, "state\t%s\n", env.StateDir())
+				if fs, ok := env.Secrets().(FileSecrets); ok {
+					fmt.Fprintf(w, "secrets\t%s\n", fs.Dir)
+				}
+				fmt.Fprintf(w, "temp\t%s\n", os.TempDir())
+				return nil
+			}),
+		}, {
+			Name:  "trace",
+			Usage: "[on|off]",
+			Help: `Turn tracing on or off, or report whether it is on.
+
+The setting persists until it is changed.`,
+			Run: Adapt(func(env *Env, state []string) error {
+				fsys, marker := env.FS(), env.traceMarkerPath()
+				switch strings.Join(state, " ") {
+				case "":
+					if env.Tracing() {
+						fmt.Fprintln(env.Stdout(), "tracing is on")
+					} else {
+						fmt.Fprintln(env.Stdout(), "tracing is off")
+					}
+					return nil
+				case "on":
+					if err := MkdirAll(fsys, env.fsName(env.StateDir()), 0700); err != nil {
+						return err
+					}
+					return WriteFile(fsys, marker, nil, 0600)
+				case "off":
+					if err := Remove(fsys, marker); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+					return nil
+				}
+				return env.Usagef("invalid trace setting %q", strings.Join(state, " "))
+			}),
+		}},
+	}
+}
+
+// debugRoot returns the root environment of e.
+func debugRoot(e *Env) *Env {
+	for e.Parent != nil {
+		e = e.Parent
+	}
+	return e
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/commandtest"
+)
+
+func TestDebugOptions(t *testing.T) {
+	dir := t.TempDir()
+	cpuFile := filepath.Join(dir, "cpu.prof")
+	memFile := filepath.Join(dir, "mem.prof")
+
+	var opts command.DebugOptions
+	var log strings.Builder
+	var index string
+	var shutdown bool
+	root := opts.Install(&command.C{
+		Name:     "tool",
+		Shutdown: func(_ *command.Env, err error) error { shutdown = true; return err },
+		Run: func(env *command.Env) error {
+			_, url, ok := strings.Cut(strings.TrimSpace(log.String()), " at ")
+			if !ok {
+				t.Fatalf("No server address in %q", log.String())
+			}
+			rsp, err := http.Get(url)
+			if err != nil {
+				return err
+			}
+			defer rsp.Body.Close()
+			data, err := io.ReadAll(rsp.Body)
+			index = string(data)
+			return err
+		},
+	})
+	env := root.NewEnv(nil)
+	env.Log = &log
+	err := command.Run(env, []string{
+		"--debug-addr", "localhost:0", "--cpuprofile", cpuFile, "--memprofile", memFile,
+	})
+	if err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if !shutdown {
+		t.Error("The original Shutdown hook was not called")
+	}
+	if !strings.Contains(index, "goroutine") {
+		t.Errorf("Debug index: got %q, want goroutine profile", index)
+	}
+	for _, path := range []string{cpuFile, memFile} {
+		if fi, err := os.Stat(path); err != nil {
+			t.Errorf("Profile: %v", err)
+		} else if fi.Size() == 0 {
+			t.Errorf("Profile %q is empty", path)
+		}
+	}
+
+	// The flags are not listed in ordinary help.
+	if h := root.HelpInfo(0); strings.Contains(h.Flags, "cpuprofile") {
+		t.Errorf("Debug flags are listed in help:\n%s", h.Flags)
+	}
+}
+
+func TestDebugCommand(t *testing.T) {
+	// The state directory is in an in-memory file system, which is treated
+	// as the root of the OS file system.
+	environ := map[string]string{"XDG_STATE_HOME": "/home/user/.local/state"}
+	fsys := make(commandtest.MapFS)
+
+	var verbose bool
+	var traced []string
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&verbose, "v", false, "Verbose output")
+		},
+		Commands: []*command.C{{
+			Name: "work",
+			Help: "Do some work.",
+			Run: func(env *command.Env) error {
+				traced = append(traced, fmt.Sprint(env.Tracing()))
+				env.Tracef("working")
+				return nil
+			},
+		}, {
+			Name:     "secret",
+			Unlisted: true,
+			Run:      func(*command.Env) error { return nil },
+		}, command.DebugCommand()},
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var out strings.Builder
+		env := root.NewEnv(nil).SetProgramName("tool").SetStdout(&out).SetEnviron(environ).SetFS(fsys)
+		env.Log = io.Discard
+		if err := command.Run(env, args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+		return out.String()
+	}
+
+	if h := root.HelpInfo(command.IncludeCommands); slices.ContainsFunc(h.Commands, func(h command.HelpInfo) bool {
+		return h.Name == "debug"
+	}) {
+		t.Error("The debug command is listed in help")
+	}
+
+	const wantTree = "tool\n  work\n  secret (unlisted)\n  debug (unlisted)\n"
+	if got := run("debug", "tree"); !strings.HasPrefix(got, wantTree) {
+		t.Errorf("Tree: got %q, want prefix %q", got, wantTree)
+	}
+
+	var cfg struct {
+		Flags map[string]map[string]string
+	}
+	if err := json.Unmarshal([]byte(run("-v", "debug", "config")), &cfg); err != nil {
+		t.Errorf("Config: invalid JSON: %v", err)
+	} else if got := cfg.Flags["tool"]["v"]; got != "true" {
+		t.Errorf("Config: flag v is %q, want true", got)
+	}
+
+	var info command.HelpInfo
+	if err := json.Unmarshal([]byte(run("debug", "help-json", "work")), &info); err != nil {
+		t.Errorf("Help JSON: invalid JSON: %v", err)
+	} else if info.Name != "work" || info.Synopsis != "Do some work." {
+		t.Errorf("Help JSON: got %+v", info)
+	}
+
+	if got, want := run("debug", "paths"), "state\t"+filepath.Join("/home/user/.local/state", "tool")+"\n"; !strings.Contains(got, want) {
+		t.Errorf("Paths: got %q, want state directory", got)
+	}
+
+	run("work")
+	run("debug", "trace", "on")
+	if got := run("debug", "trace"); got != "tracing is on\n" {
+		t.Errorf("Trace: got %q, want on", got)
+	}
+	if _, ok := fsys["home/user/.local/state/tool/trace-enabled"]; !ok {
+		t.Error("Trace marker was not created in the file system of the Env")
+	}
+	run("work")
+	run("debug", "trace", "off")
+	run("work")
+	if want := []string{"false", "true", "false"}; !slices.Equal(traced, want) {
+		t.Errorf("Tracing: got %q, want %q", traced, want)
+	}
+}
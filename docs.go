@@ -0,0 +1,182 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteMarkdownDocs writes a Markdown file documenting each command in the
+// tree rooted at root into dir, which is created if necessary. The layout of
+// the files matches the Markdown documentation generated by the cobra library,
+// so that documentation pipelines built for that layout can consume them.
+//
+// Each file is named for the path of its command with words joined by "_",
+// for example "tool_remote_add.md". Unlisted commands and help topics are not
+// documented.
+func WriteMarkdownDocs(root *C, dir string) error {
+	return writeDocs(root, dir, ".md", func(buf *bytes.Buffer, d docInfo) {
+		fmt.Fprintf(buf, "## %s\n\n%s\n\n", d.path, d.info.Synopsis)
+		fmt.Fprintf(buf, "### Synopsis\n\n%s\n\n", d.info.Help)
+		if len(d.usage) != 0 {
+			fmt.Fprintf(buf, "```\n%s\n```\n\n", strings.Join(d.usage, "\n"))
+		}
+		if d.info.Flags != "" {
+			// Omit the generic heading, but keep the headings of flag groups.
+			flags := strings.TrimPrefix(d.info.Flags, "Flags:\n")
+			fmt.Fprintf(buf, "### Options\n\n```\n%s\n```\n\n", flags)
+		}
+		if d.parent != nil || len(d.children) != 0 {
+			fmt.Fprint(buf, "### SEE ALSO\n\n")
+			for _, r := range append([]*docInfo{d.parent}, d.children...) {
+				if r != nil {
+					fmt.Fprintf(buf, "* [%s](%s.md)\t - %s\n", r.path, r.base(), r.info.Synopsis)
+				}
+			}
+			fmt.Fprintln(buf)
+		}
+	})
+}
+
+// WriteYAMLDocs writes a YAML file documenting each command in the tree
+// rooted at root into dir, which is created if necessary. The layout of the
+// files matches the YAML documentation generated by the cobra library, so that
+// documentation pipelines built for that layout can consume them.
+//
+// Each file is named for the path of its command with words joined by "_",
+// for example "tool_remote_add.yaml". Unlisted commands and help topics are
+// not documented.
+func WriteYAMLDocs(root *C, dir string) error {
+	return writeDocs(root, dir, ".yaml", func(buf *bytes.Buffer, d docInfo) {
+		fmt.Fprintf(buf, "name: %s\n", yamlString(d.path))
+		fmt.Fprintf(buf, "synopsis: %s\n", yamlString(d.info.Synopsis))
+		fmt.Fprintf(buf, "description: %s\n", yamlString(d.info.Help))
+		if len(d.usage) != 0 {
+			fmt.Fprintf(buf, "usage: %s\n", yamlString(strings.Join(d.usage, "\n")))
+		}
+		if len(d.options) != 0 {
+			fmt.Fprintln(buf, "options:")
+			for _, opt := range d.options {
+				fmt.Fprintf(buf, "- name: %s\n", yamlString(opt.name))
+				if opt.short != "" {
+					fmt.Fprintf(buf, "  shorthand: %s\n", yamlString(opt.short))
+				}
+				if opt.defValue != "" {
+					fmt.Fprintf(buf, "  default_value: %s\n", yamlString(opt.defValue))
+				}
+				fmt.Fprintf(buf, "  usage: %s\n", yamlString(opt.usage))
+			}
+		}
+		if d.parent != nil || len(d.children) != 0 {
+			fmt.Fprintln(buf, "see_also:")
+			for _, r := range append([]*docInfo{d.parent}, d.children...) {
+				if r != nil {
+					fmt.Fprintf(buf, "- %s\n", yamlString(r.path+" - "+r.info.Synopsis))
+				}
+			}
+		}
+	})
+}
+
+// docInfo records the details needed to document a single command.
+type docInfo struct {
+	path     string   // the full command path, e.g., "tool remote add"
+	info     HelpInfo // help for the command
+	usage    []string // usage lines, prefixed by the path
+	options  []docOption
+	parent   *docInfo
+	children []*docInfo
+}
+
+// base returns the base name of the documentation file for d.
+func (d *docInfo) base() string { return strings.ReplaceAll(d.path, " ", "_") }
+
+// docOption describes a single flag for documentation.
+type docOption struct {
+	name, short, defValue, usage string
+}
+
+// writeDocs renders a file for each documented command in the tree rooted at
+// root into dir, using the given file extension and rendering function.
+func writeDocs(root *C, dir, ext string, render func(*bytes.Buffer, docInfo)) error {
+	var docs []*docInfo
+	var walk func(env *Env, parent *docInfo)
+	walk = func(env *Env, parent *docInfo) {
+		c := env.Command
+		c.setFlags(env, &c.Flags)
+		d := &docInfo{
+			path:    strings.Join(env.path(), " "),
+			info:    c.helpInfo(env, 0),
+			options: docOptions(c),
+			parent:  parent,
+		}
+		for _, line := range c.usageLines(0) {
+			d.usage = append(d.usage, joinSpace(d.path, env.expandHelp(line)))
+		}
+		if parent != nil {
+			parent.children = append(parent.children, d)
+		}
+		docs = append(docs, d)
+		for _, sub := range c.Commands {
+			if !sub.Unlisted && !sub.isTopic() {
+				walk(env.newChild(sub, nil), d)
+			}
+		}
+	}
+	walk(root.NewEnv(nil), nil)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var errs []error
+	for _, d := range docs {
+		var buf bytes.Buffer
+		render(&buf, *d)
+		errs = append(errs, os.WriteFile(filepath.Join(dir, d.base()+ext), buf.Bytes(), 0644))
+	}
+	return errors.Join(errs...)
+}
+
+// docOptions returns documentation for the public flags of c, in the order
+// they are listed in help.
+func docOptions(c *C) []docOption {
+	if c.CustomFlags {
+		return nil
+	}
+	var opts []docOption
+	visitFlags(&c.Flags, c.FlagOrder, func(f *flag.Flag) {
+		info := getFlagInfo(f)
+		if info.aliasOf != "" || info.inherited || isHidden(f) {
+			return
+		}
+		opt := docOption{name: f.Name, defValue: f.DefValue, usage: flagUsage(f)}
+		for _, alias := range info.aliases {
+			if len(alias) == 1 {
+				opt.short = alias
+				break
+			}
+		}
+		if info.hasDefText {
+			opt.defValue = info.defText
+		}
+		opts = append(opts, opt)
+	})
+	return opts
+}
+
+// yamlString encodes s as a YAML scalar. A JSON string is a valid YAML
+// double-quoted scalar.
+func yamlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func newDocsTestCommand() *command.C {
+	return &command.C{
+		Name: "tool",
+		Help: "A tool for <testing>.\n\nIt does many things.",
+		Commands: []*command.C{
+			{
+				Name: "get",
+				Help: "Fetch a thing.",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					var out string
+					command.VarP(fs, newStringValue(&out, "-"), "output", "o", "Write output here")
+					fs.Bool("secret", false, "PRIVATE:Not documented")
+				},
+				Run: func(*command.Env) error { return nil },
+			},
+			{Name: "hidden", Unlisted: true, Run: func(*command.Env) error { return nil }},
+			{Name: "topic", Help: "A topic."},
+			command.HelpCommand(nil),
+		},
+	}
+}
+
+func TestWriteMarkdownDocs(t *testing.T) {
+	dir := t.TempDir()
+	if err := command.WriteMarkdownDocs(newDocsTestCommand(), dir); err != nil {
+		t.Fatalf("WriteMarkdownDocs: unexpected error: %v", err)
+	}
+	if got, want := listDir(t, dir), []string{"tool.md", "tool_get.md", "tool_help.md"}; !slices.Equal(got, want) {
+		t.Errorf("Files: got %q, want %q", got, want)
+	}
+	checkContains(t, filepath.Join(dir, "tool_get.md"),
+		"## tool get\n\nFetch a thing.\n\n### Synopsis\n",
+		"```\ntool get [flags]\n```",
+		"### Options\n\n```\n  -o, --output string\n",
+		"### SEE ALSO\n\n* [tool](tool.md)\t - A tool for <testing>.\n",
+	)
+	checkContains(t, filepath.Join(dir, "tool.md"),
+		"* [tool get](tool_get.md)\t - Fetch a thing.\n",
+	)
+}
+
+func TestWriteMarkdownDocsGroups(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Help: "A tool.",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.String("host", "", "Server host")
+			fs.Int("port", 0, "Server port")
+			command.FlagGroup(fs, "Connection", "host", "port")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	dir := t.TempDir()
+	if err := command.WriteMarkdownDocs(root, dir); err != nil {
+		t.Fatalf("WriteMarkdownDocs: unexpected error: %v", err)
+	}
+	checkContains(t, filepath.Join(dir, "tool.md"),
+		"### Options\n\n```\nConnection:\n --host string\n",
+	)
+}
+
+func TestWriteYAMLDocs(t *testing.T) {
+	dir := t.TempDir()
+	if err := command.WriteYAMLDocs(newDocsTestCommand(), dir); err != nil {
+		t.Fatalf("WriteYAMLDocs: unexpected error: %v", err)
+	}
+	if got, want := listDir(t, dir), []string{"tool.yaml", "tool_get.yaml", "tool_help.yaml"}; !slices.Equal(got, want) {
+		t.Errorf("Files: got %q, want %q", got, want)
+	}
+	checkContains(t, filepath.Join(dir, "tool_get.yaml"), `name: "tool get"
+synopsis: "Fetch a thing."
+description: "Fetch a thing."
+usage: "tool get [flags]"
+options:
+- name: "output"
+  shorthand: "o"
+  default_value: "-"
+  usage: "Write output here"
+see_also:
+- "tool - A tool for <testing>."
+`)
+	checkContains(t, filepath.Join(dir, "tool.yaml"),
+		`description: "A tool for <testing>.\n\nIt does many things."`,
+	)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func checkContains(t *testing.T, path string, want ...string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range want {
+		if !strings.Contains(string(data), w) {
+			t.Errorf("File %q does not contain %q:\n%s", filepath.Base(path), w, data)
+		}
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("File %q documents a private flag:\n%s", filepath.Base(path), data)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DownloadOptions are optional settings for the Download method of an [Env].
+// A nil *DownloadOptions is ready for use and provides default values.
+type DownloadOptions struct {
+	// Client is the HTTP client used to issue requests. If nil, it uses the
+	// client associated with the environment (see Env.HTTPClient).
+	Client *http.Client
+
+	// SHA256, if non-empty, is the expected SHA-256 digest of the downloaded
+	// content, encoded as hexadecimal. If the content does not match, the
+	// download fails and the target is not written.
+	SHA256 string
+
+	// If Resume is true, an incomplete download left over from a previous
+	// attempt is continued rather than restarted, if the server supports it.
+	// Otherwise, any partial data from a failed attempt are discarded. If the
+	// server reports that there is nothing more to send, the partial data are
+	// accepted as complete only if they match SHA256; if SHA256 is not set,
+	// the download restarts from the beginning, as it does if the server
+	// responds with a range other than the one requested.
+	Resume bool
+
+	// Progress, if non-nil, is called as data are received with the number of
+	// bytes written so far and the total expected size. The total is -1 if the
+	// size is not known.
+	Progress func(done, total int64)
+}
+
+func (o *DownloadOptions) client(env *Env) *http.Client {
+	if o == nil || o.Client == nil {
+		return env.HTTPClient()
+	}
+	return o.Client
+}
+
+func (o *DownloadOptions) sha256() string {
+	if o == nil {
+		return ""
+	}
+	return strings.ToLower(o.SHA256)
+}
+
+func (o *DownloadOptions) resume() bool { return o != nil && o.Resume }
+
+func (o *DownloadOptions) progress() func(done, total int64) {
+	if o == nil || o.Progress == nil {
+		return func(int64, int64) {}
+	}
+	return o.Progress
+}
+
+// Download fetches the contents of url via HTTP GET and writes them to the
+// file at path. The request is governed by the context of e.
+//
+// Data are written to a temporary file alongside path (path + ".partial"),
+// which is renamed to path only once the download is complete and verified.
+func (e *Env) Download(url, path string, opts *DownloadOptions) (err error) {
+	partial := path + ".partial"
+	defer func() {
+		if err != nil && !opts.resume() {
+			os.Remove(partial)
+		}
+	}()
+
+	var offset int64
+	if opts.resume() {
+		if fi, err := os.Stat(partial); err == nil {
+			offset = fi.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(e.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	rsp, err := opts.client(e).Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case rsp.StatusCode == http.StatusPartialContent && offset > 0:
+		if rangeStart(rsp.Header.Get("Content-Range")) != offset {
+			// The server sent a range other than the one requested.
+			return e.restartDownload(url, path, opts)
+		}
+		flags |= os.O_APPEND
+	case rsp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if opts.sha256() == "" {
+			// The partial file may be complete, or may be stale, and without a
+			// checksum there is no way to tell.
+			return e.restartDownload(url, path, opts)
+		}
+		// The partial file may already be complete; let verification decide.
+		flags |= os.O_APPEND
+		rsp.Body = http.NoBody
+	case rsp.StatusCode == http.StatusOK:
+		offset = 0 // the server did not honor the range request
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("download %q: %s", url, rsp.Status)
+	}
+
+	f, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return err
+	}
+	total := int64(-1)
+	if rsp.ContentLength >= 0 && rsp.Body != http.NoBody {
+		total = offset + rsp.ContentLength
+	}
+	pw := &progressWriter{w: f, done: offset, total: total, report: opts.progress()}
+	_, cerr := io.Copy(pw, rsp.Body)
+	if err := errors.Join(cerr, f.Close()); err != nil {
+		return fmt.Errorf("download %q: %w", url, err)
+	}
+
+	if want := opts.sha256(); want != "" {
+		got, err := fileSHA256(partial)
+		if err != nil {
+			return err
+		} else if got != want {
+			os.Remove(partial) // the contents are not usable
+			return fmt.Errorf("download %q: checksum mismatch: got %s, want %s", url, got, want)
+		}
+	}
+	return os.Rename(partial, path)
+}
+
+// restartDownload discards the partial file for path, and downloads url to
+// path from the beginning.
+func (e *Env) restartDownload(url, path string, opts *DownloadOptions) error {
+	if err := os.Remove(path + ".partial"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return e.Download(url, path, opts)
+}
+
+// rangeStart returns the offset of the first byte of the range described by
+// the Content-Range header value h, or -1 if h does not describe a range.
+func rangeStart(h string) int64 {
+	rest, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return -1
+	}
+	v, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+// progressWriter is an [io.Writer] that reports cumulative progress.
+type progressWriter struct {
+	w           io.Writer
+	done, total int64
+	report      func(done, total int64)
+}
+
+func (p *progressWriter) Write(data []byte) (int, error) {
+	nw, err := p.w.Write(data)
+	p.done += int64(nw)
+	p.report(p.done, p.total)
+	return nw, err
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1024)
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	var lastRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		lastRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "data", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	env := (&command.C{Name: "test"}).NewEnv(nil)
+	checkFile := func(t *testing.T, path string) {
+		t.Helper()
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Read result: %v", err)
+		} else if !bytes.Equal(got, content) {
+			t.Errorf("Downloaded content: got %d bytes, want %d", len(got), len(content))
+		}
+	}
+
+	t.Run("Basic", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out")
+		var done, total int64
+		if err := env.Download(srv.URL, path, &command.DownloadOptions{
+			SHA256:   digest,
+			Progress: func(d, t int64) { done, total = d, t },
+		}); err != nil {
+			t.Fatalf("Download: unexpected error: %v", err)
+		}
+		checkFile(t, path)
+		if want := int64(len(content)); done != want || total != want {
+			t.Errorf("Progress: got %d/%d, want %d/%d", done, total, want, want)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out")
+		err := env.Download(srv.URL, path, &command.DownloadOptions{SHA256: strings.Repeat("0", 64)})
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("Download: got %v, want checksum mismatch", err)
+		}
+		if _, err := os.Stat(path); err == nil {
+			t.Error("Target file exists after a failed download")
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out")
+		if err := os.WriteFile(path+".partial", content[:1000], 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Download(srv.URL, path, &command.DownloadOptions{
+			SHA256: digest, Resume: true,
+		}); err != nil {
+			t.Fatalf("Download: unexpected error: %v", err)
+		}
+		if lastRange != "bytes=1000-" {
+			t.Errorf("Range header: got %q, want bytes=1000-", lastRange)
+		}
+		checkFile(t, path)
+	})
+
+	t.Run("ResumeStale", func(t *testing.T) {
+		// Without a checksum, a partial file the server cannot extend is not
+		// taken to be complete.
+		path := filepath.Join(t.TempDir(), "out")
+		if err := os.WriteFile(path+".partial", append(content, "junk"...), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Download(srv.URL, path, &command.DownloadOptions{Resume: true}); err != nil {
+			t.Fatalf("Download: unexpected error: %v", err)
+		}
+		if lastRange != "" {
+			t.Errorf("Range header: got %q, want none", lastRange)
+		}
+		checkFile(t, path)
+	})
+
+	t.Run("ResumeWrongRange", func(t *testing.T) {
+		// A server that reports a range other than the one requested.
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") != "" {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+				w.WriteHeader(http.StatusPartialContent)
+			}
+			w.Write(content)
+		}))
+		defer srv.Close()
+
+		path := filepath.Join(t.TempDir(), "out")
+		if err := os.WriteFile(path+".partial", content[:1000], 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Download(srv.URL, path, &command.DownloadOptions{Resume: true}); err != nil {
+			t.Fatalf("Download: unexpected error: %v", err)
+		}
+		checkFile(t, path)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out")
+		if err := env.Download(srv.URL+"/nonesuch", path, nil); err == nil {
+			t.Error("Download: got nil, want error")
+		} else {
+			t.Logf("Got expected error: %v", err)
+		}
+		if _, err := os.Stat(path + ".partial"); err == nil {
+			t.Error("Partial file exists after a failed download")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// SetEnviron sets the environment variables visible to e, and returns e. If
+// vars != nil, it replaces the process environment for e: Variables not in
+// vars are treated as unset. If vars == nil, e uses the process environment.
+// The setting is inherited by the descendants of e.
+//
+// This allows a program that runs commands on behalf of several users or
+// requests, such as a server, to give each invocation its own environment
+// without modifying the environment of the process. The environment of e is
+// used for environment variables bound to flags (see C.FlagEnvPrefix), by
+// Env.StateDir, and by programs started with Env.Exec.
+func (e *Env) SetEnviron(vars map[string]string) *Env { e.environ = vars; return e }
+
+// LookupEnv reports the value of the named environment variable of e, and
+// whether it is set (see SetEnviron).
+func (e *Env) LookupEnv(name string) (string, bool) {
+	if e.environ == nil {
+		return os.LookupEnv(name)
+	}
+	v, ok := e.environ[name]
+	return v, ok
+}
+
+// Getenv returns the value of the named environment variable of e, or "" if
+// it is not set (see SetEnviron).
+func (e *Env) Getenv(name string) string {
+	v, _ := e.LookupEnv(name)
+	return v
+}
+
+// Environ returns a copy of the environment of e, as "name=value" strings in
+// the format of [os.Environ] (see SetEnviron).
+func (e *Env) Environ() []string {
+	if e.environ == nil {
+		return os.Environ()
+	}
+	out := make([]string, 0, len(e.environ))
+	for name, value := range e.environ {
+		out = append(out, name+"="+value)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Exec returns an [exec.Cmd] to run the named program with the given
+// arguments in the environment of e (see SetEnviron). The program is bound to
+// the context of e, so it is killed if e is canceled, and its standard output
+// and error are the primary and diagnostic outputs of e. The caller may
+// modify the command before it is started.
+func (e *Env) Exec(name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(e.Context(), name, args...)
+	cmd.Env = e.Environ()
+	cmd.Stdout, cmd.Stderr = e.Stdout(), e.output()
+	return cmd
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestSetEnviron(t *testing.T) {
+	t.Setenv("TOOL_NAME", "process")
+	t.Setenv("TOOL_OTHER", "process")
+
+	var name string
+	var got []string
+	root := &command.C{
+		Name:          "tool",
+		FlagEnvPrefix: "TOOL",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.StringVar(&name, "name", "", "Name")
+		},
+		Commands: []*command.C{{
+			Name: "show",
+			Run: func(env *command.Env) error {
+				got = env.Environ()
+				return nil
+			},
+		}},
+	}
+	run := func(vars map[string]string) {
+		t.Helper()
+		name, got = "", nil
+		if err := command.Run(root.NewEnv(nil).SetEnviron(vars), []string{"show"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+	}
+
+	// Without an environment, the process environment is used.
+	run(nil)
+	if name != "process" {
+		t.Errorf("Flag --name is %q, want process", name)
+	}
+	if !slices.Contains(got, "TOOL_OTHER=process") {
+		t.Errorf("Environ: got %q, want TOOL_OTHER=process", got)
+	}
+
+	// With an environment, it replaces the process environment.
+	run(map[string]string{"TOOL_NAME": "request", "PATH": "/bin"})
+	if name != "request" {
+		t.Errorf("Flag --name is %q, want request", name)
+	}
+	if want := []string{"PATH=/bin", "TOOL_NAME=request"}; !slices.Equal(got, want) {
+		t.Errorf("Environ: got %q, want %q", got, want)
+	}
+
+	env := root.NewEnv(nil).SetEnviron(map[string]string{"A": "1"})
+	if v, ok := env.LookupEnv("TOOL_OTHER"); ok {
+		t.Errorf("LookupEnv TOOL_OTHER: got %q, want unset", v)
+	}
+	if v := env.Getenv("A"); v != "1" {
+		t.Errorf("Getenv A: got %q, want 1", v)
+	}
+}
+
+func TestExec(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skipf("No shell available: %v", err)
+	}
+	var out strings.Builder
+	env := (&command.C{Name: "tool"}).NewEnv(nil).
+		SetEnviron(map[string]string{"GREETING": "hello"}).
+		SetStdout(&out)
+	if err := env.Exec(sh, "-c", `echo "$GREETING"`).Run(); err != nil {
+		t.Fatalf("Exec: unexpected error: %v", err)
+	}
+	if got := out.String(); got != "hello\n" {
+		t.Errorf("Exec output: got %q, want %q", got, "hello\n")
+	}
+}
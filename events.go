@@ -0,0 +1,118 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OutputFlag registers an --output flag on fs that selects the format of the
+// output of the invocation: "text" (the default) for human-readable output, or
+// "json" for machine-readable output. When "json" is selected, events
+// reported by Env.Event are written as newline-delimited JSON, and
+// Env.OutputJSON reports true so that commands can format their own output
+// to match. The flag applies only to the invocation of [Run] in which it is
+// given; it does not remain set for later invocations of the same command tree.
+//
+// OutputFlag is suitable for use as the SetFlags field of a [C], or may be
+// called from another SetFlags function. It is most useful on the root.
+func OutputFlag(_ *Env, fs *flag.FlagSet) {
+	fs.Var(new(outputFlag), "output", "Output format (text or json)")
+}
+
+// outputFlag is a [flag.Value] for the name of an output format.
+type outputFlag string
+
+func (o *outputFlag) String() string {
+	if *o == "" {
+		return "text"
+	}
+	return string(*o)
+}
+
+func (o *outputFlag) Get() any { return o.String() }
+
+func (o *outputFlag) Set(s string) error {
+	switch s {
+	case "text", "json":
+		*o = outputFlag(s)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want text or json)", s)
+}
+
+// checkOutputFlag enables JSON output for the current invocation if the
+// command for e has an --output flag registered by OutputFlag, set to "json".
+// The flag is reset once it is read, so that it does not carry over to later
+// invocations. Outside a call to Run (for example in Resolve), the flag is
+// reset without enabling JSON output.
+func (e *Env) checkOutputFlag() {
+	if f := e.Command.Flags.Lookup("output"); f != nil {
+		if o, ok := unwrapValue(f.Value).(*outputFlag); ok && *o == "json" {
+			*o = ""
+			inv := e.invocation()
+			inv.mu.Lock()
+			defer inv.mu.Unlock()
+			if inv.active {
+				inv.json = true
+			}
+		}
+	}
+}
+
+// OutputJSON reports whether JSON output was selected for the current
+// invocation of [Run] (see OutputFlag).
+func (e *Env) OutputJSON() bool {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.json
+}
+
+// An Event is a structured report of progress by a command (see Env.Event).
+// When JSON output is selected, each event is written as a JSON object with
+// these fields, on a line by itself.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`           // the path of the command, e.g., "tool sync"
+	Type    string    `json:"type"`              // the kind of event, e.g., "progress"
+	Payload any       `json:"payload,omitempty"` // event details, if any
+}
+
+// Event reports an event of the given type with an optional payload, to
+// track the progress of a long-running operation. The event is written to the
+// diagnostic output of e.
+//
+// Normally the event is written as a line of text giving its type and the
+// payload formatted as if by fmt.Print. When JSON output is selected (see
+// OutputFlag), the event is written as an [Event] encoded as a single line of
+// JSON, so that other programs can consume the stream. A payload that cannot
+// be encoded as JSON is replaced by its text form.
+//
+// It is safe to report events concurrently from multiple goroutines.
+func (e *Env) Event(typ string, payload any) {
+	if !e.OutputJSON() {
+		if payload == nil {
+			fmt.Fprintln(e, typ)
+		} else {
+			fmt.Fprintf(e, "%s: %v\n", typ, payload)
+		}
+		return
+	}
+	ev := Event{
+		Time:    e.Now(),
+		Command: strings.Join(e.displayPath(), " "),
+		Type:    typ,
+		Payload: payload,
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		ev.Payload = fmt.Sprint(payload)
+		data, _ = json.Marshal(ev) // cannot fail
+	}
+	e.Write(append(data, '\n'))
+}
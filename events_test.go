@@ -0,0 +1,117 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvent(t *testing.T) {
+	var isJSON bool
+	root := &command.C{
+		Name:     "tool",
+		SetFlags: command.OutputFlag,
+		Commands: []*command.C{{
+			Name: "sync",
+			Run: func(env *command.Env) error {
+				isJSON = env.OutputJSON()
+				env.Event("start", nil)
+				env.Event("progress", map[string]int{"done": 3, "total": 5})
+				env.Event("skip", func() {}) // not encodable as JSON
+				return nil
+			},
+		}},
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var log strings.Builder
+		env := root.NewEnv(nil).SetProgramName("tool")
+		env.Log = &log
+		if err := command.Run(env, args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+		return log.String()
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		out := run("sync")
+		if isJSON {
+			t.Error("OutputJSON is true, want false")
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 3 || lines[0] != "start" || lines[1] != "progress: map[done:3 total:5]" ||
+			!strings.HasPrefix(lines[2], "skip: 0x") {
+			t.Errorf("Text events: got\n%s", out)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		out := run("--output=json", "sync")
+		if !isJSON {
+			t.Error("OutputJSON is false, want true")
+		}
+		type event struct {
+			Command, Type string
+			Payload       any
+		}
+		var got []event
+		sc := bufio.NewScanner(strings.NewReader(out))
+		for sc.Scan() {
+			var ev command.Event
+			if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+				t.Fatalf("Invalid event %q: %v", sc.Text(), err)
+			}
+			if ev.Time.IsZero() {
+				t.Errorf("Event %q has no time", sc.Text())
+			}
+			if ev.Type == "skip" {
+				if s, ok := ev.Payload.(string); !ok || !strings.HasPrefix(s, "0x") {
+					t.Errorf("Event %q: payload is not a text form", sc.Text())
+				}
+				ev.Payload = nil
+			}
+			got = append(got, event{ev.Command, ev.Type, ev.Payload})
+		}
+		want := []event{
+			{"tool sync", "start", nil},
+			{"tool sync", "progress", map[string]any{"done": 3.0, "total": 5.0}},
+			{"tool sync", "skip", nil},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("JSON events (-want, +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		// The format selected by one run does not carry over to the next.
+		run("--output=json", "sync")
+		if run("sync"); isJSON {
+			t.Error("OutputJSON is true after a later run without --output")
+		}
+
+		// Nor does the format selected when resolving a command line.
+		env := root.NewEnv(nil)
+		env.Log = io.Discard
+		if _, err := command.Resolve(env, []string{"--output=json", "sync"}); err != nil {
+			t.Fatalf("Resolve: unexpected error: %v", err)
+		}
+		if err := command.Run(env, []string{"sync"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		} else if isJSON {
+			t.Error("OutputJSON is true after Resolve with --output=json")
+		}
+	})
+
+	env := root.NewEnv(nil)
+	env.Log = io.Discard
+	if err := command.Run(env, []string{"--output=yaml", "sync"}); err == nil {
+		t.Error("Run with --output=yaml: got nil error, want error")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"encoding/json"
+	"flag"
+)
+
+// An Explanation describes what a command line would do if it were run.
+// Its JSON encoding is stable, and suitable for use by scripts and tools.
+type Explanation struct {
+	// Path gives the names of the commands selected by the arguments, from the
+	// root to the command that would be run.
+	Path []string `json:"path"`
+
+	// Flags maps the names of the flags explicitly set by the arguments to
+	// their values, as formatted by the flag. Flags set for any command along
+	// the path are included. If the same flag name is set for more than one
+	// command, the value for the command nearest the end of the path is used.
+	Flags map[string]string `json:"flags"`
+
+	// Args are the positional arguments remaining after flags are parsed,
+	// which would be passed to the selected command.
+	Args []string `json:"args"`
+
+	// Runnable reports whether the selected command has a Run function.
+	// If false, running the arguments would print help.
+	Runnable bool `json:"runnable"`
+}
+
+// Explain reports what would happen if rawArgs were passed to Run with env,
+// without running any commands. It resolves the arguments as [Resolve] does,
+// and so does not call the Startup, Init, or Run functions of any command.
+// Any variables bound to the flags are updated by parsing.
+//
+// Because Init is not called, the result may differ from a real run for a
+// command whose Init function modifies its arguments.
+//
+// If the arguments cannot be parsed, Explain reports the same error that Run
+// would report.
+func Explain(env *Env, rawArgs []string) (Explanation, error) {
+	target, err := Resolve(env, rawArgs)
+	if err != nil {
+		return Explanation{}, err
+	}
+	ex := Explanation{
+		Path:     target.path(),
+		Flags:    make(map[string]string),
+		Args:     target.Args,
+		Runnable: target.Command.Run != nil,
+	}
+	if ex.Args == nil {
+		ex.Args = []string{}
+	}
+	var chain []*Env
+	for cur := target; cur != nil; cur = cur.Parent {
+		chain = append(chain, cur)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		chain[i].Command.Flags.Visit(func(f *flag.Flag) {
+			name := f.Name
+			if alias := getFlagInfo(f).aliasOf; alias != "" {
+				name = alias
+			}
+			ex.Flags[name] = f.Value.String()
+		})
+	}
+	return ex, nil
+}
+
+// Resolve performs the argument traversal of rawArgs starting from env, as
+// [Run] does, and returns the environment for the command that would be run,
+// without running it. It selects subcommands as Run does, including the
+// Default subcommand of a command with no Run function, and reports an error
+// for a command that is not supported on the current platform.
+//
+// Each command along the path is set up as Run sets it up: Its flags are
+// parsed, values from the configuration file and the environment are applied
+// (see UseConfigFile and C.FlagEnvPrefix), path flags are expanded and checked
+// (see PathVar), and flag constraints are checked (see MarkRequired and
+// Requires). Any variables bound to the flags are updated. Unlike Run, Resolve
+// does not read secret sources (see SecretVar), and does not call the Startup,
+// Init, or Run functions of any command.
+//
+// The resulting environment has the Args and Parent fields set as they would
+// be when Init is called. This is useful to inspect a command line before
+// running it, for example to check permissions or to drive a custom execution
+// loop. If the arguments cannot be parsed, Resolve reports the same error
+// that Run would report.
+func Resolve(env *Env, rawArgs []string) (*Env, error) {
+	if _, err := env.prepare(rawArgs, false); err != nil {
+		return nil, err
+	}
+	sub, rest, topic, err := env.selectSubcommand()
+	if err != nil {
+		return nil, err
+	} else if topic {
+		return env.newChild(sub, rest), nil // Run would show help for the topic
+	} else if sub != nil {
+		return Resolve(env.newChild(sub, rest), rest)
+	}
+	return env, nil
+}
+
+// ExplainCommand constructs a standardized "explain" command that reports
+// what its arguments would do if they were given to the root command, without
+// running anything. The caller is free to edit the resulting command, each
+// call returns a separate value.
+//
+// The report is written to stdout as a JSON-encoded [Explanation]. The
+// arguments are parsed using a clone of the command tree (see C.Clone), so
+// the flags of the original tree are not affected.
+func ExplainCommand() *C {
+	return &C{
+		Name:  "explain",
+		Usage: "<command> [flags] [args...]",
+		Help: `Report what a command line would do, without running it.
+
+The arguments are parsed as if they were given to the program, and a JSON
+object describing the result is written to stdout. The object has fields:
+
+  path:      the names of the selected commands, from the program name
+  flags:     a map of the flags set by the arguments to their values
+  args:      the positional arguments passed to the selected command
+  runnable:  whether the selected command would run (false means help)`,
+
+		CustomFlags: true,
+
+		Run: func(env *Env) error {
+			root := env
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			renv := root.newChild(root.Command.Clone(), nil)
+			renv.Parent = nil
+			ex, err := Explain(renv, env.Args)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(env.Stdout()).Encode(ex)
+		},
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplain(t *testing.T) {
+	var ran bool
+	newTree := func() *command.C {
+		return &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.Bool("v", false, "Verbose")
+			},
+			Init: func(*command.Env) error { ran = true; return nil },
+			Commands: []*command.C{{
+				Name: "get",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					var out string
+					command.VarP(fs, newStringValue(&out, ""), "output", "o", "Output file")
+					fs.Int("n", 1, "Count")
+				},
+				Run: func(*command.Env) error { ran = true; return nil },
+			}, {
+				Name: "topic",
+				Help: "A help topic.",
+			}},
+		}
+	}
+
+	tests := []struct {
+		args string
+		want command.Explanation
+	}{
+		{"", command.Explanation{
+			Path: []string{"tool"}, Flags: map[string]string{}, Args: []string{},
+		}},
+		{"-v get -o out.txt x y", command.Explanation{
+			Path:     []string{"tool", "get"},
+			Flags:    map[string]string{"v": "true", "output": "out.txt"},
+			Args:     []string{"x", "y"},
+			Runnable: true,
+		}},
+		{"get x --n 5", command.Explanation{
+			Path:     []string{"tool", "get"},
+			Flags:    map[string]string{"n": "5"},
+			Args:     []string{"x"},
+			Runnable: true,
+		}},
+	}
+	for _, tc := range tests {
+		ex, err := command.Explain(newTree().NewEnv(nil), strings.Fields(tc.args))
+		if err != nil {
+			t.Errorf("Explain %q: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, ex); diff != "" {
+			t.Errorf("Explain %q (-want, +got):\n%s", tc.args, diff)
+		}
+		if _, err := json.Marshal(ex); err != nil {
+			t.Errorf("Marshal: unexpected error: %v", err)
+		}
+	}
+	if ran {
+		t.Error("Explain ran a command")
+	}
+
+	var uerr command.UsageError
+	if _, err := command.Explain(newTree().NewEnv(nil), []string{"get", "--bogus"}); !errors.As(err, &uerr) {
+		t.Errorf("Explain: got %v, want usage error", err)
+	}
+
+	// Arguments the root does not understand are reported as Run does.
+	for _, args := range []string{"bogus", "topic"} {
+		env := newTree().NewEnv(nil)
+		env.Log = io.Discard
+		if _, err := command.Explain(env, strings.Fields(args)); !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Explain %q: got %v, want %v", args, err, command.ErrRequestHelp)
+		}
+	}
+
+	// The default subcommand is selected as Run does.
+	root := newTree()
+	root.Default = "get"
+	if ex, err := command.Explain(root.NewEnv(nil), nil); err != nil {
+		t.Errorf("Explain with default: unexpected error: %v", err)
+	} else if want := []string{"tool", "get"}; !ex.Runnable || !slices.Equal(ex.Path, want) {
+		t.Errorf("Explain with default: got path %q, runnable %v; want %q, true", ex.Path, ex.Runnable, want)
+	}
+
+	// A command not supported on this platform is reported as Run does.
+	root = newTree()
+	root.Commands[0].Platforms = []string{"nonesuch"}
+	if _, err := command.Explain(root.NewEnv(nil), []string{"get"}); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Explain unsupported: got %v, want %v", err, errors.ErrUnsupported)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	var ran bool
+	var zone string
+	newRoot := func() *command.C {
+		ran = false
+		return &command.C{
+			Name: "tool",
+			Init: func(*command.Env) error { ran = true; return nil },
+			Commands: []*command.C{{
+				Name: "deploy",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.StringVar(&zone, "zone", "", "Zone name")
+					command.MarkRequired(fs, "zone")
+				},
+				Run: func(*command.Env) error { ran = true; return nil },
+			}},
+		}
+	}
+
+	env, err := command.Resolve(newRoot().NewEnv(nil), []string{"deploy", "--zone", "west", "app"})
+	if err != nil {
+		t.Fatalf("Resolve: unexpected error: %v", err)
+	}
+	if ran {
+		t.Error("Resolve called Init or Run")
+	}
+	if env.Command.Name != "deploy" || env.Parent == nil || env.Parent.Command.Name != "tool" {
+		t.Errorf("Resolve: got command %q, want deploy", env.Command.Name)
+	}
+	if zone != "west" || len(env.Args) != 1 || env.Args[0] != "app" {
+		t.Errorf("Resolve: zone=%q args=%q, want west, [app]", zone, env.Args)
+	}
+
+	// The environment can be used to run the command.
+	if err := env.Command.Run(env); err != nil || !ran {
+		t.Errorf("Run: got (%v, ran=%v), want success", err, ran)
+	}
+
+	// Resolve checks flag constraints as Run does.
+	if _, err := command.Resolve(newRoot().NewEnv(nil), []string{"deploy"}); !command.IsUsage(err) {
+		t.Errorf("Resolve without --zone: got %v, want usage error", err)
+	}
+}
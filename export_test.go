@@ -0,0 +1,9 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+// Unexported functions exposed for testing.
+var (
+	ParsePseudoVersion = parsePseudoVersion
+	VersionInfoFrom    = versionInfo
+)
@@ -0,0 +1,33 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+// Extract returns a new root command for the subtree of root reached by the
+// given path of subcommand names, or nil if there is no such command. This
+// allows a program to ship small single-purpose binaries built from the same
+// tree as its main binary. For example:
+//
+//	migrate := command.Extract(root, "db", "migrate")
+//	command.RunOrFail(migrate.NewEnv(nil), os.Args[1:])
+//
+// The result is a clone of the subtree (see C.Clone), so the original tree is
+// not affected. Because the result is a root, usage and help text name it
+// directly instead of by its path from root, and help placeholders such as
+// {{.Path}} are expanded relative to it. If the subtree does not define its
+// own glossary, it uses the glossary of root. If root has a "help" command and
+// the result has subcommands but no "help" command, the result has a copy of
+// the help command of root.
+func Extract(root *C, path ...string) *C {
+	sub := findPath(root, path)
+	if sub == nil {
+		return nil
+	}
+	out := sub.Clone()
+	if out.Glossary == nil {
+		out.Glossary = root.Glossary
+	}
+	if help := root.FindSubcommand("help"); help != nil && len(out.Commands) != 0 && out.FindSubcommand("help") == nil {
+		out.Commands = append(out.Commands, help.Clone())
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestExtract(t *testing.T) {
+	var steps int
+	root := &command.C{
+		Name:     "tool",
+		Glossary: []command.Term{{Name: "schema", Definition: "The layout of a database."}},
+		Commands: []*command.C{{
+			Name: "db",
+			Commands: []*command.C{{
+				Name: "migrate",
+				Help: "Migrate the schema of {{.Path}}.",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.IntVar(&steps, "steps", 1, "Number of steps")
+				},
+				Commands: []*command.C{{
+					Name: "up",
+					Help: "Apply migrations.",
+					Run:  func(*command.Env) error { return nil },
+				}},
+				Run: func(*command.Env) error { return nil },
+			}},
+		}, command.HelpCommand(nil)},
+	}
+
+	if got := command.Extract(root, "db", "nonesuch"); got != nil {
+		t.Errorf("Extract nonexistent: got %q, want nil", got.Name)
+	}
+
+	mig := command.Extract(root, "db", "migrate")
+	if mig == nil {
+		t.Fatal("Extract: got nil")
+	}
+	if err := command.Run(mig.NewEnv(nil), []string{"--steps", "3"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if steps != 3 {
+		t.Errorf("Run: steps=%d, want 3", steps)
+	}
+
+	help := captureStdout(t, func() { command.Run(mig.NewEnv(nil), []string{"help"}) })
+	for _, want := range []string{
+		"  migrate [flags] <command>\n",
+		"Migrate the schema of migrate.",
+		"Terms:",
+		"  migrate up ",
+		"  migrate help ",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Help output is missing %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "tool") || strings.Contains(help, " db ") {
+		t.Errorf("Help output mentions the original tree:\n%s", help)
+	}
+
+	// The original tree is not affected.
+	if sub := root.Commands[0].Commands[0]; sub.FindSubcommand("help") != nil {
+		t.Error("Extract modified the original tree")
+	}
+}
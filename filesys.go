@@ -0,0 +1,232 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FS returns the file system of e. This is the OS file system unless another
+// is set by SetFS. Commands that read and write files through FS, rather than
+// the os package directly, can be tested with an in-memory file system, and
+// embedded in programs that restrict them to a subtree (see DirFS).
+//
+// The OS file system accepts any path the os package accepts, including
+// absolute paths and paths relative to the working directory. It implements
+// [WritableFS]. Use [WriteFile], [MkdirAll], [Remove], and [Rename] to modify
+// the file system of e, and the functions of the [io/fs] package to read it.
+//
+// The package keeps the files it manages for the program, such as the state
+// files in Env.StateDir and the configuration file loaded by [UseConfigFile],
+// in this file system. Another file system is treated as the root of the OS
+// file system, as by chroot: An absolute path such as /home/user/.local/state
+// names home/user/.local/state in it, and a relative path is relative to its
+// root. On Windows, the volume name of a path is ignored.
+func (e *Env) FS() fs.FS {
+	if e.fsys == nil {
+		return dirFS("")
+	}
+	return e.fsys
+}
+
+// fsName returns the name in the file system of e of the file at osPath in
+// the OS file system (see FS). The OS file system accepts osPath as it is.
+func (e *Env) fsName(osPath string) string {
+	if d, ok := e.FS().(dirFS); ok && d == "" {
+		return osPath
+	}
+	name := filepath.ToSlash(strings.TrimPrefix(osPath, filepath.VolumeName(osPath)))
+	return path.Clean(strings.TrimLeft(name, "/"))
+}
+
+// readFile reads the file at path in the file system of e (see fsName).
+func (e *Env) readFile(path string) ([]byte, error) {
+	return fs.ReadFile(e.FS(), e.fsName(path))
+}
+
+// writeFileAtomic writes data to the file at path in the file system of e
+// (see fsName), creating its directory if necessary. It writes to a temporary
+// file and renames it, so that a concurrent reader does not see a partial
+// update.
+func (e *Env) writeFileAtomic(path string, data []byte) error {
+	fsys, name := e.FS(), e.fsName(path)
+	if err := MkdirAll(fsys, e.fsName(filepath.Dir(path)), 0700); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%016x.tmp", name, rand.Uint64())
+	err := WriteFile(fsys, tmp, data, 0600)
+	if err == nil {
+		err = Rename(fsys, tmp, name)
+	}
+	if err != nil {
+		Remove(fsys, tmp)
+	}
+	return err
+}
+
+// SetFS sets the file system of e to fsys, and returns e. If fsys == nil, it
+// restores the default, the OS file system. The setting is inherited by the
+// descendants of e.
+func (e *Env) SetFS(fsys fs.FS) *Env { e.fsys = fsys; return e }
+
+// WritableFS is a file system that can be modified (see Env.FS).
+type WritableFS interface {
+	fs.FS
+
+	// WriteFile writes data to the named file, creating it with permissions
+	// perm if necessary, and truncating it otherwise.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+
+	// MkdirAll creates the named directory and any missing parents.
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+
+	// Rename renames (moves) oldname to newname. If newname already exists
+	// and is not a directory, Rename replaces it.
+	Rename(oldname, newname string) error
+}
+
+// WriteFile writes data to the named file of fsys (see WritableFS). It
+// reports an error wrapping [errors.ErrUnsupported] if fsys is read-only.
+func WriteFile(fsys fs.FS, name string, data []byte, perm fs.FileMode) error {
+	w, err := writable(fsys, "write", name)
+	if err != nil {
+		return err
+	}
+	return w.WriteFile(name, data, perm)
+}
+
+// MkdirAll creates the named directory of fsys and any missing parents (see
+// WritableFS). It reports an error wrapping [errors.ErrUnsupported] if fsys
+// is read-only.
+func MkdirAll(fsys fs.FS, name string, perm fs.FileMode) error {
+	w, err := writable(fsys, "mkdir", name)
+	if err != nil {
+		return err
+	}
+	return w.MkdirAll(name, perm)
+}
+
+// Remove removes the named file or empty directory of fsys (see WritableFS).
+// It reports an error wrapping [errors.ErrUnsupported] if fsys is read-only.
+func Remove(fsys fs.FS, name string) error {
+	w, err := writable(fsys, "remove", name)
+	if err != nil {
+		return err
+	}
+	return w.Remove(name)
+}
+
+// Rename renames the file oldname of fsys to newname (see WritableFS). It
+// reports an error wrapping [errors.ErrUnsupported] if fsys is read-only.
+func Rename(fsys fs.FS, oldname, newname string) error {
+	w, err := writable(fsys, "rename", oldname)
+	if err != nil {
+		return err
+	}
+	return w.Rename(oldname, newname)
+}
+
+func writable(fsys fs.FS, op, name string) (WritableFS, error) {
+	if w, ok := fsys.(WritableFS); ok {
+		return w, nil
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: errors.ErrUnsupported}
+}
+
+// DirFS returns a [WritableFS] for the tree of the OS file system rooted at
+// dir. Unlike the default file system of an Env, names must be valid paths
+// as defined by [fs.ValidPath], so that a command using it cannot reach files
+// outside dir by name. Symbolic links within dir are followed, however, so
+// this is a convenience rather than a security boundary.
+func DirFS(dir string) WritableFS { return dirFS(dir) }
+
+// dirFS implements WritableFS for the OS file system. If it is empty, names
+// are OS paths; otherwise names are valid paths relative to the named root.
+type dirFS string
+
+func (d dirFS) path(op, name string) (string, error) {
+	if d == "" {
+		return name, nil
+	} else if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(d), filepath.FromSlash(name)), nil
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	p, err := d.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	p, err := d.path("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := d.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(p)
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := d.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p, err := d.path("write", name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, perm)
+}
+
+func (d dirFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := d.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+func (d dirFS) Remove(name string) error {
+	p, err := d.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func (d dirFS) Rename(oldname, newname string) error {
+	op, err := d.path("rename", oldname)
+	if err != nil {
+		return err
+	}
+	np, err := d.path("rename", newname)
+	if err != nil {
+		return err
+	}
+	return os.Rename(op, np)
+}
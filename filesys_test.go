@@ -0,0 +1,90 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/creachadair/command"
+)
+
+func TestFS(t *testing.T) {
+	dir := t.TempDir()
+	var got string
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "copy",
+			Run: func(env *command.Env) error {
+				data, err := fs.ReadFile(env.FS(), env.Args[0])
+				if err != nil {
+					return err
+				}
+				got = string(data)
+				if err := command.MkdirAll(env.FS(), "out", 0700); err != nil {
+					return err
+				}
+				return command.WriteFile(env.FS(), "out/"+env.Args[0], data, 0600)
+			},
+		}},
+	}
+
+	// A file system rooted at a directory.
+	if err := os.WriteFile(filepath.Join(dir, "input"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := command.Run(root.NewEnv(nil).SetFS(command.DirFS(dir)), []string{"copy", "input"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("Read: got %q, want hello", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "out", "input")); err != nil || string(data) != "hello" {
+		t.Errorf("Output: got %q, %v; want hello", data, err)
+	}
+
+	// Names outside the root are rejected.
+	if err := command.Run(root.NewEnv(nil).SetFS(command.DirFS(dir)), []string{"copy", "../input"}); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Run: got %v, want %v", err, fs.ErrInvalid)
+	}
+
+	// A read-only file system cannot be written.
+	ro := fstest.MapFS{"input": {Data: []byte("world")}}
+	if err := command.Run(root.NewEnv(nil).SetFS(ro), []string{"copy", "input"}); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Run: got %v, want %v", err, errors.ErrUnsupported)
+	}
+	if got != "world" {
+		t.Errorf("Read: got %q, want world", got)
+	}
+
+	// The default is the OS file system, which accepts OS paths.
+	env := root.NewEnv(nil)
+	path := filepath.Join(dir, "abs")
+	if err := command.WriteFile(env.FS(), path, []byte("ok"), 0600); err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+	if data, err := fs.ReadFile(env.FS(), path); err != nil || string(data) != "ok" {
+		t.Errorf("ReadFile: got %q, %v; want ok", data, err)
+	}
+	if err := command.Remove(env.FS(), path); err != nil {
+		t.Errorf("Remove: unexpected error: %v", err)
+	}
+
+	// Another file system holds the state files of the program, as if it were
+	// the root of the OS file system.
+	state := filepath.Join(string(filepath.Separator), "home", "user", ".local", "state")
+	env = root.NewEnv(nil).SetProgramName("tool").SetFS(command.DirFS(dir)).SetEnviron(map[string]string{"XDG_STATE_HOME": state})
+	if err := env.SetFirstRunDone(); err != nil {
+		t.Fatalf("SetFirstRunDone: unexpected error: %v", err)
+	} else if env.IsFirstRun() {
+		t.Error("IsFirstRun: got true after SetFirstRunDone")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "home", "user", ".local", "state", "tool")); err != nil {
+		t.Errorf("State directory: %v", err)
+	}
+}
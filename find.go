@@ -0,0 +1,151 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FindCommand constructs a standardized "find" command that searches the
+// command tree for commands matching a query. The caller is free to edit the
+// resulting command, each call returns a separate value.
+//
+// Each runnable command is matched against the words of the query by its path
+// from the root (for example "remote add") and the synopsis of its help text.
+// A word matches if it occurs in either, or if its letters occur in order in
+// the path ("rmad" matches "remote add"). Matches are printed to stdout, best
+// first.
+//
+// If a [Chooser] is set on the environment (see SetChooser), the user is
+// prompted to choose one of the matching commands, and the chosen command is
+// run with no arguments.
+func FindCommand() *C {
+	return &C{
+		Name:  "find",
+		Usage: "<query>...",
+		Help: `Search for commands matching a query.
+
+Commands are matched by their names and the summaries of their help text.
+A query word matches if it occurs in either, or if its letters occur in order
+in the name of the command.
+Matching commands are listed with the best matches first.`,
+
+		Run: func(env *Env) error {
+			if len(env.Args) == 0 {
+				return env.Usagef("a search query is required")
+			}
+			root := env
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			matches := findCommands(root.Command, env.Args, env.hflag)
+			if len(matches) == 0 {
+				return fmt.Errorf("no commands match %q", strings.Join(env.Args, " "))
+			}
+
+			if env.chooser != nil {
+				name, err := env.chooser.Choose(env, matches)
+				if err == nil {
+					target := walkArgs(root.HelpFlags(env.hflag), strings.Fields(name))
+					if target == nil {
+						return fmt.Errorf("unknown command %q", name)
+					}
+					return run(target, nil)
+				} else if !errors.Is(err, ErrNotInteractive) {
+					return err
+				}
+			}
+			// Name the matches as the user invokes them (see displayPath).
+			var prefix string
+			if !env.multi {
+				prefix = env.rootName() + " "
+			}
+			writeTopics(env.Stdout(), env.displayWidth, prefix, "Matching commands:", matches)
+			return nil
+		},
+	}
+}
+
+// findCommands returns help for the runnable descendants of root that match
+// all the words of query, in decreasing order of match quality. The Name of
+// each result is the path of the command relative to root.
+func findCommands(root *C, query []string, flags HelpFlags) []HelpInfo {
+	type match struct {
+		info  HelpInfo
+		score int
+	}
+	var out []match
+	var walk func(c *C, path string)
+	walk = func(c *C, path string) {
+		for _, sub := range c.Commands {
+			if !sub.Supported() || (sub.Unlisted && !flags.wantUnlisted()) {
+				continue
+			}
+			name := joinSpace(path, sub.Name)
+			if sub.Runnable() {
+				senv := sub.NewEnv(nil)
+				syn := strings.SplitN(strings.TrimSpace(senv.expandHelp(sub.helpText(senv))), "\n", 2)[0]
+				if score, ok := matchQuery(query, name, syn); ok {
+					out = append(out, match{HelpInfo{Name: name, Synopsis: syn}, score})
+				}
+			}
+			walk(sub, name)
+		}
+	}
+	walk(root, "")
+
+	slices.SortStableFunc(out, func(a, b match) int { return cmp.Compare(b.score, a.score) })
+	infos := make([]HelpInfo, len(out))
+	for i, m := range out {
+		infos[i] = m.info
+	}
+	return infos
+}
+
+// matchQuery reports whether each word of query matches the name or synopsis
+// of a command, and if so returns a score for the match. Higher scores are
+// better, and matches on the name are preferred to matches on the synopsis.
+// Only the name is matched by scattered letters.
+func matchQuery(query []string, name, synopsis string) (int, bool) {
+	name, synopsis = strings.ToLower(name), strings.ToLower(synopsis)
+	var total int
+	for _, word := range query {
+		word = strings.ToLower(word)
+		best := fuzzyScore(word, name)
+		if strings.Contains(synopsis, word) {
+			best = max(best, fuzzyScore(word, synopsis)/2)
+		}
+		if best <= 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fuzzyScore returns a positive score if word matches text, or 0 if it does
+// not. A substring match scores higher than a match of scattered letters, and
+// earlier and tighter matches score higher than later and looser ones.
+func fuzzyScore(word, text string) int {
+	const base = 100
+	if word == "" {
+		return 0
+	}
+	if i := strings.Index(text, word); i >= 0 {
+		return 2*base - min(i, base-1)
+	}
+	gaps, pos := 0, 0
+	for _, r := range word {
+		i := strings.IndexRune(text[pos:], r)
+		if i < 0 {
+			return 0
+		}
+		gaps += i
+		pos += i + len(string(r))
+	}
+	return max(base-gaps, 1)
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestFindCommand(t *testing.T) {
+	var ran string
+	runAs := func(name string) func(*command.Env) error {
+		return func(*command.Env) error { ran = name; return nil }
+	}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{
+				Name: "remote",
+				Help: "Manage remote repositories.",
+				Commands: []*command.C{
+					{Name: "add", Help: "Add a new remote.", Run: runAs("remote add")},
+					{Name: "remove", Help: "Remove a remote.", Run: runAs("remote remove")},
+				},
+			},
+			{Name: "status", Help: "Show the working tree status.", Run: runAs("status")},
+			{Name: "secret", Unlisted: true, Help: "Remote control.", Run: runAs("secret")},
+			command.FindCommand(),
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  []string // matching paths, in order
+	}{
+		{"remote", []string{"remote add", "remote remove"}},
+		{"rmad", []string{"remote add"}},
+		{"remove", []string{"remote remove"}},
+		{"working", []string{"status"}},
+		{"REM new", []string{"remote add"}},
+		{"stat", []string{"status"}},
+		{"search", []string{"find"}},
+	}
+	for _, tc := range tests {
+		ran = ""
+		var out strings.Builder
+		env := root.NewEnv(nil).SetChooser(command.NumberedChooser{
+			In: strings.NewReader("1\n"), Out: &out,
+		})
+		if err := command.Run(env, append([]string{"find"}, strings.Fields(tc.query)...)); err != nil && tc.want[0] != "find" {
+			t.Errorf("Find %q: unexpected error: %v", tc.query, err)
+		}
+
+		var got []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if _, rest, ok := strings.Cut(line, ". "); ok {
+				got = append(got, strings.SplitN(rest, " - ", 2)[0])
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("Find %q: got %q, want %q", tc.query, got, tc.want)
+		}
+		if tc.want[0] != "find" && ran != tc.want[0] {
+			t.Errorf("Find %q: ran %q, want %q", tc.query, ran, tc.want[0])
+		}
+	}
+
+	t.Run("ProgramName", func(t *testing.T) {
+		var out strings.Builder
+		env := root.NewEnv(nil).SetProgramName("renamed").SetStdout(&out)
+		if err := command.Run(env, []string{"find", "remote"}); err != nil {
+			t.Fatalf("Find: unexpected error: %v", err)
+		}
+		if got := out.String(); !strings.Contains(got, "renamed remote add") || strings.Contains(got, "tool ") {
+			t.Errorf("Find: output does not use the program name:\n%s", got)
+		}
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		err := command.Run(root.NewEnv(nil), []string{"find", "xyzzy"})
+		if err == nil || !strings.Contains(err.Error(), "no commands match") {
+			t.Errorf("Find: got %v, want no match", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
+// firstRunMarker is the name of the file in the state directory that records
+// that first-run setup has completed.
+const firstRunMarker = "setup-done"
+
+// FirstRunOptions are settings for [UseFirstRun]. A nil *FirstRunOptions is
+// ready for use and provides default values.
+type FirstRunOptions struct {
+	// If set, this reports whether the program can interact with the user.
+	// If nil, the program is interactive if the channel of its invocation is
+	// interactive (see Env.Channel).
+	Interactive func() bool
+}
+
+func (o *FirstRunOptions) interactive(env *Env) bool {
+	if o == nil || o.Interactive == nil {
+		return env.Channel().Interactive()
+	}
+	return o.Interactive()
+}
+
+// UseFirstRun arranges for setup to be run the first time root is run, and
+// returns root. It adds a --no-interactive flag to root, and wraps its Startup
+// hook. Any existing SetFlags and Startup functions of root are preserved.
+//
+// Setup is meant to be an interactive command that prepares the program for
+// use, for example by asking for consent, creating a default profile, or
+// offering to enable telemetry. When root is started, if first-run setup has
+// not been done (see Env.IsFirstRun), setup is run with no arguments before
+// the Startup hook of root. If setup fails, its error is reported and the
+// command is not run. When setup succeeds, it is recorded as done.
+//
+// Setup is skipped without being recorded as done if the --no-interactive
+// flag is set, or if the program is not interactive (see FirstRunOptions),
+// so that scripts and CI jobs are not blocked waiting for input. It is also
+// skipped if setup is a subcommand of root and the user is running it.
+// Setup may be listed as a subcommand so that users can run it again.
+func UseFirstRun(root, setup *C, opts *FirstRunOptions) *C {
+	run := setup.Run
+	setup.Run = func(env *Env) error {
+		if run != nil {
+			if err := run(env); err != nil {
+				return err
+			}
+		}
+		return env.SetFirstRunDone()
+	}
+
+	var noInteractive bool
+	setFlags := root.SetFlags
+	root.SetFlags = func(env *Env, fs *flag.FlagSet) {
+		if setFlags != nil {
+			setFlags(env, fs)
+		}
+		fs.BoolVar(&noInteractive, "no-interactive", false, "Do not prompt for input")
+	}
+	startup := root.Startup
+	root.Startup = func(env *Env) error {
+		if !noInteractive && !env.namesCommand(setup) && env.IsFirstRun() && opts.interactive(env) {
+			if err := env.runSetup(setup); err != nil {
+				return fmt.Errorf("first-run setup: %w", err)
+			}
+		}
+		if startup != nil {
+			return startup(env)
+		}
+		return nil
+	}
+	return root
+}
+
+// StateDir returns the state directory for the program, the directory named
+// by Env.ProgramName in the user state directory. On Unix-like systems, the
+// user state directory is $XDG_STATE_HOME if set, otherwise ~/.local/state.
+// On other systems, it is the user configuration directory (see
+// [os.UserConfigDir]), or on Windows, the local application data directory.
+func (e *Env) StateDir() string {
+	dir := e.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		switch runtime.GOOS {
+		case "windows":
+			dir, _ = os.UserCacheDir() // %LocalAppData%
+		case "darwin", "ios", "plan9":
+			dir, _ = os.UserConfigDir()
+		default:
+			if home, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(home, ".local", "state")
+			}
+		}
+	}
+	return filepath.Join(dir, e.ProgramName()) // if unavailable, use a relative path
+}
+
+// IsFirstRun reports whether first-run setup has not yet been done for the
+// program, meaning that there is no marker file for it in the state directory
+// of the program (see Env.StateDir), in the file system of e (see Env.FS).
+func (e *Env) IsFirstRun() bool {
+	_, err := fs.Stat(e.FS(), e.fsName(filepath.Join(e.StateDir(), firstRunMarker)))
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// SetFirstRunDone records that first-run setup has been done for the program,
+// by creating a marker file in the state directory of the program (see
+// Env.StateDir), which is created if necessary.
+func (e *Env) SetFirstRunDone() error {
+	dir := e.StateDir()
+	if err := MkdirAll(e.FS(), e.fsName(dir), 0700); err != nil {
+		return err
+	}
+	return WriteFile(e.FS(), e.fsName(filepath.Join(dir, firstRunMarker)), nil, 0600)
+}
+
+// namesCommand reports whether the arguments of e name cmd as a subcommand of
+// the command of e.
+func (e *Env) namesCommand(cmd *C) bool {
+	return len(e.Args) != 0 && e.Args[0] == cmd.Name && slices.Contains(e.Command.Commands, cmd)
+}
+
+// runSetup runs cmd with no arguments as a child of e. The child has its own
+// context, so that its completion does not cancel the context of e.
+func (e *Env) runSetup(cmd *C) error {
+	child := e.newChild(cmd, nil)
+	child.SetContext(e.Context())
+	return run(child, nil)
+}
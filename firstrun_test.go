@@ -0,0 +1,75 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestFirstRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var setups, runs int
+	var setupErr error
+	newRoot := func(interactive bool) *command.C {
+		// Startup is called at most once per process for each root, so each
+		// case needs a fresh tree.
+		setup := &command.C{
+			Name: "setup",
+			Run:  func(*command.Env) error { setups++; return setupErr },
+		}
+		root := &command.C{
+			Name:     "tool",
+			Commands: []*command.C{setup},
+			Run:      func(*command.Env) error { runs++; return nil },
+		}
+		return command.UseFirstRun(root, setup, &command.FirstRunOptions{
+			Interactive: func() bool { return interactive },
+		})
+	}
+	check := func(t *testing.T, root *command.C, args []string, wantSetups, wantRuns int) {
+		t.Helper()
+		setups, runs = 0, 0
+		if err := command.Run(root.NewEnv(nil).SetProgramName("tool"), args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+		if setups != wantSetups || runs != wantRuns {
+			t.Errorf("Run %q: got %d setups, %d runs; want %d, %d", args, setups, runs, wantSetups, wantRuns)
+		}
+	}
+	isFirst := func() bool { return newRoot(false).NewEnv(nil).SetProgramName("tool").IsFirstRun() }
+
+	if !isFirst() {
+		t.Fatal("IsFirstRun is false before setup")
+	}
+
+	// Setup is skipped if the program is not interactive, or if requested.
+	check(t, newRoot(false), nil, 0, 1)
+	check(t, newRoot(true), []string{"--no-interactive"}, 0, 1)
+	if !isFirst() {
+		t.Error("IsFirstRun is false after skipping setup")
+	}
+
+	// A failed setup stops the command and is not recorded.
+	setupErr = errors.New("declined")
+	if err := command.Run(newRoot(true).NewEnv(nil).SetProgramName("tool"), nil); err == nil || !strings.Contains(err.Error(), "declined") {
+		t.Errorf("Run: got error %v, want %v", err, setupErr)
+	}
+	if !isFirst() {
+		t.Error("IsFirstRun is false after failed setup")
+	}
+	setupErr = nil
+
+	// Running setup explicitly does not run it twice.
+	check(t, newRoot(true), []string{"setup"}, 1, 0)
+	if isFirst() {
+		t.Fatal("IsFirstRun is true after setup")
+	}
+
+	// Once setup is done, it is not run again.
+	check(t, newRoot(true), nil, 0, 1)
+}
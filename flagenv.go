@@ -0,0 +1,77 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// flagEnvName returns the name of the environment variable from which the
+// named flag of the command of e is read, or "" if the command and its
+// ancestors do not set a FlagEnvPrefix.
+func (e *Env) flagEnvName(name string) string {
+	var cmds []string // command names, innermost first
+	for cur := e; cur != nil; cur = cur.Parent {
+		if p := cur.Command.FlagEnvPrefix; p != "" {
+			slices.Reverse(cmds)
+			words := append(append([]string{p}, cmds...), name)
+			return strings.Map(envNameRune, strings.Join(words, "_"))
+		}
+		cmds = append(cmds, cur.Command.Name)
+	}
+	return ""
+}
+
+// envNameRune maps r to its replacement in an environment variable name:
+// Letters are converted to upper case, digits are kept, and everything else
+// is replaced by an underscore.
+func envNameRune(r rune) rune {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return r - 'a' + 'A'
+	case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return r
+	default:
+		return '_'
+	}
+}
+
+// hasEnvValue reports whether the environment gives a value for the named
+// flag of the command of e (see C.FlagEnvPrefix).
+func (e *Env) hasEnvValue(name string) bool {
+	if v := e.flagEnvName(name); v != "" {
+		_, ok := e.LookupEnv(v)
+		return ok
+	}
+	return false
+}
+
+// applyFlagEnv sets each flag of the command of e not set on the command line
+// from its environment variable, if it is set (see C.FlagEnvPrefix).
+func (e *Env) applyFlagEnv() error {
+	c := e.Command
+	if c.CustomFlags {
+		return nil
+	}
+	set := explicitFlags(&c.Flags)
+	var errs []error
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if info := getFlagInfo(f); set[f.Name] || info.aliasOf != "" || info.inherited {
+			return
+		}
+		name := e.flagEnvName(f.Name)
+		if name == "" {
+			return
+		}
+		if v, ok := e.LookupEnv(name); ok {
+			if err := f.Value.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("$%s: invalid value for flag %q: %w", name, f.Name, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
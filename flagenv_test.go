@@ -0,0 +1,97 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestFlagEnvPrefix(t *testing.T) {
+	var verbose, dryRun bool
+	var count int
+	var zone string
+	newRoot := func() *command.C {
+		verbose, dryRun, count, zone = false, false, 0, ""
+		return &command.C{
+			Name:          "mytool",
+			FlagEnvPrefix: "MYTOOL",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.BoolVar(&verbose, "verbose", false, "Verbose output")
+				command.Alias(fs, "verbose", "v")
+			},
+			Commands: []*command.C{{
+				Name: "remote",
+				Commands: []*command.C{{
+					Name: "add",
+					SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+						fs.BoolVar(&dryRun, "dry-run", false, "Do not make changes")
+						fs.IntVar(&count, "count", 1, "Count")
+					},
+					Run: func(*command.Env) error { return nil },
+				}},
+			}, {
+				Name:          "zone",
+				FlagEnvPrefix: "ZONE",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.StringVar(&zone, "name", "", "Zone name")
+					command.MarkRequired(fs, "name")
+				},
+				Run: func(*command.Env) error { return nil },
+			}, command.HelpCommand(nil)},
+		}
+	}
+	t.Setenv("MYTOOL_VERBOSE", "true")
+	t.Setenv("MYTOOL_REMOTE_ADD_DRY_RUN", "1")
+	t.Setenv("MYTOOL_REMOTE_ADD_COUNT", "5")
+	t.Setenv("ZONE_NAME", "west")
+
+	run := func(args ...string) error {
+		t.Helper()
+		env := newRoot().NewEnv(nil)
+		env.Log = io.Discard
+		return command.Run(env, args)
+	}
+
+	if err := run("remote", "add"); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if !verbose || !dryRun || count != 5 {
+		t.Errorf("From environment: verbose=%v dry-run=%v count=%d, want true, true, 5", verbose, dryRun, count)
+	}
+
+	// Flags on the command line take precedence, including aliases.
+	if err := run("-v=false", "remote", "add", "--count", "3"); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if verbose || !dryRun || count != 3 {
+		t.Errorf("With flags: verbose=%v dry-run=%v count=%d, want false, true, 3", verbose, dryRun, count)
+	}
+
+	// A descendant may use its own prefix, and the environment satisfies
+	// required flags.
+	if err := run("zone"); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if zone != "west" {
+		t.Errorf("Zone name is %q, want west", zone)
+	}
+
+	t.Setenv("MYTOOL_REMOTE_ADD_COUNT", "many")
+	if err := run("remote", "add"); err == nil || !strings.Contains(err.Error(), "MYTOOL_REMOTE_ADD_COUNT") {
+		t.Errorf("Run with invalid value: got %v, want error naming the variable", err)
+	}
+
+	help := captureStdout(t, func() { run("help", "remote", "add") })
+	for _, want := range []string{
+		"Do not make changes (env MYTOOL_REMOTE_ADD_DRY_RUN)\n",
+		"Count (default 1) (env MYTOOL_REMOTE_ADD_COUNT)\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Help output is missing %q:\n%s", want, help)
+		}
+	}
+}
@@ -0,0 +1,616 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A FlagSnapshot records the values of the flags in a command tree, so that
+// they can later be restored. Use the SnapshotFlags method of an [Env] to
+// construct a snapshot.
+type FlagSnapshot struct {
+	values map[*C]map[string]any // command → flag name → value
+}
+
+// SnapshotFlags records the current values of the flags of e.Command and all
+// its descendants. Before recording, it invokes the SetFlags hook of each
+// command whose flags have not yet been set, so that the snapshot covers all
+// the flags the tree defines.
+//
+// A typical use is to take a snapshot when a tree is set up, and to restore it
+// between dispatches to the same tree, for example in a read-eval-print loop
+// or a test harness:
+//
+//	snap := env.SnapshotFlags()
+//	for ... {
+//	   err := command.Run(env, args)
+//	   // ...
+//	   env.RestoreFlags(snap)
+//	}
+func (e *Env) SnapshotFlags() FlagSnapshot {
+	snap := FlagSnapshot{values: make(map[*C]map[string]any)}
+	var walk func(*Env)
+	walk = func(env *Env) {
+		cmd := env.Command
+		cmd.setFlags(env, &cmd.Flags)
+		vals := make(map[string]any)
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			if r, ok := unwrapValue(f.Value).(rawFlagValue); ok {
+				vals[f.Name] = r.rawValue()
+			} else {
+				vals[f.Name] = f.Value.String()
+			}
+		})
+		snap.values[cmd] = vals
+		for _, sub := range cmd.Commands {
+			walk(env.newChild(sub, nil))
+		}
+	}
+	walk(e)
+	return snap
+}
+
+// RestoreFlags restores the flag values recorded by s for e.Command and its
+// descendants, and resets the parse state of their flag sets so that no flags
+// are considered to have been set. Flags defined after s was recorded are not
+// modified.
+//
+// Values are restored by calling the Set method of each flag with the string
+// representation recorded in the snapshot. Flag types whose Set method does
+// not replace the previous value, such as those that accumulate repeated
+// values, must account for this. The repeatable flags of this package (see
+// StringsVar) are restored exactly.
+func (e *Env) RestoreFlags(s FlagSnapshot) error {
+	var errs []error
+	var walk func(*C)
+	walk = func(cmd *C) {
+		if vals, ok := s.values[cmd]; ok {
+			cmd.Flags.VisitAll(func(f *flag.Flag) {
+				if v, ok := vals[f.Name]; ok {
+					if r, ok := unwrapValue(f.Value).(rawFlagValue); ok {
+						r.setRaw(v)
+					} else if err := f.Value.Set(v.(string)); err != nil {
+						errs = append(errs, fmt.Errorf("restore %s flag %q: %w", cmd.Name, f.Name, err))
+					}
+				}
+			})
+			cmd.resetFlags()
+		}
+		for _, sub := range cmd.Commands {
+			walk(sub)
+		}
+	}
+	walk(e.Command)
+	return errors.Join(errs...)
+}
+
+// rawFlagValue is implemented by flag values that cannot be restored from the
+// string reported by their String method, such as secrets and repeatable
+// flags, to save and restore them exactly. The argument to setRaw is a value
+// returned by rawValue.
+type rawFlagValue interface {
+	rawValue() any
+	setRaw(any)
+}
+
+// resetFlags replaces the flag set of c with a new unparsed flag set having
+// the same flag definitions.
+func (c *C) resetFlags() { c.Flags = copyFlagSet(&c.Flags, true) }
+
+// copyFlagSet returns a new unparsed flag set with the same name and error
+// handling as fs. If withFlags is true, the result also has the same flag
+// definitions as fs, bound to the same values.
+func copyFlagSet(fs *flag.FlagSet, withFlags bool) flag.FlagSet {
+	var out flag.FlagSet
+	out.Init(fs.Name(), fs.ErrorHandling())
+	if withFlags {
+		fs.VisitAll(func(f *flag.Flag) {
+			out.Var(f.Value, f.Name, f.Usage)
+			out.Lookup(f.Name).DefValue = f.DefValue
+		})
+	}
+	return out
+}
+
+// FlagsInOrder returns a function suitable for the FlagOrder field of a [C],
+// that lists the named flags first in the order given, followed by any other
+// flags in lexicographic order.  This may be used, for example, to list flags
+// in the order they are declared.
+func FlagsInOrder(names ...string) func(a, b *flag.Flag) int {
+	rank := func(f *flag.Flag) int {
+		if i := slices.Index(names, f.Name); i >= 0 {
+			return i
+		}
+		return len(names)
+	}
+	return func(a, b *flag.Flag) int { return cmp.Compare(rank(a), rank(b)) }
+}
+
+// flagInfo records metadata about a flag beyond what the flag package tracks,
+// for use in parsing and help rendering.
+type flagInfo struct {
+	aliasOf string   // if non-empty, this flag is an alias of the named flag
+	aliases []string // the names of aliases of this flag
+
+	defText    string // replacement text for the default value
+	hasDefText bool   // whether defText is set
+
+	required bool           // whether the flag must be set (see MarkRequired)
+	requires []string       // flags that must be set if this one is (see Requires)
+	validate []func() error // checks on the value of the flag (see ValidateFlag)
+
+	deprecated bool   // whether the flag is deprecated (see Deprecate)
+	depHint    string // a hint for users of a deprecated flag
+
+	hidden bool   // whether the flag is omitted from help (see HideFlags)
+	group  string // the heading the flag is listed under (see FlagGroup)
+
+	persistent bool // whether subcommands inherit the flag (see MarkPersistent)
+	inherited  bool // whether the flag is inherited from an ancestor
+
+	complete Completer // completes values of the flag (see CompleteFlag)
+}
+
+// metaValue wraps a [flag.Value] with additional metadata.
+// It forwards the optional methods of the flag package to the wrapped value.
+type metaValue struct {
+	flag.Value
+	info *flagInfo
+}
+
+// IsBoolFlag forwards to the wrapped value, for the flag package.
+func (m *metaValue) IsBoolFlag() bool {
+	v, ok := m.Value.(interface{ IsBoolFlag() bool })
+	return ok && v.IsBoolFlag()
+}
+
+// Get forwards to the wrapped value, to implement [flag.Getter].
+func (m *metaValue) Get() any {
+	if g, ok := m.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return nil
+}
+
+// unwrapValue returns the value wrapped by v, or v itself if it is not a
+// metadata wrapper.
+func unwrapValue(v flag.Value) flag.Value {
+	if m, ok := v.(*metaValue); ok {
+		return m.Value
+	}
+	return v
+}
+
+// getFlagInfo returns the metadata for f, or a zero value if f has none.
+func getFlagInfo(f *flag.Flag) *flagInfo {
+	if m, ok := f.Value.(*metaValue); ok {
+		return m.info
+	}
+	return new(flagInfo)
+}
+
+// annotate returns the metadata for the flag with the given name in fs,
+// attaching a new empty metadata record if it has none.  It panics if fs does
+// not define the flag, as this indicates a programming error.
+func annotate(fs *flag.FlagSet, name string) *flagInfo {
+	f := fs.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("flag %q is not defined", name))
+	}
+	m, ok := f.Value.(*metaValue)
+	if !ok {
+		m = &metaValue{Value: f.Value, info: new(flagInfo)}
+		f.Value = m
+	}
+	return m.info
+}
+
+// VarP defines a flag on fs with the specified long name and a single-letter
+// short name. Both names are bound to value, and the help text lists them
+// together as a single entry, for example:
+//
+//	-o, --output string
+//	    Write output to this file
+//
+// VarP panics if short is not a single letter, or if either name is already
+// defined in fs.
+func VarP(fs *flag.FlagSet, value flag.Value, name, short, usage string) {
+	if len(short) != 1 {
+		panic(fmt.Sprintf("short flag name %q is not a single letter", short))
+	}
+	fs.Var(value, name, usage)
+	Alias(fs, name, short)
+}
+
+// Alias defines each of aliases as another name for the flag with the given
+// name in fs. The aliases share the value, usage, and default of the original
+// flag, and the help text lists all the names together as a single entry. For
+// example:
+//
+//	fs.BoolVar(&verbose, "verbose", false, "Enable verbose logging")
+//	command.Alias(fs, "verbose", "v")
+//
+// renders as:
+//
+//	-v, --verbose
+//	    Enable verbose logging
+//
+// Alias panics if fs does not define the named flag, if the named flag is
+// itself an alias, or if any of the aliases is already defined in fs.
+func Alias(fs *flag.FlagSet, name string, aliases ...string) {
+	info := annotate(fs, name)
+	if info.aliasOf != "" {
+		panic(fmt.Sprintf("flag %q is an alias of %q", name, info.aliasOf))
+	}
+	f := fs.Lookup(name)
+	for _, alias := range aliases {
+		fs.Var(&metaValue{Value: unwrapValue(f.Value), info: &flagInfo{aliasOf: name}}, alias, f.Usage)
+		fs.Lookup(alias).DefValue = f.DefValue
+		info.aliases = append(info.aliases, alias)
+	}
+}
+
+// CountVar defines a counting flag on fs with the specified name and usage.
+// Each occurrence of the flag without a value increments *p, so that, for
+// example, "-v -v -v" sets *p to 3, as does "-vvv" if short flag clusters are
+// enabled (see Env.PflagStyle). A value may also be given explicitly, as in
+// "-v=2", which sets *p to that value. For example:
+//
+//	var verbose int
+//	command.CountVar(fs, &verbose, "v", "Increase verbosity")
+func CountVar(fs *flag.FlagSet, p *int, name, usage string) {
+	fs.Var((*countValue)(p), name, usage)
+}
+
+// countValue is a [flag.Value] for a counting flag (see CountVar).
+type countValue int
+
+func (c *countValue) String() string {
+	if c == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countValue) Set(s string) error {
+	switch s {
+	case "true":
+		*c++
+		return nil
+	case "false":
+		*c = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid count %q", s)
+	}
+	*c = countValue(n)
+	return nil
+}
+
+func (c *countValue) Get() any { return int(*c) }
+
+// IsBoolFlag reports true, so that the flag may be given without a value.
+func (c *countValue) IsBoolFlag() bool { return true }
+
+// DefaultText sets the text shown for the default value of the named flag in
+// help output to text, in place of the flag's literal default value.  If text
+// is empty, no default value is shown. This is useful when the default is
+// computed, or too large to display usefully. For example:
+//
+//	fs.StringVar(&user, "user", currentUser(), "User name")
+//	command.DefaultText(fs, "user", "the current user")
+//
+// renders "(default the current user)" regardless of the actual user name.
+// DefaultText panics if fs does not define the named flag.
+func DefaultText(fs *flag.FlagSet, name, text string) {
+	info := annotate(fs, name)
+	info.defText, info.hasDefText = text, true
+}
+
+// MarkRequired marks the named flags of fs as required. When a command with
+// required flags is run, Run reports a [UsageError] listing the required flags
+// that were not set, before the Init and Run functions of the command are
+// called. A flag is set if it is given on the command line (by any of its
+// names), or by a configuration file (see UseConfigFile). Help output notes
+// which flags are required. For example:
+//
+//	fs.StringVar(&project, "project", "", "Project name")
+//	command.MarkRequired(fs, "project")
+//
+// MarkRequired panics if fs does not define one of the named flags.
+func MarkRequired(fs *flag.FlagSet, names ...string) {
+	for _, name := range names {
+		annotate(fs, name).required = true
+	}
+}
+
+// Requires declares that the named flag of fs may be set only if each of the
+// flags named by deps is also set. When a command is run with the flag set
+// but one of deps not set, Run reports a [UsageError] before the Init and Run
+// functions of the command are called. A flag is set as described for
+// MarkRequired. Help output lists the dependencies of a flag. For example:
+//
+//	fs.StringVar(&keyFile, "key-file", "", "Encryption key `file`")
+//	fs.BoolVar(&encrypt, "encrypt", false, "Encrypt the output")
+//	command.Requires(fs, "key-file", "encrypt")
+//
+// Requires panics if fs does not define one of the named flags.
+func Requires(fs *flag.FlagSet, name string, deps ...string) {
+	// Record the constraint on the aliased flags, if any (see Alias).
+	primary := func(name string) string {
+		if info := annotate(fs, name); info.aliasOf != "" {
+			return info.aliasOf
+		}
+		return name
+	}
+	info := annotate(fs, primary(name))
+	for _, dep := range deps {
+		info.requires = append(info.requires, primary(dep))
+	}
+}
+
+// checkFlagRules reports a usage error if any of the required flags of the
+// command of e are not set (see MarkRequired), or if any flag that is set
+// requires a flag that is not (see Requires).
+func (e *Env) checkFlagRules() error {
+	c := e.Command
+	if c.CustomFlags {
+		return nil
+	}
+	set := explicitFlags(&c.Flags)
+	isSet := func(name string) bool {
+		return set[name] || e.hasConfigValue(name) || e.hasEnvValue(name)
+	}
+	var missing, problems []string
+	visitFlags(&c.Flags, c.FlagOrder, func(f *flag.Flag) {
+		info := getFlagInfo(f)
+		if info.inherited {
+			return // checked by the command that defines it
+		} else if !isSet(f.Name) {
+			if info.required {
+				missing = append(missing, "--"+f.Name)
+			}
+			return
+		}
+		for _, dep := range info.requires {
+			if !isSet(dep) {
+				problems = append(problems, fmt.Sprintf("flag --%s requires --%s", f.Name, dep))
+			}
+		}
+		for _, check := range info.validate {
+			if err := check(); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid value for flag --%s: %v", f.Name, err))
+			}
+		}
+	})
+	switch len(missing) {
+	case 0:
+	case 1:
+		return e.Usagef("missing required flag %s", missing[0])
+	default:
+		return e.Usagef("missing required flags %s", strings.Join(missing, ", "))
+	}
+	if len(problems) != 0 {
+		return e.Usagef("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateFlag registers check to validate the value of the named flag of fs.
+// When a command is run with the flag set, check is called with the value of
+// the flag after the flags are parsed, and before the Init and Run functions
+// of the command are called. If check reports an error, Run reports a
+// [UsageError] that names the flag. A flag is set as described for
+// MarkRequired. For example:
+//
+//	fs.IntVar(&port, "port", 8080, "Port to listen on")
+//	command.ValidateFlag(fs, "port", func(v int) error {
+//	   if v < 1 || v > 65535 {
+//	      return errors.New("port must be between 1 and 65535")
+//	   }
+//	   return nil
+//	})
+//
+// reports "invalid value for flag --port: port must be between 1 and 65535".
+// A flag may have multiple validators, which are checked in order.
+//
+// The type T must be the type of the value reported by the Get method of the
+// flag (see [flag.Getter]), for example int for a flag defined by IntVar.
+// ValidateFlag panics if fs does not define the named flag, or if the flag
+// does not report a value of type T.
+func ValidateFlag[T any](fs *flag.FlagSet, name string, check func(T) error) {
+	info := annotate(fs, name)
+	if info.aliasOf != "" {
+		name, info = info.aliasOf, annotate(fs, info.aliasOf)
+	}
+	g, ok := fs.Lookup(name).Value.(flag.Getter)
+	if ok {
+		_, ok = g.Get().(T)
+	}
+	if !ok {
+		panic(fmt.Sprintf("flag %q does not have a value of type %T", name, *new(T)))
+	}
+	info.validate = append(info.validate, func() error { return check(g.Get().(T)) })
+}
+
+// Deprecate marks the named flag of fs as deprecated. When the flag is set on
+// the command line, Run reports a warning (see Env.Warnf) before the Init and
+// Run functions of the command are called, including hint if it is not empty.
+// Help output marks a deprecated flag, with the hint. For example:
+//
+//	fs.StringVar(&out, "output", "", "Output file")
+//	command.Alias(fs, "output", "out")
+//	command.Deprecate(fs, "out", "use --output")
+//
+// warns "flag --out is deprecated: use --output" when --out is used. A
+// deprecated alias is omitted from help output, so the example lists only
+// --output. Deprecate panics if fs does not define the named flag.
+func Deprecate(fs *flag.FlagSet, name, hint string) {
+	info := annotate(fs, name)
+	info.deprecated, info.depHint = true, hint
+}
+
+// HideFlags marks the named flags of fs as hidden. Hidden flags work as usual,
+// but are omitted from help output, generated documentation, and example
+// configuration files, unless private flags are requested (see
+// [IncludePrivateFlags]). For example:
+//
+//	fs.BoolVar(&trace, "trace-rpc", false, "Log RPC traffic")
+//	command.HideFlags(fs, "trace-rpc")
+//
+// HideFlags panics if fs does not define one of the named flags.
+func HideFlags(fs *flag.FlagSet, names ...string) {
+	for _, name := range names {
+		annotate(fs, name).hidden = true
+	}
+}
+
+// FlagGroup assigns the named flags of fs to a group with the given heading.
+// Help output lists the flags that are not in any group first, followed by
+// each group under its heading, for example:
+//
+//	fs.StringVar(&out, "output", "", "Output file")
+//	fs.BoolVar(&compact, "compact", false, "Omit optional whitespace")
+//	command.FlagGroup(fs, "Output options", "output", "compact")
+//
+// lists --compact and --output beneath "Output options:". Groups are listed
+// in the order of their first flag in the listing (see C.FlagOrder). A flag
+// belongs to at most one group; assigning it again moves it. An empty group
+// name removes the flags from their group. FlagGroup panics if fs does not
+// define one of the named flags.
+func FlagGroup(fs *flag.FlagSet, group string, names ...string) {
+	for _, name := range names {
+		if info := annotate(fs, name); info.aliasOf != "" {
+			name = info.aliasOf // aliases are listed with their primary
+		}
+		annotate(fs, name).group = group
+	}
+}
+
+// MarkPersistent marks the named flags of fs as persistent. The subcommands
+// of a command with persistent flags inherit them: Each subcommand, and each
+// of its descendants in turn, defines the same flags bound to the same values,
+// unless it defines a flag of the same name itself. For example:
+//
+//	root := &command.C{
+//	   Name: "tool",
+//	   SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+//	      fs.BoolVar(&verbose, "verbose", false, "Enable verbose logging")
+//	      command.MarkPersistent(fs, "verbose")
+//	   },
+//	   Commands: []*command.C{ ... },
+//	}
+//
+// accepts "tool sub --verbose" even when flags are not merged (see
+// Env.MergeFlags). The help for each subcommand lists inherited flags under
+// "Global flags". Configuration files, environment variables, and rules such
+// as MarkRequired apply to a persistent flag as part of the command that
+// defines it, not the commands that inherit it. The aliases of a persistent
+// flag are also inherited. MarkPersistent panics if fs does not define one of
+// the named flags.
+func MarkPersistent(fs *flag.FlagSet, names ...string) {
+	for _, name := range names {
+		if info := annotate(fs, name); info.aliasOf != "" {
+			name = info.aliasOf
+		}
+		annotate(fs, name).persistent = true
+	}
+}
+
+// inheritFlags defines on fs the persistent flags of the parent command of
+// env, bound to the same values, except those fs already defines.
+func inheritFlags(env *Env, fs *flag.FlagSet) {
+	if env == nil || env.Parent == nil {
+		return
+	}
+	pfs := &env.Parent.Command.Flags
+	pfs.VisitAll(func(f *flag.Flag) {
+		info := getFlagInfo(f)
+		primary := info
+		if info.aliasOf != "" {
+			primary = getFlagInfo(pfs.Lookup(info.aliasOf))
+		}
+		if !primary.persistent || fs.Lookup(f.Name) != nil {
+			return
+		} else if p := fs.Lookup(info.aliasOf); p != nil && !getFlagInfo(p).inherited {
+			return // the primary is shadowed by a flag of the subcommand
+		}
+		cp := *info
+		cp.inherited = true
+		cp.aliases = slices.DeleteFunc(slices.Clone(info.aliases), func(name string) bool {
+			a := fs.Lookup(name) // shadowed by a flag of the subcommand
+			return a != nil && !getFlagInfo(a).inherited
+		})
+		fs.Var(&metaValue{Value: unwrapValue(f.Value), info: &cp}, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.DefValue
+	})
+}
+
+// flagPrivatePrefix is a usage prefix that marks a flag as hidden.
+// It is retained for compatibility; new code should use HideFlags.
+const flagPrivatePrefix = "PRIVATE:"
+
+// isHidden reports whether f is hidden, either by HideFlags or by the legacy
+// "PRIVATE:" usage prefix.
+func isHidden(f *flag.Flag) bool {
+	return getFlagInfo(f).hidden || strings.HasPrefix(f.Usage, flagPrivatePrefix)
+}
+
+// flagUsage returns the usage text of f, without a legacy "PRIVATE:" prefix.
+func flagUsage(f *flag.Flag) string {
+	if u, ok := strings.CutPrefix(f.Usage, flagPrivatePrefix); ok {
+		return strings.TrimPrefix(u, " ")
+	}
+	return f.Usage
+}
+
+// warnDeprecated reports a warning for each deprecated flag of the command
+// of e that was set on the command line (see Deprecate).
+func (e *Env) warnDeprecated() {
+	if e.Command.CustomFlags {
+		return
+	}
+	e.Command.Flags.Visit(func(f *flag.Flag) {
+		if info := getFlagInfo(f); !info.deprecated {
+			return
+		} else if info.depHint != "" {
+			e.Warnf("flag --%s is deprecated: %s", f.Name, info.depHint)
+		} else {
+			e.Warnf("flag --%s is deprecated", f.Name)
+		}
+	})
+}
+
+// explicitFlags returns the set of names of the flags of fs that were set on
+// the command line. A flag set by one of its aliases is reported by the name
+// of the aliased flag (see Alias).
+func explicitFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		if name := getFlagInfo(f).aliasOf; name != "" {
+			set[name] = true
+		} else {
+			set[f.Name] = true
+		}
+	})
+	return set
+}
+
+// flagNames returns the names of f for help rendering, consisting of f.Name
+// and any aliases of f, ordered with single-letter names first.
+func flagNames(f *flag.Flag) []string {
+	names := append([]string{f.Name}, getFlagInfo(f).aliases...)
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(min(len(a), 2), min(len(b), 2))
+	})
+	return names
+}
@@ -0,0 +1,565 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestSnapshotFlags(t *testing.T) {
+	var name string
+	var count int
+	root := &command.C{
+		Name: "root",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.StringVar(&name, "name", "anon", "A name")
+		},
+		Commands: []*command.C{{
+			Name: "sub",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.IntVar(&count, "count", 1, "A count")
+			},
+			Run: func(env *command.Env) error { return nil },
+		}},
+	}
+	env := root.NewEnv(nil)
+	snap := env.SnapshotFlags()
+
+	if err := command.Run(env, []string{"--name", "alice", "sub", "--count", "5"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if name != "alice" || count != 5 {
+		t.Errorf("After Run: name=%q count=%d, want alice, 5", name, count)
+	}
+
+	if err := env.RestoreFlags(snap); err != nil {
+		t.Fatalf("RestoreFlags: unexpected error: %v", err)
+	}
+	if name != "anon" || count != 1 {
+		t.Errorf("After restore: name=%q count=%d, want anon, 1", name, count)
+	}
+	var set []string
+	root.Commands[0].Flags.Visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	if len(set) != 0 {
+		t.Errorf("After restore: flags %q are marked as set", set)
+	}
+	if f := root.Commands[0].Flags.Lookup("count"); f == nil || f.DefValue != "1" {
+		t.Errorf("After restore: count flag is %+v, want default 1", f)
+	}
+
+	// The tree can be dispatched again after restoring.
+	if err := command.Run(env, []string{"sub", "--count=3"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if name != "anon" || count != 3 {
+		t.Errorf("After second Run: name=%q count=%d, want anon, 3", name, count)
+	}
+}
+
+func TestVarP(t *testing.T) {
+	var output string
+	var verbose bool
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			command.VarP(fs, newStringValue(&output, "out.txt"), "output", "o", "Write output to this `file`")
+			fs.BoolVar(&verbose, "v", false, "Verbose output")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+
+	for _, args := range [][]string{{"-o", "x"}, {"--output", "x"}, {"-output=x"}, {"--o=x"}} {
+		output = ""
+		if err := command.Run(cmd.NewEnv(nil), args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", args, err)
+		} else if output != "x" {
+			t.Errorf("Run %q: output is %q, want x", args, output)
+		}
+	}
+
+	help := renderHelp(cmd, 0)
+	const want = `Flags:
+  -o, --output file
+    	Write output to this file (default "out.txt")
+  -v	Verbose output`
+	if !strings.Contains(help, want) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", want, help)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	var verbose bool
+	var level int
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&verbose, "verbose", false, "Verbose output")
+			command.Alias(fs, "verbose", "v")
+			fs.IntVar(&level, "level", 2, "Logging level")
+			command.Alias(fs, "level", "l", "log-level")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	for _, args := range [][]string{{"-v", "-l", "5"}, {"--verbose", "--log-level=5"}, {"-v=true", "--level", "5"}} {
+		verbose, level = false, 0
+		if err := command.Run(cmd.NewEnv(nil), args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", args, err)
+		} else if !verbose || level != 5 {
+			t.Errorf("Run %q: verbose=%v level=%d, want true, 5", args, verbose, level)
+		}
+	}
+
+	help := renderHelp(cmd, 0)
+	const want = `Flags:
+  -l, --level, --log-level int
+    	Logging level (default 2)
+  -v, --verbose
+    	Verbose output
+`
+	if !strings.Contains(help, want) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", want, help)
+	}
+	if n := strings.Count(help, "Logging level"); n != 1 {
+		t.Errorf("Help output lists the level flag %d times, want 1", n)
+	}
+}
+
+// newStringValue returns a flag.Value for a string with the given default.
+func newStringValue(s *string, dflt string) flag.Value {
+	var fs flag.FlagSet
+	fs.StringVar(s, "x", dflt, "")
+	return fs.Lookup("x").Value
+}
+
+func TestDefaultText(t *testing.T) {
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.String("user", "alice", "User name")
+			command.DefaultText(fs, "user", "the current user")
+			fs.String("blob", strings.Repeat("x", 500), "Configuration blob")
+			command.DefaultText(fs, "blob", "")
+			fs.Int("n", 3, "A number")
+		},
+	}
+	cmd.SetFlags(nil, &cmd.Flags)
+	help := renderHelp(cmd, 0)
+	for _, want := range []string{
+		"User name (default the current user)\n",
+		"Configuration blob\n",
+		"A number (default 3)\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Help output is missing %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "alice") || strings.Contains(help, "xxx") {
+		t.Errorf("Help output contains a suppressed default:\n%s", help)
+	}
+}
+
+func TestMarkRequired(t *testing.T) {
+	var ran bool
+	newRoot := func() *command.C {
+		ran = false
+		return &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.VarP(fs, new(flagString), "project", "p", "Project name")
+				fs.String("zone", "", "Zone name")
+				fs.Bool("verbose", false, "Verbose output")
+				command.MarkRequired(fs, "project", "zone")
+			},
+			Init: func(*command.Env) error { ran = true; return nil },
+			Run:  func(*command.Env) error { ran = true; return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want string // error text, or "" for success
+	}{
+		{nil, "missing required flags --project, --zone"},
+		{[]string{"--verbose", "--zone", "z"}, "missing required flag --project"},
+		{[]string{"-p", "x"}, "missing required flag --zone"},
+		{[]string{"-p", "x", "--zone="}, ""},
+		{[]string{"--project", "x", "--zone", "z"}, ""},
+	}
+	for _, tc := range tests {
+		root := newRoot()
+		env := root.NewEnv(nil)
+		env.Log = io.Discard
+		err := command.Run(env, tc.args)
+		if tc.want == "" {
+			if err != nil || !ran {
+				t.Errorf("Run %q: got (%v, ran=%v), want success", tc.args, err, ran)
+			}
+			continue
+		}
+		var uerr command.UsageError
+		if !errors.As(err, &uerr) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Run %q: got %v, want usage error %q", tc.args, err, tc.want)
+		}
+		if ran {
+			t.Errorf("Run %q: command ran with missing flags", tc.args)
+		}
+	}
+
+	root := newRoot()
+	root.SetFlags(nil, &root.Flags)
+	if help := renderHelp(root, 0); !strings.Contains(help, "Zone name (required)\n") {
+		t.Errorf("Help output does not mark required flags:\n%s", help)
+	}
+}
+
+func TestDeprecate(t *testing.T) {
+	var output string
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.StringVar(&output, "output", "", "Output file")
+			command.Alias(fs, "output", "o", "out")
+			command.Deprecate(fs, "out", "use --output")
+			fs.Bool("legacy", false, "Use the legacy format")
+			command.Deprecate(fs, "legacy", "")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--output", "x"}, nil},
+		{[]string{"-o", "x"}, nil},
+		{[]string{"--out", "x"}, []string{"flag --out is deprecated: use --output"}},
+		{[]string{"--out", "x", "--legacy"}, []string{
+			"flag --legacy is deprecated",
+			"flag --out is deprecated: use --output",
+		}},
+	}
+	for _, tc := range tests {
+		var log strings.Builder
+		env := cmd.NewEnv(nil)
+		env.Log = &log
+		if err := command.Run(env, tc.args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", tc.args, err)
+		}
+		if got := env.Warnings(); !slices.Equal(got, tc.want) {
+			t.Errorf("Run %q: got warnings %q, want %q", tc.args, got, tc.want)
+		}
+		if output != "x" {
+			t.Errorf("Run %q: output is %q, want x", tc.args, output)
+		}
+	}
+
+	help := renderHelp(cmd, 0)
+	for _, want := range []string{
+		" --legacy\n    \tUse the legacy format (deprecated)\n",
+		"  -o, --output string\n    \tOutput file\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Help output is missing %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "--out ") || strings.Contains(help, "--out,") {
+		t.Errorf("Help output lists a deprecated alias:\n%s", help)
+	}
+}
+
+func TestHideFlags(t *testing.T) {
+	var trace bool
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&trace, "trace-rpc", false, "Log RPC traffic")
+			fs.String("name", "", "Your name")
+			command.HideFlags(fs, "trace-rpc")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	// A hidden flag still works.
+	if err := command.Run(cmd.NewEnv(nil), []string{"--trace-rpc"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if !trace {
+		t.Error("Flag --trace-rpc was not set")
+	}
+
+	if help := renderHelp(cmd, 0); strings.Contains(help, "trace-rpc") {
+		t.Errorf("Help output lists a hidden flag:\n%s", help)
+	}
+	if help := renderHelp(cmd, command.IncludePrivateFlags); !strings.Contains(help, " --trace-rpc\n    \tLog RPC traffic\n") {
+		t.Errorf("Private help output is missing the hidden flag:\n%s", help)
+	}
+}
+
+func TestFlagGroup(t *testing.T) {
+	cmd := &command.C{
+		Name: "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.Bool("verbose", false, "Verbose logging")
+			fs.String("user", "", "User name")
+			fs.String("token", "", "Access token")
+			command.VarP(fs, new(flagString), "output", "o", "Output file")
+			fs.Bool("compact", false, "Omit optional whitespace")
+			command.FlagGroup(fs, "Output options", "o", "compact")
+			command.FlagGroup(fs, "Auth options", "user", "token")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	if err := command.Run(cmd.NewEnv(nil), nil); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	const want = `Flags:
+ --verbose
+    	Verbose logging
+
+Output options:
+ --compact
+    	Omit optional whitespace
+  -o, --output value
+    	Output file
+
+Auth options:
+ --token string
+    	Access token
+ --user string
+    	User name`
+	if got := cmd.HelpInfo(0).Flags; got != want {
+		t.Errorf("Flag help: got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkPersistent(t *testing.T) {
+	var verbose, fast bool
+	newRoot := func() *command.C {
+		verbose, fast = false, false
+		return &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.BoolVar(&verbose, "verbose", false, "Verbose logging")
+				command.Alias(fs, "verbose", "v")
+				fs.Bool("local", false, "Not inherited")
+				command.MarkPersistent(fs, "v")
+			},
+			Commands: []*command.C{{
+				Name: "run",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					fs.BoolVar(&fast, "fast", false, "Run fast")
+				},
+				Run: func(*command.Env) error { return nil },
+				Commands: []*command.C{{
+					Name: "deep",
+					Run:  func(*command.Env) error { return nil },
+				}},
+			}},
+		}
+	}
+	tests := []struct {
+		args    []string
+		want    bool
+		wantErr bool
+	}{
+		{[]string{"run"}, false, false},
+		{[]string{"--verbose", "run"}, true, false},
+		{[]string{"run", "--verbose"}, true, false},
+		{[]string{"run", "-v", "--fast"}, true, false},
+		{[]string{"run", "deep", "-v"}, true, false},
+		{[]string{"run", "--local"}, false, true},
+	}
+	for _, tc := range tests {
+		env := newRoot().NewEnv(nil).MergeFlags(false)
+		env.Log = io.Discard
+		err := command.Run(env, tc.args)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Run %q: got error %v, want error %v", tc.args, err, tc.wantErr)
+		}
+		if verbose != tc.want {
+			t.Errorf("Run %q: verbose is %v, want %v", tc.args, verbose, tc.want)
+		}
+	}
+
+	root := newRoot()
+	if err := command.Run(root.NewEnv(nil), []string{"run", "deep"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	const want = `Flags:
+ --fast
+    	Run fast
+
+Global flags:
+  -v, --verbose
+    	Verbose logging`
+	if got := root.Commands[0].HelpInfo(0).Flags; got != want {
+		t.Errorf("Flag help: got:\n%s\nwant:\n%s", got, want)
+	}
+	const wantDeep = `Global flags:
+  -v, --verbose
+    	Verbose logging`
+	if got := root.Commands[0].Commands[0].HelpInfo(0).Flags; got != wantDeep {
+		t.Errorf("Flag help: got:\n%s\nwant:\n%s", got, wantDeep)
+	}
+}
+
+func TestRequires(t *testing.T) {
+	newCmd := func() *command.C {
+		return &command.C{
+			Name: "test",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.Bool("encrypt", false, "Encrypt the output")
+				command.Alias(fs, "encrypt", "e")
+				fs.String("key-file", "", "Encryption key file")
+				fs.String("cipher", "aes", "Cipher name")
+				command.Requires(fs, "key-file", "encrypt")
+				command.Requires(fs, "cipher", "e", "key-file")
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want string // error text, or "" for success
+	}{
+		{nil, ""},
+		{[]string{"--encrypt"}, ""},
+		{[]string{"--key-file", "k"}, "flag --key-file requires --encrypt"},
+		{[]string{"-e", "--key-file", "k"}, ""},
+		{[]string{"--cipher", "des"}, "flag --cipher requires --encrypt; flag --cipher requires --key-file"},
+		{[]string{"--cipher", "des", "--key-file", "k", "-e"}, ""},
+	}
+	for _, tc := range tests {
+		env := newCmd().NewEnv(nil)
+		env.Log = io.Discard
+		err := command.Run(env, tc.args)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			}
+		} else if !command.IsUsage(err) || err.Error() != tc.want {
+			t.Errorf("Run %q: got %v, want usage error %q", tc.args, err, tc.want)
+		}
+	}
+
+	cmd := newCmd()
+	cmd.SetFlags(nil, &cmd.Flags)
+	if help := renderHelp(cmd, 0); !strings.Contains(help, "Cipher name (default \"aes\") (requires --encrypt, --key-file)\n") {
+		t.Errorf("Help output does not list dependencies:\n%s", help)
+	}
+}
+
+func TestValidateFlag(t *testing.T) {
+	inited := false
+	newCmd := func() *command.C {
+		inited = false
+		return &command.C{
+			Name: "test",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.Int("port", 0, "Port to listen on")
+				command.Alias(fs, "port", "p")
+				command.ValidateFlag(fs, "p", func(v int) error {
+					if v < 1 || v > 65535 {
+						return errors.New("out of range")
+					}
+					return nil
+				})
+				fs.String("name", "", "Server name")
+				command.ValidateFlag(fs, "name", func(v string) error {
+					if strings.ContainsAny(v, " \t") {
+						return errors.New("must not contain spaces")
+					}
+					return nil
+				})
+			},
+			Init: func(*command.Env) error { inited = true; return nil },
+			Run:  func(*command.Env) error { return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want string // error text, or "" for success
+	}{
+		{nil, ""}, // flags not set are not checked
+		{[]string{"--port", "80"}, ""},
+		{[]string{"-p", "0"}, "invalid value for flag --port: out of range"},
+		{[]string{"--name", "a b", "--port", "99999"},
+			"invalid value for flag --name: must not contain spaces; invalid value for flag --port: out of range"},
+	}
+	for _, tc := range tests {
+		env := newCmd().NewEnv(nil)
+		env.Log = io.Discard
+		err := command.Run(env, tc.args)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			}
+		} else if !command.IsUsage(err) || err.Error() != tc.want {
+			t.Errorf("Run %q: got %v, want usage error %q", tc.args, err, tc.want)
+		} else if inited {
+			t.Errorf("Run %q: Init was called despite an invalid flag", tc.args)
+		}
+	}
+
+	// The type of the validator must match the flag.
+	defer func() {
+		if recover() == nil {
+			t.Error("ValidateFlag with the wrong type did not panic")
+		}
+	}()
+	var fs flag.FlagSet
+	fs.Int("port", 0, "Port")
+	command.ValidateFlag(&fs, "port", func(string) error { return nil })
+}
+
+func TestCountVar(t *testing.T) {
+	var level int
+	newCmd := func() *command.C {
+		level = 0
+		return &command.C{
+			Name: "test",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.CountVar(fs, &level, "verbose", "Increase verbosity")
+				command.Alias(fs, "verbose", "v")
+				fs.Bool("x", false, "Another flag")
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-v", "-v", "--verbose"}, 3},
+		{[]string{"-vvv"}, 3},
+		{[]string{"-xvv", "arg"}, 2},
+		{[]string{"--verbose=5", "-v"}, 6},
+		{[]string{"-v", "-v=false"}, 0},
+	}
+	for _, tc := range tests {
+		env := newCmd().NewEnv(nil).PflagStyle(true)
+		if err := command.Run(env, tc.args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		} else if level != tc.want {
+			t.Errorf("Run %q: level is %d, want %d", tc.args, level, tc.want)
+		}
+	}
+
+	env := newCmd().NewEnv(nil)
+	env.Log = io.Discard
+	if err := command.Run(env, []string{"-v=lots"}); err == nil {
+		t.Error("Run with invalid count: got nil error, want error")
+	}
+
+	cmd := newCmd()
+	cmd.SetFlags(nil, &cmd.Flags)
+	if help := renderHelp(cmd, 0); !strings.Contains(help, "  -v, --verbose\n    \tIncrease verbosity\n") {
+		t.Errorf("Help output does not list the count flag:\n%s", help)
+	}
+}
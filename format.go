@@ -0,0 +1,96 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Format defines how values written by Env.Emit are rendered, for example
+// to follow the conventions of a locale (see Env.SetFormat). A nil *Format
+// renders values as [fmt.Sprint] does.
+type Format struct {
+	// If non-empty, this separates groups of three digits in the integer part
+	// of a number, as in "1,234,567" for ",".
+	Grouping string
+
+	// If non-empty, this replaces "." as the decimal separator of a
+	// floating-point number, as in "3,5" for ",".
+	Decimal string
+
+	// If non-empty, this is the layout for [time.Time] values, as for
+	// [time.Time.Format]. Otherwise times are rendered by their String method.
+	TimeLayout string
+
+	// If set, this is called first for each value. If it reports true, its
+	// result is used as the rendering of the value. This allows a program to
+	// customize the rendering of other types, such as currency amounts.
+	Value func(v any) (string, bool)
+}
+
+// SetFormat sets the format for values written by e.Emit, and returns e. If
+// f == nil, values are rendered as by [fmt.Sprint] (the default). The setting
+// is inherited by the descendants of e.
+func (e *Env) SetFormat(f *Format) *Env { e.format = f; return e }
+
+// FormatValue renders v as text according to the format of e (see SetFormat),
+// as Emit does for each of its arguments.
+func (e *Env) FormatValue(v any) string { return e.format.value(v) }
+
+// value renders v according to f.
+func (f *Format) value(v any) string {
+	if f == nil {
+		return fmt.Sprint(v)
+	}
+	if f.Value != nil {
+		if s, ok := f.Value(v); ok {
+			return s
+		}
+	}
+	switch t := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return f.number(fmt.Sprint(t))
+	case float32:
+		return f.number(strconv.FormatFloat(float64(t), 'f', -1, 32))
+	case float64:
+		return f.number(strconv.FormatFloat(t, 'f', -1, 64))
+	case time.Time:
+		if f.TimeLayout != "" {
+			return t.Format(f.TimeLayout)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// number renders a decimal number s, such as "-1234.5", according to f.
+func (f *Format) number(s string) string {
+	var sign string
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if f.Grouping != "" && len(whole) > 3 && isDigits(whole) {
+		var sb strings.Builder
+		for i, d := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				sb.WriteString(f.Grouping)
+			}
+			sb.WriteRune(d)
+		}
+		whole = sb.String()
+	}
+	if !hasFrac {
+		return sign + whole
+	} else if f.Decimal != "" {
+		return sign + whole + f.Decimal + frac
+	}
+	return sign + whole + "." + frac
+}
+
+// isDigits reports whether s consists only of decimal digits.
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
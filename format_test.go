@@ -0,0 +1,74 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestFormat(t *testing.T) {
+	when := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	de := &command.Format{
+		Grouping:   ".",
+		Decimal:    ",",
+		TimeLayout: "02.01.2006",
+		Value: func(v any) (string, bool) {
+			if b, ok := v.(bool); ok {
+				if b {
+					return "ja", true
+				}
+				return "nein", true
+			}
+			return "", false
+		},
+	}
+	env := (&command.C{Name: "test"}).NewEnv(nil).SetFormat(de)
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1234, "1.234"},
+		{-1234567, "-1.234.567"},
+		{uint64(1000000), "1.000.000"},
+		{1234.5, "1.234,5"},
+		{float32(-0.25), "-0,25"},
+		{math.Inf(1), "+Inf"},
+		{when, "14.03.2026"},
+		{true, "ja"},
+		{"12345", "12345"}, // strings are not numbers
+		{time.Second, "1s"},
+	}
+	for _, tc := range tests {
+		if got := env.FormatValue(tc.v); got != tc.want {
+			t.Errorf("FormatValue(%v): got %q, want %q", tc.v, got, tc.want)
+		}
+	}
+
+	// Without a format, values are rendered as by fmt.Sprint.
+	plain := (&command.C{Name: "test"}).NewEnv(nil)
+	if got, want := plain.FormatValue(1234.5), "1234.5"; got != want {
+		t.Errorf("FormatValue: got %q, want %q", got, want)
+	}
+
+	// Emit uses the format of its environment.
+	var out strings.Builder
+	root := &command.C{
+		Name: "report",
+		Run: func(env *command.Env) error {
+			return env.Emit("total:", 1234567, "on", when)
+		},
+	}
+	if err := command.Run(root.NewEnv(nil).SetStdout(&out).SetFormat(de), nil); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if got, want := out.String(), "total: 1.234.567 on 14.03.2026\n"; got != want {
+		t.Errorf("Emit: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A Term is a word or phrase defined in the glossary of a command tree (see
+// C.Glossary). Terms are defined once, on the root command, and used
+// throughout the help text of the tree.
+//
+// An occurrence of a term in help text is recognized when it appears as a
+// whole word, ignoring case. For each command whose help text uses one or
+// more terms, the help shows the first line of the definition of each:
+//
+//	Terms:
+//	  workspace  A directory containing a project and its settings.
+//
+// All the terms, with their full definitions, are listed by the generated
+// "help glossary" topic, unless the help command has its own topic by that
+// name.
+type Term struct {
+	Name       string // the term, for example "workspace"
+	Definition string // the definition of the term
+}
+
+// glossaryName is the name of the generated glossary topic.
+const glossaryName = "glossary"
+
+// glossary returns the glossary of the root command of e.
+func (e *Env) glossary() []Term {
+	root := e
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return root.Command.Glossary
+}
+
+// glossaryInfo returns the help for the generated glossary topic for e, or
+// nil if there is none. Here e is the environment of the help command.
+func (e *Env) glossaryInfo() *HelpInfo {
+	terms := e.glossary()
+	if len(terms) == 0 || e.Command.FindSubcommand(glossaryName) != nil {
+		return nil
+	}
+	terms = slices.SortedFunc(slices.Values(terms), func(a, b Term) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	const synopsis = "Definitions of terms used in help."
+	var sb strings.Builder
+	sb.WriteString(synopsis)
+	for _, t := range terms {
+		sb.WriteString("\n\n  " + t.Name + "\n")
+		sb.WriteString(indent("    ", "    ", strings.TrimSpace(t.Definition)))
+	}
+	return &HelpInfo{
+		Name:     glossaryName,
+		Synopsis: synopsis,
+		Help:     sb.String(),
+	}
+}
+
+// glossaryNotes returns a list of the terms of e's glossary that occur in
+// help, with the first lines of their definitions, or "" if there are none.
+func (e *Env) glossaryNotes(help string) string {
+	type note struct{ name, def string }
+	var notes []note
+	var width int
+	for _, t := range e.glossary() {
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(t.Name) + `\b`)
+		if re.MatchString(help) {
+			def, _, _ := strings.Cut(strings.TrimSpace(t.Definition), "\n")
+			notes = append(notes, note{t.Name, def})
+			width = max(width, e.displayWidth(t.Name))
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Terms:")
+	for _, n := range notes {
+		sb.WriteString("\n  " + n.name + strings.Repeat(" ", width-e.displayWidth(n.name)+2) + n.def)
+	}
+	return sb.String()
+}
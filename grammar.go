@@ -0,0 +1,68 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ArgumentGrammar describes the forms of the arguments accepted for a single
+// command by [Run], in the EBNF notation of the Go specification. Here, arg
+// is any argument, name is the name of a flag defined by the command, and
+// value is any string.
+//
+// When flags are merged (the default; see Env.MergeFlags), flags and free
+// arguments may be interleaved, and the result of parsing is the same as if
+// the flags, in their original order, preceded the free arguments. Otherwise,
+// the first free argument ends the flags, and the rest of the arguments are
+// free. A flag-shaped argument that does not name a flag of the command is
+// treated as a free argument when flags are merged, so that it may be parsed
+// by a subcommand; otherwise it is an error.
+//
+// When PflagStyle is enabled, clusters of single-letter flags are expanded
+// into separate flags before the arguments are parsed.
+//
+// Use [ValidateArgs] to check whether arguments conform to this grammar.
+const ArgumentGrammar = `
+args   = { flag | free } [ "--" { arg } ] .
+flag   = prefix name "=" value  (* any flag *)
+       | prefix name            (* a Boolean flag *)
+       | prefix name arg        (* a non-Boolean flag *) .
+prefix = "-" | "--" .
+free   = "-" | arg not beginning with "-" .
+`
+
+// ValidateArgs reports whether args conform to [ArgumentGrammar] for a
+// command with the flags defined by fs. It returns nil if they do; otherwise
+// it returns an error describing the first argument that does not conform.
+// It does not check whether the values given for the flags are valid.
+//
+// Arguments that conform to the grammar parse the same way whether or not
+// flags are merged, except for the placement of the free arguments.
+func ValidateArgs(fs *flag.FlagSet, args []string) error {
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if s == "--" {
+			return nil
+		} else if s == "-" || !strings.HasPrefix(s, "-") {
+			continue
+		}
+		rest := strings.TrimPrefix(s[1:], "-")
+		name, _, hasValue := strings.Cut(rest, "=")
+		if name == "" || strings.HasPrefix(name, "-") {
+			return fmt.Errorf("argument %d: malformed flag %q", i+1, s)
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("argument %d: flag %q is not defined", i+1, name)
+		} else if !hasValue && !isBoolFlag(f) {
+			if i+1 == len(args) {
+				return fmt.Errorf("argument %d: missing value for flag %q", i+1, name)
+			}
+			i++ // skip the value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+// grammarFlags defines the flags used by the grammar tests on fs.
+func grammarFlags(fs *flag.FlagSet) {
+	fs.Bool("v", false, "Boolean")
+	fs.Bool("quiet", false, "Boolean")
+	fs.String("s", "", "String")
+	fs.String("name", "", "String")
+	fs.Int("n", 0, "Integer")
+}
+
+// parseResult records the flag values and free arguments from a parse.
+type parseResult struct {
+	Flags map[string]string
+	Args  []string
+}
+
+func resultOf(fs *flag.FlagSet) parseResult {
+	r := parseResult{Flags: make(map[string]string), Args: fs.Args()}
+	fs.VisitAll(func(f *flag.Flag) { r.Flags[f.Name] = f.Value.String() })
+	return r
+}
+
+// genArgs generates a random argument list conforming to the argument grammar
+// for grammarFlags, and returns it along with an equivalent list in which all
+// the flags precede the free arguments.
+func genArgs(rng *rand.Rand) (args, canon []string) {
+	pick := func(ss ...string) string { return ss[rng.IntN(len(ss))] }
+	value := func() string { return pick("x", "", "-", "--", "-v", "a=b", "--name") }
+	var flags, free []string
+	for range rng.IntN(8) {
+		if rng.IntN(2) == 0 {
+			arg := pick("x", "y", "-", "", "a=b")
+			args = append(args, arg)
+			free = append(free, arg)
+			continue
+		}
+		prefix := pick("-", "--")
+		var toks []string
+		switch name := pick("v", "quiet", "s", "name", "n"); name {
+		case "v", "quiet":
+			toks = []string{prefix + name + pick("", "=true", "=false")}
+		case "n":
+			toks = []string{prefix + name + "=" + pick("1", "-5", "0x10")}
+		default:
+			if rng.IntN(2) == 0 {
+				toks = []string{prefix + name + "=" + value()}
+			} else {
+				toks = []string{prefix + name, value()}
+			}
+		}
+		args = append(args, toks...)
+		flags = append(flags, toks...)
+	}
+	canon = append(flags, free...)
+	if rng.IntN(3) == 0 {
+		tail := []string{"--"}
+		for range rng.IntN(3) {
+			tail = append(tail, pick("x", "-v", "--", "--name=q", "-s"))
+		}
+		args = append(args, tail...)
+		canon = append(canon, tail...)
+	}
+	return args, canon
+}
+
+func TestArgumentGrammar(t *testing.T) {
+	var got parseResult
+	root := &command.C{
+		Name:     "test",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) { grammarFlags(fs) },
+		Run: func(env *command.Env) error {
+			got = resultOf(&env.Command.Flags)
+			got.Args = env.Args
+			return nil
+		},
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 2000 {
+		args, canon := genArgs(rng)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		grammarFlags(fs)
+		if err := command.ValidateArgs(fs, args); err != nil {
+			t.Fatalf("ValidateArgs %q: unexpected error: %v", args, err)
+		}
+
+		// Parsing the canonical arguments directly gives the expected result.
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse(canon); err != nil {
+			t.Fatalf("Parse %q: unexpected error: %v", canon, err)
+		}
+		want := resultOf(fs)
+
+		// Parsing the original arguments with merged flags gives the same result.
+		got = parseResult{}
+		if err := command.Run(root.Clone().NewEnv(nil).MergeFlags(true), args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Fatalf("Run %q: wrong result (-got, +want):\n%s", args, diff)
+		}
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	grammarFlags(fs)
+	tests := []struct {
+		args    string
+		wantErr string
+	}{
+		{"", ""},
+		{"x -v y --name=z -s q -", ""},
+		{"-n 1 -- -bogus ---", ""},
+		{"--s -- x", ""},
+		{"x -bogus", `flag "bogus" is not defined`},
+		{"x ---v", `malformed flag "---v"`},
+		{"-=1", `malformed flag "-=1"`},
+		{"-v -s", `missing value for flag "s"`},
+	}
+	for _, tc := range tests {
+		err := command.ValidateArgs(fs, strings.Fields(tc.args))
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateArgs %q: unexpected error: %v", tc.args, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("ValidateArgs %q: got %v, want %q", tc.args, err, tc.wantErr)
+		}
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"reflect"
+	"slices"
 	"strings"
-	"text/tabwriter"
+	"unicode"
 )
 
 // HelpCommand constructs a standardized help command with optional topics.
@@ -49,12 +49,38 @@ With -a or --all, also show help for unlisted commands and private flags.`,
 
 // A HelpTopic specifies a name and some help text for use in constructing help
 // topic commands.
+//
+// A topic may contain subtopics, which are listed beneath it in help output,
+// and are addressed by their path from the help command. For example, the
+// subtopic "proxies" of the topic "networking" of the topic "guides" is shown
+// by "help guides networking proxies".
 type HelpTopic struct {
-	Name string
-	Help string
+	Name   string
+	Help   string
+	Topics []HelpTopic // subtopics, if any
+}
+
+func (h HelpTopic) command() *C {
+	cmd := &C{Name: h.Name, Help: h.Help}
+	for _, t := range h.Topics {
+		cmd.Commands = append(cmd.Commands, t.command())
+	}
+	return cmd
 }
 
-func (h HelpTopic) command() *C { return &C{Name: h.Name, Help: h.Help} }
+// isTopic reports whether c is a help topic: It is not runnable, and its
+// subcommands, if any, are also help topics.
+func (c *C) isTopic() bool {
+	if c.Runnable() {
+		return false
+	}
+	for _, sub := range c.Commands {
+		if !sub.isTopic() {
+			return false
+		}
+	}
+	return true
+}
 
 // HelpInfo records synthesized help details for a command.
 type HelpInfo struct {
@@ -69,6 +95,13 @@ type HelpInfo struct {
 
 	// Help for subtopics (populated if requested)
 	Topics []HelpInfo
+
+	// Help for the most used descendants, named by their paths relative to
+	// this command (populated only when help is printed by a program that
+	// tracks usage; see Env.TrackUsage)
+	MostUsed []HelpInfo
+
+	width func(string) int // measures names in tables (see Env.SetDisplayWidth)
 }
 
 // HelpFlags is a bit mask of flags for the HelpInfo method.
@@ -86,50 +119,119 @@ const (
 
 // HelpInfo returns help details for c.
 //
+// Subcommands and topics are listed in the order they are defined, and flags
+// are listed in the order given by the FlagOrder of the command, or in
+// lexicographic order by name if it has none. Rendering help does not modify
+// the commands or their flags. Unless a command has a HelpFunc, whose text may
+// vary from call to call, the result depends only on the command tree rooted
+// at c and the flags defined by its commands, and not on the environment: in
+// particular, MostUsed is not populated (see Env.TrackUsage).
+//
 // A command or subcommand with no Run function and no subcommands of its own
-// is considered a help topic, and listed separately.
+// is considered a help topic, and listed separately. A command that is not
+// runnable and whose subcommands are all help topics is also a help topic,
+// and its subtopics are listed beneath it.
 //
-// Flags whose usage message has the case-sensitive prefix "PRIVATE:" are
-// omitted from help listings unless [IncludePrivateFlags] is set.
+// Flags marked hidden (see [HideFlags]) are omitted from help listings unless
+// [IncludePrivateFlags] is set. For compatibility, a flag whose usage message
+// has the case-sensitive prefix "PRIVATE:" is also treated as hidden.
 // Subcommands marked as unlisted are omitted from help listings unless
 // [IncludeUnlisted] is set.
-func (c *C) HelpInfo(flags HelpFlags) HelpInfo {
-	help := strings.TrimSpace(c.Help)
-	prefix := "  " + c.Name + " "
+//
+// If c has a HelpFunc, it is called to compute the help text, with a new root
+// environment for c (see C.NewEnv).
+//
+// The Usage and Help text may contain placeholders, which are replaced when
+// help is rendered:
+//
+//	{{.Prog}}  the name of the program (also $PROG; see Env.ProgramName)
+//	{{.Name}}  the name of the command
+//	{{.Path}}  the names of the commands from the root to this one
+//
+// This allows help text to remain correct if the program binary is renamed or
+// the command is attached to a different parent.
+func (c *C) HelpInfo(flags HelpFlags) HelpInfo { return c.helpInfo(nil, flags) }
+
+// helpInfo implements HelpInfo for c. If env != nil, it is the environment
+// for c, which is passed to the HelpFunc of c and its subcommands; otherwise a
+// new root environment for c is used.
+func (c *C) helpInfo(env *Env, flags HelpFlags) HelpInfo {
+	if env == nil {
+		env = c.NewEnv(nil)
+	}
+	help := strings.TrimSpace(env.expandHelp(c.helpText(env)))
+	name := c.Name
+	if env.Parent == nil {
+		name = env.rootName()
+	}
+	prefix := "  " + name + " "
 	h := HelpInfo{
 		Name:     c.Name,
 		Synopsis: strings.SplitN(help, "\n", 2)[0],
 		Help:     help,
+		width:    env.width,
+	}
+	if notes := env.glossaryNotes(help); notes != "" {
+		h.Help += "\n\n" + notes
 	}
 	if u := c.usageLines(flags); len(u) != 0 {
-		h.Usage = "Usage:\n\n" + indent(prefix, prefix, strings.Join(u, "\n"))
+		h.Usage = "Usage:\n\n" + indent(prefix, prefix, env.expandHelp(strings.Join(u, "\n")))
 	}
 	if c.hasFlagsDefined(flags.wantPrivateFlags()) {
 		var buf bytes.Buffer
-		fmt.Fprintln(&buf, "Flags:")
-		writeFlagHelp(&buf, &c.Flags, flags.wantPrivateFlags())
+		writeFlagHelp(&buf, &c.Flags, flags.wantPrivateFlags(), c.FlagOrder, env.flagEnvName)
 		h.Flags = strings.TrimSpace(buf.String())
 	}
 	if flags.wantCommands() {
 		for _, cmd := range c.Commands {
-			if cmd.Unlisted && !flags.wantUnlisted() {
+			if !cmd.Supported() || (cmd.Unlisted && !flags.wantUnlisted()) {
 				continue
 			}
-			sh := cmd.HelpInfo(flags &^ IncludeCommands) // don't recur
-			if cmd.Runnable() || len(cmd.Commands) != 0 {
-				h.Commands = append(h.Commands, sh)
-			} else {
-				h.Topics = append(h.Topics, sh)
+			if cmd.isTopic() {
+				// Include subtopics, so they can be listed beneath the topic.
+				h.Topics = append(h.Topics, cmd.helpInfo(env.newChild(cmd, nil), flags))
+				continue
+			}
+			sh := cmd.helpInfo(env.newChild(cmd, nil), flags&^IncludeCommands) // don't recur
+			if c.Default != "" && cmd.Name == c.Default {
+				sh.Synopsis = joinSpace(sh.Synopsis, "(default)")
 			}
+			h.Commands = append(h.Commands, sh)
 		}
 	}
 	return h
 }
 
+// helpText returns the help text for c, computed by its HelpFunc if it has
+// one. If env == nil, HelpFunc is passed a new root environment for c.
+func (c *C) helpText(env *Env) string {
+	if c.HelpFunc == nil {
+		return c.Help
+	} else if env == nil {
+		env = c.NewEnv(nil)
+	}
+	return c.HelpFunc(env)
+}
+
+// expandHelp replaces the help placeholders in s (see C.HelpInfo) with their
+// values for the command of e.
+func (e *Env) expandHelp(s string) string {
+	if !strings.Contains(s, "{{.") && !strings.Contains(s, "$PROG") {
+		return s
+	}
+	prog := e.ProgramName()
+	return strings.NewReplacer(
+		"{{.Prog}}", prog,
+		"$PROG", prog,
+		"{{.Name}}", e.Command.Name,
+		"{{.Path}}", strings.Join(e.displayPath(), " "),
+	).Replace(s)
+}
+
 func (c *C) hasFlagsDefined(wantPrivate bool) (ok bool) {
 	if !c.CustomFlags {
 		c.Flags.VisitAll(func(f *flag.Flag) {
-			if !strings.HasPrefix(f.Usage, flagPrivatePrefix) || wantPrivate {
+			if !isHidden(f) || wantPrivate {
 				ok = true
 			}
 		})
@@ -138,10 +240,23 @@ func (c *C) hasFlagsDefined(wantPrivate bool) (ok bool) {
 }
 
 func (c *C) setFlags(env *Env, fs *flag.FlagSet) {
-	if c != nil && c.SetFlags != nil && !c.isFlagSet {
+	if c == nil || c.isFlagSet {
+		return
+	}
+	mirror := c.FlagParser != nil && !c.CustomFlags
+	if mirror {
+		mirrorFlags(c.FlagParser, fs)
+	}
+	if c.SetFlags != nil {
 		c.SetFlags(env, fs)
-		c.isFlagSet = true
 	}
+	if !c.CustomFlags {
+		if mirror {
+			mirrorFlags(c.FlagParser, fs)
+		}
+		inheritFlags(env, fs) // see MarkPersistent
+	}
+	c.isFlagSet = true
 }
 
 // WriteUsage writes a usage summary to w.
@@ -177,32 +292,123 @@ func (h HelpInfo) WriteLong(w io.Writer) {
 	if h.Flags != "" {
 		fmt.Fprint(w, h.Flags, "\n\n")
 	}
+	if len(h.MostUsed) != 0 {
+		writeTopics(w, h.width, h.Name+" ", "Most used:", h.MostUsed)
+	}
 	if len(h.Commands) != 0 {
-		writeTopics(w, h.Name+" ", "Subcommands:", h.Commands)
+		writeTopics(w, h.width, h.Name+" ", "Subcommands:", h.Commands)
 	}
 	if len(h.Topics) != 0 {
-		writeTopics(w, "", "Help topics:", h.Topics)
+		writeTopics(w, h.width, "", "Help topics:", h.Topics)
 	}
 }
 
-func writeTopics(w io.Writer, base, label string, topics []HelpInfo) {
+// writeTopics writes a labelled list of the names and synopses of topics to
+// w. The subtopics of each topic, if any, are listed beneath it, indented.
+// The names are aligned by their display width as measured by width, or by
+// DisplayWidth if width == nil.
+func writeTopics(w io.Writer, width func(string) int, base, label string, topics []HelpInfo) {
 	fmt.Fprintln(w, label)
-	tw := tabwriter.NewWriter(w, 4, 8, 1, ' ', 0)
-	for _, cmd := range topics {
-		syn := cmd.Synopsis
+
+	// Flatten the hierarchy into a list of indented names.
+	type entry struct{ name, syn string }
+	var entries []entry
+	var add func(indent string, topics []HelpInfo)
+	add = func(indent string, topics []HelpInfo) {
+		for _, cmd := range topics {
+			entries = append(entries, entry{indent + base + cmd.Name, cmd.Synopsis})
+			add(indent+"  ", cmd.Topics)
+		}
+	}
+	add("", topics)
+
+	// Align the synopses by the display width of the names, which may differ
+	// from their length in bytes or runes.
+	if width == nil {
+		width = DisplayWidth
+	}
+	var col int
+	for _, e := range entries {
+		col = max(col, width(e.name))
+	}
+	for _, e := range entries {
+		syn := e.syn
 		if syn == "" {
 			syn = "(no description available)"
 		}
-		fmt.Fprint(tw, "  ", base+cmd.Name, "\t:\t", syn, "\n")
+		pad := strings.Repeat(" ", col-width(e.name)+1)
+		fmt.Fprint(w, "  ", e.name, pad, ":   ", syn, "\n")
 	}
-	tw.Flush()
 	fmt.Fprintln(w)
 }
 
+// DisplayWidth reports the number of terminal columns occupied by s. It is
+// the default measure used to align tables in help output (see
+// Env.SetDisplayWidth).
+//
+// It treats combining marks and other zero-width characters as occupying no
+// columns, East Asian wide and fullwidth characters as occupying two columns,
+// and all other characters as occupying one.
+func DisplayWidth(s string) (n int) {
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || r == '\u200b':
+			// zero width
+		case isWideRune(r):
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// SetDisplayWidth sets the function used to measure the number of terminal
+// columns occupied by a string when aligning tables in help output, and
+// returns e. If f == nil, [DisplayWidth] is used (the default). Programs that
+// need more precise measurement, for example of emoji sequences, may supply
+// their own. The setting is inherited by the descendants of e.
+func (e *Env) SetDisplayWidth(f func(string) int) *Env { e.width = f; return e }
+
+// displayWidth reports the display width of s according to the setting of e
+// (see SetDisplayWidth).
+func (e *Env) displayWidth(s string) int {
+	if e.width != nil {
+		return e.width(s)
+	}
+	return DisplayWidth(s)
+}
+
+// isWideRune reports whether r is an East Asian wide or fullwidth character,
+// or an emoji typically rendered in two columns.
+func isWideRune(r rune) bool {
+	switch {
+	case r < 0x1100:
+		return false
+	case r <= 0x115f, // Hangul Jamo initials
+		r >= 0x2e80 && r <= 0x303e,   // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33ff,   // Kana, CJK compatibility
+		r >= 0x3400 && r <= 0x4dbf,   // CJK extension A
+		r >= 0x4e00 && r <= 0x9fff,   // CJK unified ideographs
+		r >= 0xa000 && r <= 0xa4cf,   // Yi
+		r >= 0xac00 && r <= 0xd7a3,   // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,   // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,   // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,   // fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,   // fullwidth signs
+		r >= 0x1f300 && r <= 0x1f64f, // pictographs, emoticons
+		r >= 0x1f900 && r <= 0x1f9ff, // supplemental pictographs
+		r >= 0x20000 && r <= 0x3fffd: // CJK extensions B and later
+		return true
+	}
+	return false
+}
+
 // runLongHelp is a run function that prints long-form help.
 // The topics are additional help topics to include in the output.
 func printLongHelp(env *Env, topics []HelpInfo) error {
-	ht := env.Command.HelpInfo(env.hflag | IncludeCommands)
+	ht := env.Command.helpInfo(env, env.hflag|IncludeCommands)
+	ht.MostUsed = env.mostUsed(env.hflag)
 	ht.Topics = append(ht.Topics, topics...)
 	ht.WriteLong(env)
 	return ErrRequestHelp
@@ -210,15 +416,19 @@ func printLongHelp(env *Env, topics []HelpInfo) error {
 
 // runShortHelp is a run function that prints synopsis help.
 func printShortHelp(env *Env) error {
-	env.Command.HelpInfo(env.hflag).WriteSynopsis(env)
+	if uf := env.Command.UsageFunc; uf != nil {
+		uf(env, env)
+	} else {
+		env.Command.helpInfo(env, env.hflag).WriteSynopsis(env)
+	}
 	return ErrRequestHelp
 }
 
-// toStdout returns a copy of e in which output goes to stdout instead of
-// whatever it is set to (stderr by default).
+// toStdout returns a copy of e in which output goes to the primary output of
+// e (see Env.Stdout) instead of whatever it is set to (stderr by default).
 func (e *Env) toStdout() *Env {
 	cenv := *e // shallow copy
-	cenv.Log = os.Stdout
+	cenv.Log = e.Stdout()
 	return &cenv
 }
 
@@ -227,9 +437,14 @@ func (e *Env) toStdout() *Env {
 func RunHelp(env *Env) error {
 	// Check whether the arguments describe the parent or one of its subcommands.
 	target := walkArgs(env.Parent.HelpFlags(env.hflag), env.Args)
+	gt := env.glossaryInfo()
 	if target == env.Parent {
 		// For the parent, include the help command's own topics.
-		return printLongHelp(target.toStdout(), env.Command.HelpInfo(env.hflag|IncludeCommands).Topics)
+		topics := env.Command.helpInfo(env, env.hflag|IncludeCommands).Topics
+		if gt != nil {
+			topics = append(topics, *gt)
+		}
+		return printLongHelp(target.toStdout(), topics)
 	} else if target != nil {
 		return printLongHelp(target.toStdout(), nil)
 	}
@@ -237,6 +452,9 @@ func RunHelp(env *Env) error {
 	// Otherwise, check whether the arguments name a help subcommand.
 	if ht := walkArgs(env, env.Args); ht != nil {
 		return printLongHelp(ht.toStdout(), nil)
+	} else if gt != nil && len(env.Args) == 1 && env.Args[0] == gt.Name {
+		gt.WriteLong(env.toStdout())
+		return ErrRequestHelp
 	}
 
 	// Otherwise the arguments request an unknown topic.
@@ -252,52 +470,112 @@ func walkArgs(env *Env, args []string) *Env {
 		// with that command is unlisted and we weren't asked to show unlisted
 		// things, report no match.
 		next := cur.Command.FindSubcommand(arg)
-		if next == nil {
+		if !next.Supported() {
 			return nil
 		} else if next.Unlisted && !env.hflag.wantUnlisted() {
 			return nil // skip unlisted commands when not flagged on
 		}
 		// Populate flags so that the help text will include them.
-		next.setFlags(cur, &next.Flags)
 		cur = cur.newChild(next, nil)
+		next.setFlags(cur, &next.Flags)
 	}
 	return cur
 }
 
-const flagPrivatePrefix = "PRIVATE:"
+// visitFlags calls visit for each flag in fs, in lexicographic order by name
+// or, if order != nil, in the order defined by order.
+func visitFlags(fs *flag.FlagSet, order func(a, b *flag.Flag) int, visit func(*flag.Flag)) {
+	if order == nil {
+		fs.VisitAll(visit)
+		return
+	}
+	var all []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { all = append(all, f) })
+	slices.SortStableFunc(all, order)
+	for _, f := range all {
+		visit(f)
+	}
+}
 
 // writeFlagHelp writes descriptive help about the flags defined in fs to w.
 //
 // This is essentially a copy of flag.FlagSet.PrintDefault, with changes:
 //
 // - Long flag names (> 1 character) are prefixed by "--" instead of "-".
-// - Flags whose usage begins with "PRIVATE:" are omitted.
-func writeFlagHelp(w *bytes.Buffer, fs *flag.FlagSet, wantPrivate bool) {
+// - Hidden flags are omitted unless wantPrivate is true (see HideFlags).
+// - Aliases of a flag are listed together with the flag, not separately.
+// - If order != nil, flags are listed in the order it defines.
+// - The default value text may be replaced or suppressed (see DefaultText).
+// - If envName != nil and reports a variable name for a flag, it is listed.
+// - Flags are listed under the heading "Flags", except that flags assigned
+// to a group are listed under its heading (see FlagGroup), and flags
+// inherited from an ancestor under "Global flags" (see MarkPersistent).
+func writeFlagHelp(w *bytes.Buffer, fs *flag.FlagSet, wantPrivate bool, order func(a, b *flag.Flag) int, envName func(string) string) {
 	var errs []error
-	fs.VisitAll(func(f *flag.Flag) {
-		if u, ok := strings.CutPrefix(f.Usage, flagPrivatePrefix); ok {
-			if !wantPrivate {
-				return // don't display this flag
+	groups := []string{"Flags"}
+	grouped := map[string]*bytes.Buffer{"Flags": new(bytes.Buffer)}
+	var global bytes.Buffer
+	visitFlags(fs, order, func(f *flag.Flag) {
+		info := getFlagInfo(f)
+		if info.aliasOf != "" {
+			return // this flag is listed with its primary name
+		} else if isHidden(f) && !wantPrivate {
+			return // don't display this flag
+		}
+		w := grouped["Flags"]
+		if info.inherited {
+			w = &global
+		} else if info.group != "" {
+			w = grouped[info.group]
+			if w == nil {
+				w = new(bytes.Buffer)
+				grouped[info.group] = w
+				groups = append(groups, info.group)
 			}
-			f.Usage = strings.TrimPrefix(u, " ")
 		}
-		tag := "  -"
-		if len(f.Name) > 1 {
-			tag = " --"
+		names := slices.DeleteFunc(flagNames(f), func(name string) bool {
+			return name != f.Name && getFlagInfo(fs.Lookup(name)).deprecated
+		})
+
+		// Render a copy, so that the original flag is not modified.
+		cp := *f
+		cp.Value = unwrapValue(f.Value)
+		f = &cp
+		f.Usage = flagUsage(f)
+
+		for i, name := range names {
+			switch {
+			case i != 0:
+				w.WriteString(", ")
+			case len(name) == 1:
+				w.WriteString("  ")
+			default:
+				w.WriteString(" ")
+			}
+			if len(name) > 1 {
+				w.WriteString("-")
+			}
+			fmt.Fprint(w, "-", name)
 		}
-		fmt.Fprint(w, tag, f.Name)
 		name, usage := flag.UnquoteUsage(f)
+		if t, ok := f.Value.(interface{ typeName() string }); ok && name == "value" {
+			name = t.typeName()
+		}
 		if name != "" {
 			fmt.Fprint(w, " ", name)
 		}
-		if len(f.Name) == 1 && name == "" {
+		if len(names) == 1 && len(f.Name) == 1 && name == "" {
 			w.WriteString("\t")
 		} else {
 			w.WriteString("\n    \t")
 		}
 		w.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
 
-		if ok, err := isZeroValue(f, f.DefValue); err != nil {
+		if info.hasDefText {
+			if info.defText != "" {
+				fmt.Fprintf(w, " (default %s)", info.defText)
+			}
+		} else if ok, err := isZeroValue(f, f.DefValue); err != nil {
 			errs = append(errs, err)
 		} else if !ok {
 			if isStringish(f) {
@@ -306,8 +584,37 @@ func writeFlagHelp(w *bytes.Buffer, fs *flag.FlagSet, wantPrivate bool) {
 				fmt.Fprintf(w, " (default %v)", f.DefValue)
 			}
 		}
+		if envName != nil && !info.inherited {
+			if v := envName(f.Name); v != "" {
+				fmt.Fprintf(w, " (env %s)", v)
+			}
+		}
+		if info.required {
+			w.WriteString(" (required)")
+		}
+		if len(info.requires) != 0 {
+			fmt.Fprintf(w, " (requires --%s)", strings.Join(info.requires, ", --"))
+		}
+		if info.deprecated {
+			if info.depHint != "" {
+				fmt.Fprintf(w, " (deprecated: %s)", info.depHint)
+			} else {
+				w.WriteString(" (deprecated)")
+			}
+		}
 		w.WriteString("\n")
 	})
+	groups = append(groups, "Global flags")
+	grouped["Global flags"] = &global
+	for _, g := range groups {
+		if b := grouped[g]; b.Len() != 0 {
+			if w.Len() != 0 {
+				w.WriteString("\n")
+			}
+			fmt.Fprintf(w, "%s:\n", g)
+			w.Write(b.Bytes())
+		}
+	}
 	if len(errs) != 0 {
 		for _, err := range errs {
 			fmt.Fprint(w, "\n", err)
@@ -0,0 +1,547 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newHelpTestCommand() *command.C {
+	return &command.C{
+		Name: "tool",
+		Help: "A tool for testing help output.",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.String("zeta", "z", "The last flag")
+			fs.Bool("alpha", false, "The first flag")
+			fs.Int("secret", 0, "PRIVATE: A private flag")
+		},
+		Commands: []*command.C{
+			{Name: "run", Help: "Run something.", Run: func(*command.Env) error { return nil }},
+			{Name: "build", Help: "Build something.", Run: func(*command.Env) error { return nil }},
+			{Name: "topic", Help: "A help topic."},
+		},
+	}
+}
+
+func renderHelp(c *command.C, flags command.HelpFlags) string {
+	var buf strings.Builder
+	c.HelpInfo(flags | command.IncludeCommands).WriteLong(&buf)
+	return buf.String()
+}
+
+func TestHelpDeterministic(t *testing.T) {
+	// Render help from two separately-constructed trees, and repeatedly from the
+	// same tree, interleaving settings that affect what is shown.
+	c1, c2 := newHelpTestCommand(), newHelpTestCommand()
+	for _, c := range []*command.C{c1, c2} {
+		c.SetFlags(nil, &c.Flags)
+	}
+
+	base := renderHelp(c1, 0)
+	if strings.Contains(base, "secret") {
+		t.Errorf("Private flag was listed:\n%s", base)
+	}
+	priv := renderHelp(c1, command.IncludePrivateFlags)
+	if !strings.Contains(priv, "--secret") || strings.Contains(priv, "PRIVATE:") {
+		t.Errorf("Private flag was not listed correctly:\n%s", priv)
+	}
+	for i := range 3 {
+		if diff := cmp.Diff(renderHelp(c1, 0), base); diff != "" {
+			t.Errorf("Render %d (-got, +want):\n%s", i+1, diff)
+		}
+		if diff := cmp.Diff(renderHelp(c2, command.IncludePrivateFlags), priv); diff != "" {
+			t.Errorf("Render %d private (-got, +want):\n%s", i+1, diff)
+		}
+	}
+
+	// Flags are in lexicographic order, commands in declaration order.
+	for _, order := range [][2]string{{"--alpha", "--zeta"}, {"tool run", "tool build"}} {
+		if i, j := strings.Index(base, order[0]), strings.Index(base, order[1]); i < 0 || j < 0 || i > j {
+			t.Errorf("Help output: want %q before %q:\n%s", order[0], order[1], base)
+		}
+	}
+}
+
+func TestFlagOrder(t *testing.T) {
+	c := newHelpTestCommand()
+	c.SetFlags(nil, &c.Flags)
+
+	tests := []struct {
+		order func(a, b *flag.Flag) int
+		want  []string
+	}{
+		{nil, []string{"--alpha", "--secret", "--zeta"}},
+		{command.FlagsInOrder("zeta", "secret", "alpha"), []string{"--zeta", "--secret", "--alpha"}},
+		{command.FlagsInOrder("zeta"), []string{"--zeta", "--alpha", "--secret"}},
+		{func(a, b *flag.Flag) int { return -strings.Compare(a.Name, b.Name) },
+			[]string{"--zeta", "--secret", "--alpha"}},
+	}
+	for _, tc := range tests {
+		c.FlagOrder = tc.order
+		help := renderHelp(c, command.IncludePrivateFlags)
+		last := -1
+		for _, name := range tc.want {
+			i := strings.Index(help, name)
+			if i < last {
+				t.Errorf("Help: got %q out of order, want %q:\n%s", name, tc.want, help)
+			}
+			last = i
+		}
+	}
+}
+
+func TestHelpAlignment(t *testing.T) {
+	run := func(*command.Env) error { return nil }
+	c := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{Name: "a", Help: "first", Run: run},
+			{Name: "世界", Help: "wide", Run: run},
+			{Name: "cafe\u0301", Help: "combining", Run: run},
+		},
+	}
+	const want = "Subcommands:\n" +
+		"  tool a    :   first\n" +
+		"  tool 世界 :   wide\n" +
+		"  tool cafe\u0301 :   combining\n"
+	if got := renderHelp(c, 0); !strings.Contains(got, want) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", want, got)
+	}
+
+	for _, tc := range []struct {
+		input string
+		want  int
+	}{
+		{"", 0}, {"abc", 3}, {"世界", 4}, {"cafe\u0301", 4}, {"ｆｕｌｌ", 8},
+	} {
+		if got := command.DisplayWidth(tc.input); got != tc.want {
+			t.Errorf("DisplayWidth(%q): got %d, want %d", tc.input, got, tc.want)
+		}
+	}
+
+	// A program may measure names in its own way, without affecting others.
+	var buf strings.Builder
+	env := c.NewEnv(nil).SetDisplayWidth(func(s string) int {
+		return len(s) // bytes
+	})
+	env.Log = &buf
+	command.Run(env, []string{"--help"})
+	const bytesWant = "Subcommands:\n" +
+		"  tool a      :   first\n" +
+		"  tool 世界 :   wide\n" +
+		"  tool cafe\u0301 :   combining\n"
+	if got := buf.String(); !strings.Contains(got, bytesWant) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", bytesWant, got)
+	}
+	if got := renderHelp(c, 0); !strings.Contains(got, want) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestUsageFunc(t *testing.T) {
+	targets := []string{"alpha", "beta"}
+	usage := func(env *command.Env, w io.Writer) {
+		fmt.Fprintf(w, "Usage: %s <target>\nTargets: %s\n", env.Command.Name, strings.Join(targets, ", "))
+	}
+	root := &command.C{
+		Name:      "tool",
+		UsageFunc: usage,
+		Commands: []*command.C{{
+			Name:      "fail",
+			UsageFunc: usage,
+			Run:       command.FailWithUsage,
+		}, {
+			Name: "plain",
+			Run:  command.FailWithUsage,
+		}},
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var buf strings.Builder
+		env := root.NewEnv(nil)
+		env.Log = &buf
+		if err := command.Run(env, args); !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Run %q: got %v, want %v", args, err, command.ErrRequestHelp)
+		}
+		return buf.String()
+	}
+	if got, want := run(), "Usage: tool <target>\nTargets: alpha, beta\n"; got != want {
+		t.Errorf("Run: got %q, want %q", got, want)
+	}
+	if got, want := run("fail"), "Usage: fail <target>\nTargets: alpha, beta\n"; got != want {
+		t.Errorf("Run fail: got %q, want %q", got, want)
+	}
+
+	// Other commands are not affected.
+	if got := run("plain"); strings.Contains(got, "Targets:") {
+		t.Errorf("Run plain: usage includes custom text:\n%s", got)
+	}
+}
+
+func TestHelpFunc(t *testing.T) {
+	formats := []string{"json", "yaml"}
+	var paths []string
+	sub := &command.C{
+		Name: "convert",
+		Help: "Static help is not used.",
+		HelpFunc: func(env *command.Env) string {
+			var names []string
+			for cur := env; cur != nil; cur = cur.Parent {
+				names = append(names, cur.Command.Name)
+			}
+			paths = append(paths, strings.Join(names, "<"))
+			return "Convert between formats.\n\nSupported formats: " + strings.Join(formats, ", ")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	root := &command.C{Name: "tool", Commands: []*command.C{sub}}
+
+	h := sub.HelpInfo(0)
+	if got, want := h.Synopsis, "Convert between formats."; got != want {
+		t.Errorf("Synopsis: got %q, want %q", got, want)
+	}
+	if !strings.HasSuffix(h.Help, "Supported formats: json, yaml") {
+		t.Errorf("Help: got %q, want formats", h.Help)
+	}
+
+	formats = append(formats, "toml")
+	var buf strings.Builder
+	env := root.NewEnv(nil)
+	env.Log = &buf
+	if err := command.Run(env, []string{"convert", "--help"}); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if !strings.Contains(buf.String(), "Supported formats: json, yaml, toml") {
+		t.Errorf("Long help does not include computed text:\n%s", buf.String())
+	}
+	if diff := cmp.Diff(paths, []string{"convert", "convert<tool"}); diff != "" {
+		t.Errorf("HelpFunc environments (-got, +want):\n%s", diff)
+	}
+}
+
+func TestHelpPlaceholders(t *testing.T) {
+	prog := new(command.Env).ProgramName()
+	sub := &command.C{
+		Name:  "add",
+		Usage: "<name> <url>",
+		Help: `Add a remote named {{.Name}}.
+
+Example: $PROG remote add origin https://example.com
+Run "{{.Path}} --help" for details.`,
+		Run: func(*command.Env) error { return nil },
+	}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:     "remote",
+			Help:     "Manage remotes for {{.Prog}}.",
+			Commands: []*command.C{sub},
+		}},
+	}
+
+	var buf strings.Builder
+	env := root.NewEnv(nil)
+	env.Log = &buf
+	if err := command.Run(env, []string{"remote", "add", "--help"}); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	for _, want := range []string{
+		"Add a remote named add.",
+		"Example: " + prog + " remote add origin",
+		`Run "tool remote add --help" for details.`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Help output is missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	if got, want := root.Commands[0].HelpInfo(0).Synopsis, "Manage remotes for "+prog+"."; got != want {
+		t.Errorf("Synopsis: got %q, want %q", got, want)
+	}
+	if got := sub.HelpInfo(0).Help; !strings.Contains(got, `Run "add --help"`) {
+		t.Errorf("Detached help: got %q, want path relative to command", got)
+	}
+}
+
+func TestSetProgramName(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:  "sub",
+			Usage: "<arg>",
+			Help:  "Usage: {{.Path}} <arg>",
+			Run:   func(*command.Env) error { return nil },
+		}, command.HelpCommand(nil)},
+	}
+
+	var buf strings.Builder
+	env := root.NewEnv(nil).SetProgramName("other")
+	env.Log = &buf
+	if err := command.Run(env, []string{"sub", "--help"}); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := buf.String(); !strings.Contains(got, "Usage: other sub <arg>") {
+		t.Errorf("Help does not use the program name:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := command.Run(env, []string{"bogus"}); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := buf.String(); !strings.Contains(got, "See 'other help' for more information.") {
+		t.Errorf("Hint does not use the program name:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := command.Run(env, nil); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := buf.String(); !strings.Contains(got, "  other <command>") {
+		t.Errorf("Root usage does not use the program name:\n%s", got)
+	}
+	if got, want := env.ProgramName(), "other"; got != want {
+		t.Errorf("ProgramName: got %q, want %q", got, want)
+	}
+}
+
+func TestHelpTriggers(t *testing.T) {
+	var gotArgs []string
+	root := &command.C{
+		Name: "tool",
+		Help: "Help for the tool.",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.String("name", "", "Name")
+		},
+		Commands: []*command.C{{
+			Name: "sub",
+			Help: "Help for sub.",
+			Run: func(env *command.Env) error {
+				gotArgs = env.Args
+				return nil
+			},
+		}},
+	}
+	tests := []struct {
+		args     string
+		wantHelp string // if non-empty, expect help containing this text
+		wantArgs []string
+	}{
+		{"-?", "Help for the tool.", nil},
+		{"--name x /?", "Help for the tool.", nil},
+		{"sub -?", "Help for sub.", nil},
+		{"sub /?", "Help for sub.", nil},
+		{"--name x sub -?", "Help for sub.", nil},
+		{"--name /? sub", "", nil},            // the value of a flag
+		{"sub x -?", "", []string{"x", "-?"}}, // not directly after the command
+		{"sub -- /?", "", []string{"/?"}},     // after "--"
+	}
+	for _, tc := range tests {
+		gotArgs = nil
+		var buf strings.Builder
+		env := root.NewEnv(nil).SetHelpTriggers("-?", "/?")
+		env.Log = &buf
+		err := command.Run(env, strings.Fields(tc.args))
+		if tc.wantHelp != "" {
+			if !errors.Is(err, command.ErrRequestHelp) {
+				t.Errorf("Run %q: got %v, want %v", tc.args, err, command.ErrRequestHelp)
+			} else if !strings.Contains(buf.String(), tc.wantHelp) {
+				t.Errorf("Run %q: help does not contain %q:\n%s", tc.args, tc.wantHelp, buf.String())
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		}
+		if diff := cmp.Diff(gotArgs, tc.wantArgs); diff != "" {
+			t.Errorf("Run %q: args (-got, +want):\n%s", tc.args, diff)
+		}
+	}
+}
+
+func TestTrailingHelp(t *testing.T) {
+	var gotArgs []string
+	root := &command.C{
+		Name: "tool",
+		Help: "Help for the tool.",
+		Commands: []*command.C{{
+			Name: "db",
+			Help: "Help for db.",
+			Commands: []*command.C{{
+				Name: "migrate",
+				Help: "Help for migrate.",
+				Run: func(env *command.Env) error {
+					gotArgs = env.Args
+					return nil
+				},
+			}},
+		}},
+	}
+	run := func(env *command.Env, args string) (string, error) {
+		var buf strings.Builder
+		env.Log = &buf
+		err := command.Run(env, strings.Fields(args))
+		return buf.String(), err
+	}
+
+	for args, want := range map[string]string{
+		"help":            "Help for the tool.",
+		"db help":         "Help for db.",
+		"db migrate help": "Help for migrate.",
+	} {
+		out, err := run(root.NewEnv(nil), args)
+		if !errors.Is(err, command.ErrRequestHelp) {
+			t.Errorf("Run %q: got %v, want %v", args, err, command.ErrRequestHelp)
+		} else if !strings.Contains(out, want) {
+			t.Errorf("Run %q: help does not contain %q:\n%s", args, want, out)
+		}
+	}
+
+	// A "help" argument that is not last is an ordinary argument.
+	if _, err := run(root.NewEnv(nil), "db migrate help x"); err != nil {
+		t.Errorf("Run: unexpected error: %v", err)
+	} else if diff := cmp.Diff(gotArgs, []string{"help", "x"}); diff != "" {
+		t.Errorf("Args (-got, +want):\n%s", diff)
+	}
+
+	// With triggers disabled, a trailing "help" is an ordinary argument.
+	if _, err := run(root.NewEnv(nil).SetHelpTriggers(), "db migrate help"); err != nil {
+		t.Errorf("Run: unexpected error: %v", err)
+	} else if diff := cmp.Diff(gotArgs, []string{"help"}); diff != "" {
+		t.Errorf("Args (-got, +want):\n%s", diff)
+	}
+}
+
+func TestNestedTopics(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{Name: "run", Help: "Run something.", Run: func(*command.Env) error { return nil }},
+			command.HelpCommand([]command.HelpTopic{{
+				Name: "guides",
+				Help: "Long-form guides.",
+				Topics: []command.HelpTopic{{
+					Name: "networking",
+					Help: "Networking guides.",
+					Topics: []command.HelpTopic{
+						{Name: "proxies", Help: "How to use a proxy.\n\nSet HTTPS_PROXY."},
+						{Name: "tls", Help: "How to configure TLS."},
+					},
+				}},
+			}, {
+				Name: "faq",
+				Help: "Frequently asked questions.",
+			}}),
+		},
+	}
+
+	const want = "Help topics:\n" +
+		"  guides       :   Long-form guides.\n" +
+		"    networking :   Networking guides.\n" +
+		"      proxies  :   How to use a proxy.\n" +
+		"      tls      :   How to configure TLS.\n" +
+		"  faq          :   Frequently asked questions.\n"
+	if got := renderHelp(root.FindSubcommand("help"), 0); !strings.Contains(got, want) {
+		t.Errorf("Help output does not contain:\n%s\ngot:\n%s", want, got)
+	}
+
+	// A nested topic is addressed by its path from the help command.
+	var out strings.Builder
+	err := command.Run(root.NewEnv(nil).SetStdout(&out), []string{"help", "guides", "networking", "proxies"})
+	if !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("Run: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := out.String(); !strings.Contains(got, "Set HTTPS_PROXY.") {
+		t.Errorf("Help for nested topic: got %q", got)
+	}
+}
+
+// captureStdout returns the output written to os.Stdout by f.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(old *os.File) { os.Stdout = old }(os.Stdout)
+	os.Stdout = out
+	f()
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGlossary(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Glossary: []command.Term{
+			{Name: "workspace", Definition: "A directory containing a project.\nIt may be nested."},
+			{Name: "profile", Definition: "A named set of credentials."},
+			{Name: "remote", Definition: "A server that stores copies of a workspace."},
+		},
+		Commands: []*command.C{{
+			Name: "init",
+			Help: "Create a new Workspace using the default profile.",
+			Run:  func(*command.Env) error { return nil },
+		}, {
+			Name: "status",
+			Help: "Print the status of the project.",
+			Run:  func(*command.Env) error { return nil },
+		}, command.HelpCommand(nil)},
+	}
+
+	help := func(args ...string) string {
+		var buf strings.Builder
+		env := root.NewEnv(nil)
+		env.Log = &buf
+		if err := command.Run(env, append(args, "--help")); !errors.Is(err, command.ErrRequestHelp) {
+			t.Fatalf("Run %q: got %v, want %v", args, err, command.ErrRequestHelp)
+		}
+		return buf.String()
+	}
+
+	const notes = "Terms:\n" +
+		"  workspace  A directory containing a project.\n" +
+		"  profile    A named set of credentials.\n"
+	if got := help("init"); !strings.Contains(got, notes) {
+		t.Errorf("Help for init does not contain:\n%s\ngot:\n%s", notes, got)
+	}
+	if got := help("status"); strings.Contains(got, "Terms:") {
+		t.Errorf("Help for status has unexpected terms:\n%s", got)
+	}
+
+	// The generated glossary topic is listed, and defines all the terms.
+	stdout := func(args ...string) string {
+		var buf strings.Builder
+		command.Run(root.NewEnv(nil).SetStdout(&buf), args)
+		return buf.String()
+	}
+	got := stdout("help")
+	if !strings.Contains(got, "glossary :   Definitions of terms used in help.") {
+		t.Errorf("Help topics do not include the glossary:\n%s", got)
+	}
+	got = stdout("help", "glossary")
+	const want = `  profile
+    A named set of credentials.
+
+  remote
+    A server that stores copies of a workspace.
+
+  workspace
+    A directory containing a project.
+    It may be nested.
+`
+	if !strings.Contains(got, want) {
+		t.Errorf("Glossary does not contain:\n%s\ngot:\n%s", want, got)
+	}
+}
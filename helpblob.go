@@ -0,0 +1,90 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// HelpBlob returns a compressed encoding of the Help text of every command in
+// the tree rooted at root, for use with [UseHelpBlob]. Commands with no Help
+// text, or that compute their help with HelpFunc, are not included.
+//
+// Together these allow a program with a very large command tree to keep its
+// help text out of the tree definition. For example, a code generator that
+// produces the tree can call HelpBlob on a fully-populated tree, write the
+// result to a file embedded in the program (see [embed]), and emit the tree
+// without its Help strings. At startup, the program calls UseHelpBlob to
+// attach the embedded text:
+//
+//	//go:embed help.gz
+//	var helpBlob []byte
+//
+//	func main() {
+//	   root := generatedTree() // Help fields are empty
+//	   command.UseHelpBlob(root, helpBlob)
+//	   // ...
+//	}
+func HelpBlob(root *C) ([]byte, error) {
+	text := make(map[string]string)
+	walkTree(root, func(path string, c *C) {
+		if c.Help != "" && c.HelpFunc == nil {
+			text[path] = c.Help
+		}
+	})
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(zw).Encode(text); err != nil {
+		return nil, err
+	} else if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UseHelpBlob attaches help text from blob, which must have been produced by
+// [HelpBlob], to the commands in the tree rooted at root. Commands are matched
+// by their path from the root.  Each command that does not already have Help
+// text or a HelpFunc is given a HelpFunc that returns its text from the blob.
+//
+// The blob is not decoded until help is needed, and then only once. If it
+// cannot be decoded, the commands have no help text.
+func UseHelpBlob(root *C, blob []byte) {
+	load := sync.OnceValue(func() map[string]string {
+		var text map[string]string
+		zr, err := gzip.NewReader(bytes.NewReader(blob))
+		if err == nil {
+			err = json.NewDecoder(zr).Decode(&text)
+		}
+		if err != nil {
+			return nil
+		}
+		return text
+	})
+	walkTree(root, func(path string, c *C) {
+		if c.Help == "" && c.HelpFunc == nil {
+			c.HelpFunc = func(*Env) string { return load()[path] }
+		}
+	})
+}
+
+// walkTree calls visit for each command in the tree rooted at root, in
+// depth-first order, with its path relative to the root. The path of the root
+// itself is "".
+func walkTree(root *C, visit func(path string, c *C)) {
+	var walk func(path []string, c *C)
+	walk = func(path []string, c *C) {
+		visit(strings.Join(path, " "), c)
+		for _, sub := range c.Commands {
+			walk(append(path, sub.Name), sub)
+		}
+	}
+	walk(nil, root)
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestHelpBlob(t *testing.T) {
+	long := strings.Repeat("This text is quite repetitive. ", 100)
+	newTree := func(withHelp bool) *command.C {
+		help := func(s string) string {
+			if withHelp {
+				return s
+			}
+			return ""
+		}
+		run := func(*command.Env) error { return nil }
+		return &command.C{
+			Name: "tool",
+			Help: help("The root command.\n\n" + long),
+			Commands: []*command.C{
+				{Name: "one", Help: help("Command one."), Run: run},
+				{Name: "two", Help: help("Command two."), Commands: []*command.C{
+					{Name: "three", Help: help("Command three."), Run: run},
+				}},
+				{Name: "four", Run: run},
+				{Name: "five", Help: "Static help.", Run: run},
+			},
+		}
+	}
+
+	blob, err := command.HelpBlob(newTree(true))
+	if err != nil {
+		t.Fatalf("HelpBlob: unexpected error: %v", err)
+	}
+	if len(blob) >= len(long) {
+		t.Errorf("HelpBlob: got %d bytes, want less than %d", len(blob), len(long))
+	}
+
+	stripped := newTree(false)
+	command.UseHelpBlob(stripped, blob)
+
+	if got, want := stripped.HelpInfo(0).Synopsis, "The root command."; got != want {
+		t.Errorf("Root synopsis: got %q, want %q", got, want)
+	}
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"one"}, "Command one."},
+		{[]string{"two", "three"}, "Command three."},
+		{[]string{"four"}, ""},
+		{[]string{"five"}, "Static help."},
+	}
+	for _, tc := range tests {
+		cur := stripped
+		for _, name := range tc.path {
+			cur = cur.FindSubcommand(name)
+		}
+		if got := cur.HelpInfo(0).Help; got != tc.want {
+			t.Errorf("Help for %q: got %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	// A bad blob yields no help, but does not fail.
+	bad := newTree(false)
+	command.UseHelpBlob(bad, []byte("garbage"))
+	if got := bad.HelpInfo(0).Help; got != "" {
+		t.Errorf("Help from bad blob: got %q, want empty", got)
+	}
+}
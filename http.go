@@ -0,0 +1,110 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// HTTPOptions are network settings for commands that make HTTP requests.
+// Use its SetFlags method to register standard flags for the settings, and its
+// Init method to install a client configured from them on an [Env]:
+//
+//	var opts command.HTTPOptions
+//	cmd := &command.C{
+//	   Name:     "fetch",
+//	   SetFlags: opts.SetFlags,
+//	   Init:     opts.Init,
+//	   Run: func(env *command.Env) error {
+//	      rsp, err := env.HTTPClient().Get(...)
+//	      // ...
+//	   },
+//	}
+//
+// When a setting is not given by a flag, the standard environment variables
+// apply: HTTP_PROXY, HTTPS_PROXY, and NO_PROXY for the proxy, and on most
+// Unix systems SSL_CERT_FILE and SSL_CERT_DIR for the root certificates.
+type HTTPOptions struct {
+	// Proxy, if non-empty, is the URL of a proxy to use for all requests.
+	Proxy string
+
+	// CACert, if non-empty, is the path of a file containing PEM-encoded
+	// certificates to trust in addition to the system roots.
+	CACert string
+
+	// If InsecureSkipVerify is true, TLS certificates are not verified.
+	InsecureSkipVerify bool
+}
+
+// SetFlags registers --proxy, --cacert, and --insecure-skip-verify flags on fs
+// that set the fields of o. It is suitable for use as the SetFlags field of
+// a [C], or may be called from another SetFlags function.
+func (o *HTTPOptions) SetFlags(_ *Env, fs *flag.FlagSet) {
+	fs.StringVar(&o.Proxy, "proxy", o.Proxy, "Proxy URL for HTTP requests (default from $HTTPS_PROXY)")
+	fs.StringVar(&o.CACert, "cacert", o.CACert, "Trust the PEM certificates in this file")
+	fs.BoolVar(&o.InsecureSkipVerify, "insecure-skip-verify", o.InsecureSkipVerify,
+		"Do not verify TLS certificates (unsafe)")
+}
+
+// Init sets the HTTP client of env to a client configured by o.  It is
+// suitable for use as the Init field of a [C].
+func (o *HTTPOptions) Init(env *Env) error {
+	cli, err := o.Client()
+	if err != nil {
+		return err
+	}
+	env.SetHTTPClient(cli)
+	return nil
+}
+
+// Client returns a new HTTP client configured by o.
+func (o *HTTPOptions) Client() (*http.Client, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+	if o.CACert != "" || o.InsecureSkipVerify {
+		cfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+		if o.CACert != "" {
+			pem, err := os.ReadFile(o.CACert)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("no valid certificates found in " + o.CACert)
+			}
+			cfg.RootCAs = pool
+		}
+		tr.TLSClientConfig = cfg
+	}
+	return &http.Client{Transport: tr}, nil
+}
+
+// HTTPClient returns the HTTP client associated with e. If no client has been
+// set by SetHTTPClient, it returns [http.DefaultClient].
+func (e *Env) HTTPClient() *http.Client {
+	if e.client != nil {
+		return e.client
+	}
+	return http.DefaultClient
+}
+
+// SetHTTPClient sets the HTTP client for e and returns e. If c == nil, it
+// restores the default client. The setting is inherited by the descendants
+// of e.
+func (e *Env) SetHTTPClient(c *http.Client) *Env { e.client = c; return e }
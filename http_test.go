@@ -0,0 +1,62 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestHTTPOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(certFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args string
+		ok   bool
+	}{
+		{"", false},
+		{"--insecure-skip-verify", true},
+		{"--cacert " + certFile, true},
+		{"--cacert " + filepath.Join(t.TempDir(), "nonesuch"), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.args, func(t *testing.T) {
+			var opts command.HTTPOptions
+			cmd := &command.C{
+				Name:     "test",
+				SetFlags: opts.SetFlags,
+				Init:     opts.Init,
+				Run: func(env *command.Env) error {
+					rsp, err := env.HTTPClient().Get(srv.URL)
+					if err != nil {
+						return err
+					}
+					return rsp.Body.Close()
+				},
+			}
+			err := command.Run(cmd.NewEnv(nil), strings.Fields(tc.args))
+			if tc.ok && err != nil {
+				t.Errorf("Run: unexpected error: %v", err)
+			} else if !tc.ok && err == nil {
+				t.Error("Run: got nil, want error")
+			}
+		})
+	}
+}
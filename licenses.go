@@ -0,0 +1,177 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// LicensesCommand constructs a standardized "licenses" command that prints
+// the license and attribution notices of the components of the program. The
+// caller can safely modify the returned command to customize its behavior.
+//
+// The notices are read from fsys, typically an embedded file system (see
+// [embed]). Each regular file in fsys is a notice for the component named by
+// the path of its directory, and the files at the root of fsys are notices for
+// the program itself. For example:
+//
+//	LICENSE                              -- the program
+//	github.com/google/go-cmp/LICENSE     -- github.com/google/go-cmp
+//	golang.org/x/sys/LICENSE             -- golang.org/x/sys
+//	golang.org/x/sys/PATENTS             -- golang.org/x/sys
+//
+// If fsys == nil, the command instead lists the modules compiled into the
+// program, from its build information (see [debug.ReadBuildInfo]). In that
+// case there are no notices to select, and the command reports a usage error
+// if it is given --list or any arguments.
+//
+// By default the command prints the notices for all the components. Given
+// arguments, it prints only the notices of the named components. With --list
+// it prints only the names of the components.
+//
+// When the output is a terminal, it is shown a page at a time by the program
+// named by the PAGER environment variable, or "less" if that is not set. This
+// can be disabled by the --no-pager flag, or by setting PAGER to "cat".
+func LicensesCommand(fsys fs.FS) *C {
+	var listOnly, noPager bool
+	return &C{
+		Name:  "licenses",
+		Usage: "[--list] [component ...]",
+		Help: `Print license and attribution notices for this program.
+
+With no arguments, print the notices of all the components of the program.
+Otherwise, print the notices of the named components.`,
+		SetFlags: func(_ *Env, fs *flag.FlagSet) {
+			fs.BoolVar(&listOnly, "list", false, "List the names of the components only")
+			fs.BoolVar(&noPager, "no-pager", false, "Do not show the output in a pager")
+		},
+		Run: func(env *Env) error {
+			var buf bytes.Buffer
+			if fsys == nil {
+				if listOnly || len(env.Args) != 0 {
+					return env.Usagef("no notices are available; --list and component names are not supported")
+				}
+				writeModuleList(&buf)
+			} else if err := writeNotices(&buf, fsys, env.ProgramName(), listOnly, env.Args); err != nil {
+				return err
+			}
+			if noPager {
+				_, err := env.Stdout().Write(buf.Bytes())
+				return err
+			}
+			return writePaged(env, buf.Bytes())
+		},
+	}
+}
+
+// writeNotices writes the notices from fsys to w for the named components, or
+// for all components if names is empty. The component for the files at the
+// root of fsys is named prog. If listOnly is true, only the names of the
+// components are written.
+func writeNotices(w io.Writer, fsys fs.FS, prog string, listOnly bool, names []string) error {
+	files := make(map[string][]string) // component → file paths
+	var order []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		name := path.Dir(p)
+		if name == "." {
+			name = prog
+		}
+		if _, ok := files[name]; !ok {
+			order = append(order, name)
+		}
+		files[name] = append(files[name], p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(names) != 0 {
+		for _, name := range names {
+			if _, ok := files[name]; !ok {
+				return fmt.Errorf("no notices for %q", name)
+			}
+		}
+		order = slices.DeleteFunc(order, func(s string) bool { return !slices.Contains(names, s) })
+	}
+
+	for i, name := range order {
+		if listOnly {
+			fmt.Fprintln(w, name)
+			continue
+		} else if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "==== %s ====\n", name)
+		for _, p := range files[name] {
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\n%s\n", bytes.TrimSpace(data))
+		}
+	}
+	return nil
+}
+
+// writeModuleList writes a list of the modules compiled into the program to
+// w, from its build information.
+func writeModuleList(w io.Writer) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		fmt.Fprintln(w, "No module information is available.")
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", bi.Main.Path, bi.Main.Version)
+	if len(bi.Deps) != 0 {
+		fmt.Fprint(w, "\nThis program includes the following modules:\n\n")
+		for _, m := range bi.Deps {
+			if m.Replace != nil {
+				m = m.Replace
+			}
+			fmt.Fprintf(w, "  %s %s\n", m.Path, m.Version)
+		}
+	}
+}
+
+// writePaged writes data to the primary output of env (see Env.Stdout). If
+// the output is a terminal, data are shown by the pager named by $PAGER
+// (default "less"). If the pager cannot be started, data are written to the
+// output directly.
+func writePaged(env *Env, data []byte) error {
+	f, ok := env.stdout.(*os.File)
+	if env.stdout == nil {
+		f, ok = os.Stdout, true
+	}
+	if !ok || !isTerminal(f) {
+		_, err := env.Stdout().Write(data)
+		return err
+	}
+	pager := strings.Fields(env.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout, cmd.Stderr = f, os.Stderr
+	if err := env.Flush(); err != nil {
+		return err
+	} else if err := cmd.Start(); err != nil {
+		_, err := env.Stdout().Write(data)
+		return err
+	}
+	cmd.Wait() // the pager reports its own problems
+	return nil
+}
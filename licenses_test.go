@@ -0,0 +1,80 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/creachadair/command"
+)
+
+func TestLicensesCommand(t *testing.T) {
+	fsys := fstest.MapFS{
+		"LICENSE":                          {Data: []byte("Tool license.\n")},
+		"github.com/google/go-cmp/LICENSE": {Data: []byte("BSD license for go-cmp.\n")},
+		"golang.org/x/sys/LICENSE":         {Data: []byte("BSD license for x/sys.\n")},
+		"golang.org/x/sys/PATENTS":         {Data: []byte("Patent grant for x/sys.\n")},
+	}
+	root := &command.C{
+		Name:     "tool",
+		Commands: []*command.C{command.LicensesCommand(fsys)},
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		return captureStdout(t, func() {
+			env := root.NewEnv(nil).SetProgramName("tool")
+			if err := command.Run(env, append([]string{"licenses"}, args...)); err != nil {
+				t.Fatalf("Run %q: unexpected error: %v", args, err)
+			}
+		})
+	}
+
+	if got, want := run("--list"), "tool\ngithub.com/google/go-cmp\ngolang.org/x/sys\n"; got != want {
+		t.Errorf("List: got %q, want %q", got, want)
+	}
+	root.Commands[0].Flags.Set("list", "false")
+
+	const want = `==== golang.org/x/sys ====
+
+BSD license for x/sys.
+
+Patent grant for x/sys.
+`
+	if got := run("golang.org/x/sys"); got != want {
+		t.Errorf("Notices for x/sys: got %q, want %q", got, want)
+	}
+	all := run()
+	for _, want := range []string{"==== tool ====\n\nTool license.\n", "BSD license for go-cmp.", want} {
+		if !strings.Contains(all, want) {
+			t.Errorf("All notices do not contain %q:\n%s", want, all)
+		}
+	}
+
+	env := root.NewEnv(nil)
+	env.Log = new(strings.Builder)
+	if err := command.Run(env, []string{"licenses", "example.com/nonesuch"}); err == nil {
+		t.Error("Run for unknown component: got nil error, want error")
+	}
+
+	// Without notices, the command lists the modules from the build info.
+	mods := &command.C{Name: "tool", Commands: []*command.C{command.LicensesCommand(nil)}}
+	got := captureStdout(t, func() {
+		if err := command.Run(mods.NewEnv(nil), []string{"licenses"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+	})
+	if got == "" {
+		t.Error("Module list is empty")
+	}
+	for _, args := range [][]string{{"--list"}, {"golang.org/x/sys"}} {
+		env := mods.NewEnv(nil)
+		env.Log = new(strings.Builder)
+		err := command.Run(env, append([]string{"licenses"}, args...))
+		if !command.IsUsage(err) {
+			t.Errorf("Run %q without notices: got error %v, want usage error", args, err)
+		}
+		mods.Commands[0].Flags.Set("list", "false")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"fmt"
+	"slices"
+)
+
+// A Matrix defines commands for combinations of verbs and nouns (resources),
+// which can be invoked in either order. For example, an action with verb "get"
+// and noun "user" can be run as either of:
+//
+//	tool get user ...
+//	tool user get ...
+//
+// Both orderings share the help, flags, and functions of the action's command.
+type Matrix struct {
+	// Verbs give the names and help text for the verbs. Verbs used by actions
+	// but not listed here are added with no help, in order of first use.
+	Verbs []HelpTopic
+
+	// Nouns give the names and help text for the nouns. Nouns used by actions
+	// but not listed here are added with no help, in order of first use.
+	Nouns []HelpTopic
+
+	// Actions are the defined combinations of verbs and nouns.
+	Actions []Action
+}
+
+// An Action defines the command for a combination of a verb and a noun.
+type Action struct {
+	Verb, Noun string
+
+	// The command to run for this combination. Its Name is ignored.
+	Command *C
+}
+
+// Commands returns a command for each verb and each noun of m, in that order.
+// The command for a verb has a subcommand for each noun it is defined for, and
+// vice versa. Each of these subcommands is a clone of the command for its
+// action (see C.Clone), so the two orderings can be used independently.
+//
+// Commands panics if a verb has the same name as a noun, or if an action is
+// defined more than once.
+func (m Matrix) Commands() []*C {
+	verbs, nouns := slices.Clone(m.Verbs), slices.Clone(m.Nouns)
+	addTopic := func(topics []HelpTopic, name string) []HelpTopic {
+		if !slices.ContainsFunc(topics, func(t HelpTopic) bool { return t.Name == name }) {
+			topics = append(topics, HelpTopic{Name: name})
+		}
+		return topics
+	}
+	seen := make(map[[2]string]bool)
+	for _, a := range m.Actions {
+		key := [2]string{a.Verb, a.Noun}
+		if seen[key] {
+			panic(fmt.Sprintf("duplicate action %q %q", a.Verb, a.Noun))
+		}
+		seen[key] = true
+		verbs, nouns = addTopic(verbs, a.Verb), addTopic(nouns, a.Noun)
+	}
+	for _, v := range verbs {
+		if slices.ContainsFunc(nouns, func(n HelpTopic) bool { return n.Name == v.Name }) {
+			panic(fmt.Sprintf("%q is both a verb and a noun", v.Name))
+		}
+	}
+
+	var out []*C
+	group := func(topics []HelpTopic, key func(Action) (string, string)) {
+		for _, t := range topics {
+			parent := t.command()
+			for _, a := range m.Actions {
+				if name, sub := key(a); name == t.Name {
+					cmd := a.Command.Clone()
+					cmd.Name = sub
+					parent.Commands = append(parent.Commands, cmd)
+				}
+			}
+			out = append(out, parent)
+		}
+	}
+	group(verbs, func(a Action) (string, string) { return a.Verb, a.Noun })
+	group(nouns, func(a Action) (string, string) { return a.Noun, a.Verb })
+	return out
+}
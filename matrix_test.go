@@ -0,0 +1,83 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestMatrix(t *testing.T) {
+	var ran []string
+	var all bool
+	action := func(verb, noun string) *command.C {
+		return &command.C{
+			Help: verb + " " + noun + " resources.",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.BoolVar(&all, "all", false, "Include all")
+			},
+			Run: func(env *command.Env) error {
+				ran = append(ran, verb+"/"+noun+"/"+strings.Join(env.Args, ","))
+				return nil
+			},
+		}
+	}
+	m := command.Matrix{
+		Verbs: []command.HelpTopic{{Name: "get", Help: "Get resources."}},
+		Actions: []command.Action{
+			{Verb: "get", Noun: "user", Command: action("get", "user")},
+			{Verb: "delete", Noun: "user", Command: action("delete", "user")},
+			{Verb: "get", Noun: "group", Command: action("get", "group")},
+		},
+	}
+	root := &command.C{Name: "tool", Commands: m.Commands()}
+
+	var names []string
+	for _, c := range root.Commands {
+		var subs []string
+		for _, sub := range c.Commands {
+			subs = append(subs, sub.Name)
+		}
+		names = append(names, c.Name+"["+strings.Join(subs, ",")+"]")
+	}
+	if got, want := strings.Join(names, " "), "get[user,group] delete[user] user[get,delete] group[get]"; got != want {
+		t.Errorf("Commands: got %q, want %q", got, want)
+	}
+	if got := root.Commands[0].HelpInfo(0).Synopsis; got != "Get resources." {
+		t.Errorf("Verb help: got %q", got)
+	}
+
+	for _, args := range []string{"get user a", "user get --all b", "group get", "delete user x y"} {
+		all = false
+		if err := command.Run(root.NewEnv(nil), strings.Fields(args)); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", args, err)
+		}
+		if want := strings.Contains(args, "--all"); all != want {
+			t.Errorf("Run %q: all is %v, want %v", args, all, want)
+		}
+	}
+	if got, want := strings.Join(ran, " "), "get/user/a get/user/b get/group/ delete/user/x,y"; got != want {
+		t.Errorf("Ran: got %q, want %q", got, want)
+	}
+
+	mustPanic := func(name string, m command.Matrix) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: Commands did not panic", name)
+			}
+		}()
+		m.Commands()
+	}
+	mustPanic("duplicate", command.Matrix{Actions: []command.Action{
+		{Verb: "get", Noun: "user", Command: action("get", "user")},
+		{Verb: "get", Noun: "user", Command: action("get", "user")},
+	}})
+	mustPanic("collision", command.Matrix{Actions: []command.Action{
+		{Verb: "list", Noun: "get", Command: action("list", "get")},
+		{Verb: "get", Noun: "user", Command: action("get", "user")},
+	}})
+}
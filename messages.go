@@ -0,0 +1,78 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"flag"
+	"strings"
+)
+
+// A Message is a user-visible string defined by a command tree, reported by
+// [Messages]. Messages can be encoded as JSON for use by other tools.
+type Message struct {
+	// The path of the command that defines the string, from the root, for
+	// example "tool remote add".
+	Path string `json:"path"`
+
+	// What the string is used for: "help" for the help text of a command or
+	// help topic, "usage" for its usage summary, "flag" for the usage text of
+	// a flag, "default" for the display text of the default value of a flag
+	// (see [DefaultText]), or "term" for the definition of a glossary term
+	// (see C.Glossary).
+	Kind string `json:"kind"`
+
+	// For a flag or a glossary term, its name.
+	Name string `json:"name,omitempty"`
+
+	// The text of the string, as defined. Help placeholders are not expanded,
+	// and the legacy "PRIVATE:" prefix of a hidden flag is removed.
+	Text string `json:"text"`
+}
+
+// Messages returns every user-visible string defined by the command tree
+// rooted at root, in depth-first order, for use by tools such as spelling and
+// style checkers or localization pipelines. Unlisted commands, unsupported
+// commands, and private flags are included. Empty strings are omitted.
+//
+// The flags of each command are defined, if they have not been already, by
+// calling its SetFlags function. Help computed by a HelpFunc is included as
+// it is computed for a new root environment.
+func Messages(root *C) []Message {
+	var out []Message
+	add := func(path, kind, name, text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			out = append(out, Message{Path: path, Kind: kind, Name: name, Text: text})
+		}
+	}
+	var walk func(env *Env)
+	walk = func(env *Env) {
+		c := env.Command
+		path := strings.Join(env.path(), " ")
+		add(path, "help", "", c.helpText(env))
+		add(path, "usage", "", c.Usage)
+
+		c.setFlags(env, &c.Flags)
+		if !c.CustomFlags {
+			visitFlags(&c.Flags, c.FlagOrder, func(f *flag.Flag) {
+				info := getFlagInfo(f)
+				if info.aliasOf != "" || info.inherited {
+					return
+				}
+				add(path, "flag", f.Name, flagUsage(f))
+				if info.hasDefText {
+					add(path, "default", f.Name, info.defText)
+				}
+			})
+		}
+		if env.Parent == nil {
+			for _, t := range c.Glossary {
+				add(path, "term", t.Name, t.Definition)
+			}
+		}
+		for _, sub := range c.Commands {
+			walk(env.newChild(sub, nil))
+		}
+	}
+	walk(root.NewEnv(nil))
+	return out
+}
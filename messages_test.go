@@ -0,0 +1,66 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMessages(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Help: "A tool for {{.Prog}}.",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			command.VarP(fs, new(flagString), "output", "o", "Write output to this file")
+			fs.Bool("trace", false, "PRIVATE:Enable tracing")
+			fs.String("user", "root", "User name")
+			command.DefaultText(fs, "user", "the current user")
+		},
+		Glossary: []command.Term{{Name: "remote", Definition: "A server."}},
+		Commands: []*command.C{{
+			Name:     "remote",
+			Help:     "Manage remotes.",
+			Unlisted: true,
+			Commands: []*command.C{{
+				Name:     "add",
+				Usage:    "<name> <url>",
+				HelpFunc: func(env *command.Env) string { return "Add a remote to " + env.Command.Name + "." },
+				Run:      func(*command.Env) error { return nil },
+			}},
+		}, {
+			Name:        "exec",
+			Help:        "Run a program.",
+			CustomFlags: true,
+			Run:         func(*command.Env) error { return nil },
+		}, {
+			Name: "notes", // no help text
+		}},
+	}
+
+	got := command.Messages(root)
+	want := []command.Message{
+		{Path: "tool", Kind: "help", Text: "A tool for {{.Prog}}."},
+		{Path: "tool", Kind: "flag", Name: "output", Text: "Write output to this file"},
+		{Path: "tool", Kind: "flag", Name: "trace", Text: "Enable tracing"},
+		{Path: "tool", Kind: "flag", Name: "user", Text: "User name"},
+		{Path: "tool", Kind: "default", Name: "user", Text: "the current user"},
+		{Path: "tool", Kind: "term", Name: "remote", Text: "A server."},
+		{Path: "tool remote", Kind: "help", Text: "Manage remotes."},
+		{Path: "tool remote add", Kind: "help", Text: "Add a remote to add."},
+		{Path: "tool remote add", Kind: "usage", Text: "<name> <url>"},
+		{Path: "tool exec", Kind: "help", Text: "Run a program."},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Messages (-want, +got):\n%s", diff)
+	}
+}
+
+// flagString is a trivial [flag.Value] for testing.
+type flagString string
+
+func (f *flagString) String() string     { return string(*f) }
+func (f *flagString) Set(s string) error { *f = flagString(s); return nil }
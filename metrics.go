@@ -0,0 +1,164 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics is the interface to a sink for command execution metrics.  When a
+// Metrics value is set on an [Env] (see SetMetrics), [Run] reports each
+// invocation of a command's Run function to it.
+//
+// Commands are identified by their path, the space-separated names of the
+// commands from the root of the tree, for example "tool sub cmd".
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncInvocation records that the Run function of the command at path is
+	// being called.
+	IncInvocation(path string)
+
+	// ObserveDuration records that the Run function of the command at path
+	// completed after the given duration.
+	ObserveDuration(path string, d time.Duration)
+
+	// IncError records that the Run function of the command at path failed.
+	// The class is one of "usage", "help", "timeout", "canceled", "panic", or
+	// "error" (see [ErrorClass]).
+	IncError(path, class string)
+}
+
+// SetMetrics sets the metrics sink for e and returns e. If m == nil, metrics
+// are not recorded. The setting is inherited by the descendants of e.
+func (e *Env) SetMetrics(m Metrics) *Env { e.metrics = m; return e }
+
+// ErrorClass returns a short label classifying err for metrics: "usage" for a
+// [UsageError], "help" for [ErrRequestHelp], "timeout" for a context deadline,
+// "canceled" for context cancellation, "panic" for a [PanicError], and "error"
+// for any other non-nil error. It returns "" if err == nil.
+func ErrorClass(err error) string {
+	var uerr UsageError
+	var perr PanicError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &uerr):
+		return "usage"
+	case errors.Is(err, ErrRequestHelp):
+		return "help"
+	case errors.As(err, &perr):
+		return "panic"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+// path returns the names of the commands from the root to e.
+func (e *Env) path() []string {
+	var names []string
+	for cur := e; cur != nil; cur = cur.Parent {
+		names = append(names, cur.Command.Name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+// runCommand calls the Run function of e.Command, subject to its concurrency
+// limit, and reports metrics to the metrics sink of e, if one is set.
+func (e *Env) runCommand() (err error) {
+	release, err := e.Command.acquire(e.Context())
+	if err != nil {
+		return fmt.Errorf("command %q: %w", e.Command.Name, err)
+	}
+	defer release()
+	defer e.timePhase("run")()
+	defer func() {
+		if !IsUsage(err) && !IsHelpRequest(err) {
+			e.recordUsage()
+		}
+	}()
+
+	m := e.metrics
+	if m == nil {
+		return e.Command.Run(e)
+	}
+	path := strings.Join(e.path(), " ")
+	m.IncInvocation(path)
+	start := e.Now()
+	defer func() {
+		m.ObserveDuration(path, e.Since(start))
+		if x := recover(); x != nil {
+			m.IncError(path, "panic")
+			panic(x) // propagate to Run
+		} else if err != nil {
+			m.IncError(path, ErrorClass(err))
+		}
+	}()
+	return e.Command.Run(e)
+}
+
+// ExpvarMetrics is an implementation of the [Metrics] interface that records
+// counters in an [expvar.Map]. The map has the following keys:
+//
+//   - "invocations": a map from command path to invocation count.
+//   - "seconds": a map from command path to total elapsed time in seconds.
+//   - "errors": a map from command path to a map of error class to count.
+type ExpvarMetrics struct {
+	m, invocations, seconds, errors *expvar.Map
+
+	mu sync.Mutex // serializes creation of error maps
+}
+
+// NewExpvarMetrics constructs a new [ExpvarMetrics] value and publishes it
+// under the given expvar name. As with [expvar.Publish], it panics if name is
+// already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	em := &ExpvarMetrics{
+		m:           new(expvar.Map),
+		invocations: new(expvar.Map),
+		seconds:     new(expvar.Map),
+		errors:      new(expvar.Map),
+	}
+	em.m.Set("invocations", em.invocations)
+	em.m.Set("seconds", em.seconds)
+	em.m.Set("errors", em.errors)
+	expvar.Publish(name, em.m)
+	return em
+}
+
+// Map returns the map in which m records its metrics.
+func (m *ExpvarMetrics) Map() *expvar.Map { return m.m }
+
+// IncInvocation implements part of the [Metrics] interface.
+func (m *ExpvarMetrics) IncInvocation(path string) { m.invocations.Add(path, 1) }
+
+// ObserveDuration implements part of the [Metrics] interface.
+func (m *ExpvarMetrics) ObserveDuration(path string, d time.Duration) {
+	m.seconds.AddFloat(path, d.Seconds())
+}
+
+// IncError implements part of the [Metrics] interface.
+func (m *ExpvarMetrics) IncError(path, class string) {
+	cm, ok := m.errors.Get(path).(*expvar.Map)
+	if !ok {
+		m.mu.Lock()
+		if cm, ok = m.errors.Get(path).(*expvar.Map); !ok {
+			cm = new(expvar.Map)
+			m.errors.Set(path, cm)
+		}
+		m.mu.Unlock()
+	}
+	cm.Add(class, 1)
+}
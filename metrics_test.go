@@ -0,0 +1,70 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "ok",
+			Run:  func(*command.Env) error { return nil },
+		}, {
+			Name: "fail",
+			Run:  func(*command.Env) error { return errors.New("bad") },
+		}, {
+			Name: "args",
+			Run:  command.Adapt(func(*command.Env, string) error { return nil }),
+		}, {
+			Name: "panic",
+			Run:  func(*command.Env) error { panic("oops") },
+		}},
+	}
+	m := command.NewExpvarMetrics("test_command_metrics")
+	for _, args := range [][]string{
+		{"ok"}, {"ok"}, {"fail"}, {"args"}, {"args", "x"}, {"panic"},
+	} {
+		command.Run(root.NewEnv(nil).SetMetrics(m), args)
+	}
+
+	get := func(keys ...string) string {
+		var v expvar.Var = m.Map()
+		for _, key := range keys {
+			mv, ok := v.(*expvar.Map)
+			if !ok {
+				return ""
+			}
+			if v = mv.Get(key); v == nil {
+				return ""
+			}
+		}
+		return v.String()
+	}
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"invocations", "tool ok"}, "2"},
+		{[]string{"invocations", "tool fail"}, "1"},
+		{[]string{"invocations", "tool args"}, "2"},
+		{[]string{"errors", "tool fail", "error"}, "1"},
+		{[]string{"errors", "tool args", "usage"}, "1"},
+		{[]string{"errors", "tool panic", "panic"}, "1"},
+		{[]string{"errors", "tool ok"}, ""},
+	}
+	for _, tc := range tests {
+		if got := get(tc.keys...); got != tc.want {
+			t.Errorf("Metric %q: got %q, want %q", tc.keys, got, tc.want)
+		}
+	}
+	if get("seconds", "tool ok") == "" {
+		t.Error("No duration was recorded for tool ok")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MovedCommand installs a stub command in the tree rooted at root that
+// redirects users from the old location of a command to its new location, and
+// returns the stub. The caller may modify the stub to customize it.
+//
+// The paths are the names of the commands below root, separated by spaces,
+// for example "remote add". The parent of oldPath and the command at newPath
+// must already exist in the tree, and oldPath must not.
+//
+// The stub is unlisted. When it is run, it reports message as a warning (see
+// Env.Warnf), then runs the command at newPath with the same arguments and
+// flags it was given, as if the user had named newPath instead. If message is
+// empty, a default notice naming the new location is used.
+//
+// MovedCommand panics if the paths do not satisfy these conditions.
+func MovedCommand(root *C, oldPath, newPath, message string) *C {
+	oldNames, newNames := strings.Fields(oldPath), strings.Fields(newPath)
+	if len(oldNames) == 0 || len(newNames) == 0 {
+		panic("old and new command paths must not be empty")
+	}
+	parent := findPath(root, oldNames[:len(oldNames)-1])
+	if parent == nil {
+		panic(fmt.Sprintf("parent of %q not found", oldPath))
+	}
+	name := oldNames[len(oldNames)-1]
+	if parent.FindSubcommand(name) != nil {
+		panic(fmt.Sprintf("command %q already exists", oldPath))
+	} else if findPath(root, newNames) == nil {
+		panic(fmt.Sprintf("command %q not found", newPath))
+	}
+
+	// The stub continues traversal from the nearest common ancestor of the old
+	// and new locations, so that the Init functions and flags of the commands
+	// on the new path are processed as usual.
+	var common int
+	for common < len(oldNames)-1 && oldNames[common] == newNames[common] {
+		common++
+	}
+	stub := &C{
+		Name:        name,
+		Usage:       "[args...]",
+		Help:        fmt.Sprintf("This command has moved to %q.", newPath),
+		Unlisted:    true,
+		CustomFlags: true,
+		Run: func(env *Env) error {
+			anc := env
+			for range len(oldNames) - common {
+				anc = anc.Parent
+			}
+			rest := slices.Concat(newNames[common:], env.Args)
+			if message != "" {
+				env.Warnf("%s", message)
+			} else {
+				path := anc.displayPath()
+				env.Warnf("%q has moved to %q",
+					strings.Join(slices.Concat(path, oldNames[common:]), " "),
+					strings.Join(slices.Concat(path, newNames[common:]), " "))
+			}
+			next := anc.Command.FindSubcommand(rest[0])
+			return run(anc.newChild(next, rest[1:]), rest[1:])
+		},
+	}
+	parent.Commands = append(parent.Commands, stub)
+	return stub
+}
+
+// findPath returns the command reached from root by following the given
+// names of subcommands, or nil if there is no such command.
+func findPath(root *C, names []string) *C {
+	cur := root
+	for _, name := range names {
+		if cur = cur.FindSubcommand(name); cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/mds/mtest"
+)
+
+func TestMovedCommand(t *testing.T) {
+	var gotPath, gotArgs []string
+	var force bool
+	var inits int
+	newRoot := func() *command.C {
+		gotPath, gotArgs, force, inits = nil, nil, false, 0
+		return &command.C{
+			Name: "tool",
+			Commands: []*command.C{{
+				Name: "remote",
+				Init: func(*command.Env) error { inits++; return nil },
+				Commands: []*command.C{{
+					Name: "add",
+					SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+						fs.BoolVar(&force, "force", false, "Force")
+					},
+					Run: func(env *command.Env) error {
+						for cur := env; cur != nil; cur = cur.Parent {
+							gotPath = append([]string{cur.Command.Name}, gotPath...)
+						}
+						gotArgs = env.Args
+						return nil
+					},
+				}},
+			}, {
+				Name:     "old",
+				Commands: []*command.C{{Name: "keep", Run: func(*command.Env) error { return nil }}},
+			}},
+		}
+	}
+	tests := []struct {
+		oldPath, msg string
+		args         []string
+		warning      string
+	}{
+		{"remote-add", "", []string{"remote-add", "--force", "origin"},
+			`"tool remote-add" has moved to "tool remote add"`},
+		{"remote new", "", []string{"remote", "new", "--force", "origin"},
+			`"tool remote new" has moved to "tool remote add"`},
+		{"old add", "Use tool remote add instead.", []string{"old", "add", "--force", "origin"},
+			"Use tool remote add instead."},
+	}
+	for _, tc := range tests {
+		root := newRoot()
+		stub := command.MovedCommand(root, tc.oldPath, "remote add", tc.msg)
+		if !stub.Unlisted {
+			t.Errorf("Stub for %q is listed", tc.oldPath)
+		}
+		env := root.NewEnv(nil).SetProgramName("tool")
+		env.Log = io.Discard
+		if err := command.Run(env, tc.args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", tc.args, err)
+		}
+		if want := []string{"tool", "remote", "add"}; !slices.Equal(gotPath, want) {
+			t.Errorf("Run %q: ran %q, want %q", tc.args, gotPath, want)
+		}
+		if !force || !slices.Equal(gotArgs, []string{"origin"}) {
+			t.Errorf("Run %q: force=%v args=%q, want true, [origin]", tc.args, force, gotArgs)
+		}
+		if inits != 1 {
+			t.Errorf("Run %q: remote Init called %d times, want 1", tc.args, inits)
+		}
+		if got := env.Warnings(); len(got) != 1 || got[0] != tc.warning {
+			t.Errorf("Run %q: warnings are %q, want %q", tc.args, got, tc.warning)
+		}
+	}
+
+	root := newRoot()
+	command.MovedCommand(root, "remote-add", "remote add", "")
+	if help := renderHelp(root, 0); strings.Contains(help, "remote-add") {
+		t.Errorf("Help lists the moved command:\n%s", help)
+	}
+
+	for _, tc := range [][2]string{
+		{"remote add", "remote add"}, // old path exists
+		{"nonesuch x", "remote add"}, // no parent for old path
+		{"remote-add", "remote nonesuch"},
+		{"", "remote add"},
+	} {
+		mtest.MustPanic(t, func() { command.MovedCommand(newRoot(), tc[0], tc[1], "") })
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RunAs runs the command tree of env as a multi-call program, in which a
+// single binary behaves as several tools selected by the name it was invoked
+// by, typically via symbolic links to the binary (see InstallLinks).
+//
+// If the base name of argv0, less any ".exe" suffix, is the name of a
+// subcommand of env.Command, RunAs runs that subcommand with args, as if by
+//
+//	Run(env, append([]string{name}, args...))
+//
+// In that case, help and error output present the subcommand as the program,
+// for example "ls --help" rather than "tool ls --help". Otherwise, RunAs is
+// equivalent to Run(env, args).
+//
+// A program typically calls RunAs from main as:
+//
+//	err := command.RunAs(root.NewEnv(nil), os.Args[0], os.Args[1:])
+func RunAs(env *Env, argv0 string, args []string) error {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	if name == env.Command.Name || env.Command.FindSubcommand(name) == nil {
+		return Run(env, args)
+	}
+	env.multi = true
+	defer func() { env.multi = false }()
+	return Run(env, append([]string{name}, args...))
+}
+
+// InstallLinks creates a symbolic link in dir to the program binary at target
+// for each of the given names, so that the program can be invoked by those
+// names as a multi-call program (see RunAs). Existing links to target are left
+// in place; it is an error if any other file with one of the names exists.
+func InstallLinks(dir, target string, names ...string) error {
+	var errs []error
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if dest, err := os.Readlink(path); err == nil && dest == target {
+			continue
+		}
+		errs = append(errs, os.Symlink(target, path))
+	}
+	return errors.Join(errs...)
+}
+
+// RemoveLinks removes the symbolic links to target in dir for each of the
+// given names, as created by InstallLinks. Names that do not exist, or that
+// are not symbolic links to target, are skipped.
+func RemoveLinks(dir, target string, names ...string) error {
+	var errs []error
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if dest, err := os.Readlink(path); err == nil && dest == target {
+			errs = append(errs, os.Remove(path))
+		}
+	}
+	return errors.Join(errs...)
+}
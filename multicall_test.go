@@ -0,0 +1,97 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestRunAs(t *testing.T) {
+	var ran string
+	runner := func(env *command.Env) error {
+		ran = env.Command.Name + ":" + strings.Join(env.Args, ",")
+		return nil
+	}
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{
+			{Name: "ls", Usage: "[path...]", Help: "List files.", Run: runner},
+			{Name: "cat", Help: "Print files.", Run: runner},
+			command.HelpCommand(nil),
+		},
+	}
+
+	tests := []struct {
+		argv0 string
+		args  []string
+		want  string
+	}{
+		{"/usr/bin/ls", []string{"a", "b"}, "ls:a,b"},
+		{"cat.exe", []string{"x"}, "cat:x"},
+		{"/opt/tool", []string{"ls", "y"}, "ls:y"},
+		{"other", []string{"cat"}, "cat:"},
+	}
+	for _, tc := range tests {
+		ran = ""
+		if err := command.RunAs(root.NewEnv(nil), tc.argv0, tc.args); err != nil {
+			t.Errorf("RunAs %q %q: unexpected error: %v", tc.argv0, tc.args, err)
+		}
+		if ran != tc.want {
+			t.Errorf("RunAs %q %q: ran %q, want %q", tc.argv0, tc.args, ran, tc.want)
+		}
+	}
+
+	var buf strings.Builder
+	env := root.NewEnv(nil)
+	env.Log = &buf
+	if err := command.RunAs(env, "ls", []string{"--help"}); !errors.Is(err, command.ErrRequestHelp) {
+		t.Fatalf("RunAs --help: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	if got := buf.String(); !strings.Contains(got, "  ls [path...]") {
+		t.Errorf("Help output:\n%s", got)
+	}
+}
+
+func TestInstallLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symbolic links are not reliably available on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "tool")
+	if err := os.WriteFile(target, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := command.InstallLinks(dir, target, "ls", "cat"); err != nil {
+		t.Fatalf("InstallLinks: unexpected error: %v", err)
+	}
+	// Installing again is not an error.
+	if err := command.InstallLinks(dir, target, "ls", "cat"); err != nil {
+		t.Errorf("InstallLinks again: unexpected error: %v", err)
+	}
+	if err := command.InstallLinks(dir, target, "other"); err == nil {
+		t.Error("InstallLinks over a file: got nil, want error")
+	}
+	for _, name := range []string{"ls", "cat"} {
+		if dest, err := os.Readlink(filepath.Join(dir, name)); err != nil || dest != target {
+			t.Errorf("Readlink %q: got %q, %v; want %q", name, dest, err, target)
+		}
+	}
+
+	if err := command.RemoveLinks(dir, target, "ls", "cat", "other", "missing"); err != nil {
+		t.Fatalf("RemoveLinks: unexpected error: %v", err)
+	}
+	if got := strings.Join(listDir(t, dir), " "); got != "other tool" {
+		t.Errorf("After RemoveLinks: got %q, want %q", got, "other tool")
+	}
+}
@@ -0,0 +1,439 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+// Package openapi constructs command trees from OpenAPI 3 documents.
+//
+// The [Commands] function reads a JSON-encoded OpenAPI document and returns a
+// command for each operation it defines. Operations with tags are grouped
+// under a parent command named for their first tag:
+//
+//	spec, err := os.ReadFile("api.json")
+//	// ...
+//	cmds, err := openapi.Commands(spec, nil)
+//	// ...
+//	root := &command.C{
+//	   Name:     "petstore",
+//	   Commands: append(cmds, command.HelpCommand(nil)),
+//	}
+//
+// Each operation command has a flag for each of its path, query, and header
+// parameters, and a --body flag if the operation accepts a request body. When
+// run, it sends the request using the HTTP client of its environment (see
+// command.Env.HTTPClient) and writes the response to stdout.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/creachadair/command"
+)
+
+// Options are optional settings for [Commands]. A nil *Options is ready for
+// use and provides default values.
+type Options struct {
+	// BaseURL, if non-empty, is the URL to which request paths are appended.
+	// If empty, the URL of the first server listed in the document is used.
+	BaseURL string
+
+	// Output is where response bodies are written. If nil, responses are
+	// written to [os.Stdout].
+	Output io.Writer
+}
+
+func (o *Options) baseURL(doc *document) string {
+	if o != nil && o.BaseURL != "" {
+		return o.BaseURL
+	} else if len(doc.Servers) != 0 {
+		return doc.Servers[0].URL
+	}
+	return ""
+}
+
+func (o *Options) output() io.Writer {
+	if o == nil || o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+// Commands returns commands for the operations defined by spec, which must be
+// a JSON-encoded OpenAPI 3 document. Commands are ordered by name, and the
+// operations of each tag are grouped as subcommands of a command named for
+// the tag.
+//
+// The name of each operation command is derived from its operationId, converted
+// to lower case with words separated by hyphens ("listPets" becomes
+// "list-pets"). An operation without an operationId is named for its method
+// and path. Parameters defined by reference to the components of the document
+// are resolved; other references are not supported.
+func Commands(spec []byte, opts *Options) ([]*command.C, error) {
+	var doc document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	base := opts.baseURL(&doc)
+	if base == "" {
+		return nil, errors.New("no server URL in document or options")
+	}
+
+	var top []*command.C
+	groups := make(map[string]*command.C)
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		shared, err := doc.resolveParams(item.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %w", path, err)
+		}
+		for _, method := range sortedKeys(item.Operations) {
+			op := item.Operations[method]
+			params, err := doc.resolveParams(op.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			oc := &opCommand{
+				method: strings.ToUpper(method),
+				path:   path,
+				base:   base,
+				out:    opts.output(),
+				params: mergeParams(shared, params),
+				body:   op.RequestBody,
+			}
+			cmd := oc.command(op)
+			if len(op.Tags) == 0 {
+				top = append(top, cmd)
+				continue
+			}
+			tag := op.Tags[0]
+			g, ok := groups[tag]
+			if !ok {
+				g = &command.C{Name: commandName(tag), Help: doc.tagHelp(tag)}
+				groups[tag] = g
+				top = append(top, g)
+			}
+			g.Commands = append(g.Commands, cmd)
+		}
+	}
+	byName := func(a, b *command.C) int { return strings.Compare(a.Name, b.Name) }
+	for _, g := range groups {
+		slices.SortStableFunc(g.Commands, byName)
+	}
+	slices.SortStableFunc(top, byName)
+	return top, nil
+}
+
+// document is the subset of an OpenAPI 3 document used to construct commands.
+type document struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Tags []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"tags"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components struct {
+		Parameters map[string]parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+func (d *document) tagHelp(tag string) string {
+	for _, t := range d.Tags {
+		if t.Name == tag && t.Description != "" {
+			return t.Description
+		}
+	}
+	return "Operations tagged " + tag + "."
+}
+
+// resolveParams returns a copy of params with references resolved.
+func (d *document) resolveParams(params []parameter) ([]parameter, error) {
+	out := make([]parameter, 0, len(params))
+	for _, p := range params {
+		if p.Ref != "" {
+			name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+			rp, found := d.Components.Parameters[name]
+			if !ok || !found {
+				return nil, fmt.Errorf("unresolved parameter reference %q", p.Ref)
+			}
+			p = rp
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// pathItem is the set of operations defined for a single path.
+type pathItem struct {
+	Parameters []parameter
+	Operations map[string]*operation
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func (p *pathItem) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if ps, ok := raw["parameters"]; ok {
+		if err := json.Unmarshal(ps, &p.Parameters); err != nil {
+			return err
+		}
+	}
+	p.Operations = make(map[string]*operation)
+	for _, m := range methods {
+		if data, ok := raw[m]; ok {
+			op := new(operation)
+			if err := json.Unmarshal(data, op); err != nil {
+				return fmt.Errorf("operation %s: %w", m, err)
+			}
+			p.Operations[m] = op
+		}
+	}
+	return nil
+}
+
+// operation is the subset of an OpenAPI operation used to construct a command.
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Parameters  []parameter  `json:"parameters"`
+	RequestBody *requestBody `json:"requestBody"`
+}
+
+// parameter is the subset of an OpenAPI parameter used to construct a flag.
+type parameter struct {
+	Ref         string `json:"$ref"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// requestBody is the subset of an OpenAPI request body used by a command.
+type requestBody struct {
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// mergeParams returns the path-level parameters overridden by the
+// operation-level parameters, which take precedence by name and location.
+func mergeParams(shared, own []parameter) []parameter {
+	out := slices.Clone(own)
+	for _, s := range shared {
+		if !slices.ContainsFunc(own, func(p parameter) bool { return p.Name == s.Name && p.In == s.In }) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// opCommand carries the state of a command for a single operation.
+type opCommand struct {
+	method, path, base string
+	out                io.Writer
+	params             []parameter
+	body               *requestBody
+
+	values   map[string]*string // parameter values, by flag name
+	bodyText string             // the value of the --body flag
+}
+
+func (o *opCommand) command(op *operation) *command.C {
+	name := op.OperationID
+	if name == "" {
+		name = o.method + " " + strings.NewReplacer("{", "", "}", "").Replace(o.path)
+	}
+	help := strings.TrimSpace(op.Summary)
+	if help == "" {
+		help = o.method + " " + o.path
+	}
+	if d := strings.TrimSpace(op.Description); d != "" {
+		help += "\n\n" + d
+	}
+	help += "\n\nRequest: " + o.method + " " + o.path
+
+	var usage []string
+	for _, p := range o.params {
+		if p.Required && p.In != "cookie" {
+			usage = append(usage, "--"+flagName(p)+" <value>")
+		}
+	}
+	if o.body != nil && o.body.Required {
+		usage = append(usage, "--body <json>")
+	}
+	return &command.C{
+		Name:     commandName(name),
+		Usage:    strings.Join(append(usage, "[flags]"), " "),
+		Help:     help,
+		SetFlags: o.setFlags,
+		Run:      command.Adapt(o.run),
+	}
+}
+
+func (o *opCommand) setFlags(_ *command.Env, fs *flag.FlagSet) {
+	o.values = make(map[string]*string)
+	for _, p := range o.params {
+		if p.In == "cookie" {
+			continue // not supported
+		}
+		name := flagName(p)
+		if _, ok := o.values[name]; ok {
+			continue // duplicate name in a different location
+		}
+		usage := strings.TrimSpace(p.Description)
+		if usage == "" {
+			usage = "The " + p.Name + " " + p.In + " parameter"
+		}
+		if p.Required {
+			usage += " (required)"
+		}
+		o.values[name] = fs.String(name, "", usage)
+	}
+	if o.body != nil {
+		usage := strings.TrimSpace(o.body.Description)
+		if usage == "" {
+			usage = "Request body"
+		}
+		fs.StringVar(&o.bodyText, "body", "", usage+
+			" (JSON text, @file to read a file, or @- to read stdin)")
+	}
+}
+
+func (o *opCommand) run(env *command.Env) error {
+	path := o.path
+	query := make(url.Values)
+	header := make(http.Header)
+	for _, p := range o.params {
+		v, ok := o.values[flagName(p)]
+		if !ok {
+			continue
+		} else if *v == "" {
+			if p.Required {
+				return env.Usagef("missing required flag --%s", flagName(p))
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(*v))
+		case "query":
+			query.Add(p.Name, *v)
+		case "header":
+			header.Set(p.Name, *v)
+		}
+	}
+
+	var body io.Reader
+	if o.body != nil {
+		data, err := o.readBody()
+		if err != nil {
+			return err
+		} else if data == nil && o.body.Required {
+			return env.Usagef("missing required flag --body")
+		} else if data != nil {
+			if !json.Valid(data) {
+				return errors.New("request body is not valid JSON")
+			}
+			body = bytes.NewReader(data)
+			header.Set("Content-Type", "application/json")
+		}
+	}
+
+	u := strings.TrimSuffix(o.base, "/") + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(env.Context(), o.method, u, body)
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Accept", "application/json")
+	rsp, err := env.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	data, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode >= 400 {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s %s: %s: %s", o.method, o.path, rsp.Status, msg)
+	}
+
+	// Indent JSON responses for readability; write others as given.
+	var buf bytes.Buffer
+	if json.Indent(&buf, bytes.TrimSpace(data), "", "  ") == nil {
+		buf.WriteByte('\n')
+		data = buf.Bytes()
+	}
+	_, err = o.out.Write(data)
+	return err
+}
+
+// readBody returns the request body specified by the --body flag, or nil if
+// it was not set.
+func (o *opCommand) readBody() ([]byte, error) {
+	switch {
+	case o.bodyText == "":
+		return nil, nil
+	case o.bodyText == "@-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(o.bodyText, "@"):
+		return os.ReadFile(o.bodyText[1:])
+	default:
+		return []byte(o.bodyText), nil
+	}
+}
+
+// flagName returns the flag name for p.
+func flagName(p parameter) string { return commandName(p.Name) }
+
+// commandName converts s to lower case, with words separated by hyphens.
+// Words are delimited by non-alphanumeric characters and lower-to-upper case
+// transitions, so "listPets" and "list_pets" both become "list-pets".
+func commandName(s string) string {
+	var sb strings.Builder
+	var prev rune
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			prev = '-'
+			continue
+		}
+		if sb.Len() != 0 && (prev == '-' || (unicode.IsUpper(r) && unicode.IsLower(prev))) {
+			sb.WriteByte('-')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+		prev = r
+	}
+	return sb.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
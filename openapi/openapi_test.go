@@ -0,0 +1,143 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/openapi"
+)
+
+const testSpec = `{
+  "openapi": "3.0.0",
+  "servers": [{"url": "http://unused.example.com"}],
+  "tags": [{"name": "pets", "description": "Manage pets."}],
+  "components": {
+    "parameters": {
+      "limit": {"name": "limit", "in": "query", "description": "Maximum results"}
+    }
+  },
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "summary": "List all pets.",
+        "tags": ["pets"],
+        "parameters": [{"$ref": "#/components/parameters/limit"}]
+      },
+      "post": {
+        "operationId": "createPet",
+        "summary": "Create a pet.",
+        "tags": ["pets"],
+        "requestBody": {"required": true}
+      }
+    },
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true}],
+      "get": {
+        "operationId": "showPetById",
+        "summary": "Show one pet.",
+        "tags": ["pets"],
+        "parameters": [{"name": "X-Trace", "in": "header"}]
+      }
+    },
+    "/health": {
+      "get": {"summary": "Check health."}
+    }
+  }
+}`
+
+func TestCommands(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := r.Method + " " + r.URL.RequestURI()
+		if tr := r.Header.Get("X-Trace"); tr != "" {
+			req += " trace=" + tr
+		}
+		if len(body) != 0 {
+			req += " body=" + string(body)
+		}
+		requests = append(requests, req)
+		if strings.HasPrefix(r.URL.Path, "/pets/missing") {
+			http.Error(w, "no such pet", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"ok": "yes"})
+	}))
+	defer srv.Close()
+
+	var out strings.Builder
+	cmds, err := openapi.Commands([]byte(testSpec), &openapi.Options{BaseURL: srv.URL, Output: &out})
+	if err != nil {
+		t.Fatalf("Commands: unexpected error: %v", err)
+	}
+	root := &command.C{Name: "petstore", Commands: cmds}
+
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+		for _, sub := range c.Commands {
+			names = append(names, c.Name+" "+sub.Name)
+		}
+	}
+	if got, want := strings.Join(names, ","), "get-health,pets,pets create-pet,pets list-pets,pets show-pet-by-id"; got != want {
+		t.Errorf("Commands: got %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		args    string
+		want    string // the request received, if any
+		wantErr string
+	}{
+		{"pets list-pets", "GET /pets", ""},
+		{"pets list-pets --limit 5", "GET /pets?limit=5", ""},
+		{"pets show-pet-by-id --pet-id a/b --x-trace 123", "GET /pets/a%2Fb trace=123", ""},
+		{"pets show-pet-by-id", "", "missing required flag --pet-id"},
+		{"pets show-pet-by-id --pet-id missing", "GET /pets/missing", "404 Not Found: no such pet"},
+		{`pets create-pet --body {"name":"rex"}`, `POST /pets body={"name":"rex"}`, ""},
+		{"pets create-pet --body nope", "", "not valid JSON"},
+		{"pets create-pet", "", "missing required flag --body"},
+		{"get-health", "GET /health", ""},
+	}
+	for _, tc := range tests {
+		requests, out = nil, strings.Builder{}
+		env := root.Clone().NewEnv(nil)
+		env.Log = io.Discard
+		err := command.Run(env, strings.Fields(tc.args))
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("Run %q: got error %v, want %q", tc.args, err, tc.wantErr)
+		}
+		if got := strings.Join(requests, "\n"); got != tc.want {
+			t.Errorf("Run %q: request %q, want %q", tc.args, got, tc.want)
+		}
+		if err == nil && out.String() != "{\n  \"ok\": \"yes\"\n}\n" {
+			t.Errorf("Run %q: output %q", tc.args, out.String())
+		}
+	}
+}
+
+func TestCommandsErrors(t *testing.T) {
+	tests := []struct {
+		spec, want string
+	}{
+		{`{`, "invalid OpenAPI document"},
+		{`{"paths": {}}`, "no server URL"},
+		{`{"servers": [{"url": "x"}], "paths": {"/a": {"get": {"parameters": [{"$ref": "#/nope"}]}}}}`,
+			"unresolved parameter reference"},
+	}
+	for _, tc := range tests {
+		_, err := openapi.Commands([]byte(tc.spec), nil)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Commands(%s): got %v, want %q", tc.spec, err, tc.want)
+		}
+	}
+}
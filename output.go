@@ -0,0 +1,222 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrBrokenPipe is reported by [Run] when a write to the primary output of a
+// command failed because the reader had gone away, for example when the
+// output is piped into "head", and broken pipes are handled by the
+// environment (see Env.ExitOnBrokenPipe).
+var ErrBrokenPipe = errors.New("broken pipe")
+
+// Stdout returns a writer for the primary output of e, which is [os.Stdout]
+// unless another writer is set by SetStdout. Commands should write their
+// primary output here, rather than to os.Stdout directly, so that it is
+// subject to the output settings of e (see SetBuffering and ExitOnBrokenPipe).
+// It is safe to write to Stdout concurrently from multiple goroutines.
+//
+// During a call to [Run], output written to Stdout is buffered according to
+// the buffering mode of e, and is flushed when the command completes, whether
+// or not it succeeds. Use Flush to flush buffered output sooner.
+//
+// By contrast, writing to e itself writes to its diagnostic output.
+func (e *Env) Stdout() io.Writer {
+	w := e.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if !inv.active || e.bufMode == BufferNone {
+		return inv.teeLog(stdoutWriter{env: e, w: w})
+	}
+	for _, b := range inv.outputs {
+		if sameWriter(b.raw, w) {
+			return inv.teeLog(b)
+		}
+	}
+	b := &outputBuffer{raw: w, line: e.bufMode == BufferLine}
+	if e.bufMode == BufferAuto {
+		f, ok := w.(*os.File)
+		b.line = ok && isTerminal(f)
+	}
+	b.buf = bufio.NewWriter(stdoutWriter{env: e, w: w})
+	inv.outputs = append(inv.outputs, b)
+	return inv.teeLog(b)
+}
+
+// SetStdout sets the primary output of e to w and returns e. If w == nil, it
+// restores the default, [os.Stdout]. The setting is inherited by the
+// descendants of e.
+func (e *Env) SetStdout(w io.Writer) *Env { e.stdout = w; return e }
+
+// Stdin returns the input of e, which is [os.Stdin] unless another reader is
+// set by SetStdin.
+func (e *Env) Stdin() io.Reader {
+	if e.stdin == nil {
+		return os.Stdin
+	}
+	return e.stdin
+}
+
+// SetStdin sets the input of e to r and returns e. If r == nil, it restores
+// the default, [os.Stdin]. The setting is inherited by the descendants of e.
+func (e *Env) SetStdin(r io.Reader) *Env { e.stdin = r; return e }
+
+// Emit writes its arguments to the primary output of e (see Stdout) followed
+// by a newline, formatted as if by [fmt.Println]. If e has a format (see
+// SetFormat), each argument is rendered according to the format, and the
+// results are separated by spaces. It reports an error if the write fails.
+func (e *Env) Emit(args ...any) error {
+	if e.format != nil {
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			strs[i] = e.format.value(arg)
+		}
+		_, err := fmt.Fprintln(e.Stdout(), strings.Join(strs, " "))
+		return err
+	}
+	_, err := fmt.Fprintln(e.Stdout(), args...)
+	return err
+}
+
+// Flush writes any buffered output for the primary output of e (see Stdout)
+// to the underlying writer.
+func (e *Env) Flush() error { return e.invocation().flushOutput(nil) }
+
+// BufferMode is the buffering policy for the primary output of an [Env] (see
+// Env.SetBuffering).
+type BufferMode int
+
+const (
+	// BufferAuto buffers output by lines if it is a terminal, otherwise by
+	// blocks. This is the default.
+	BufferAuto BufferMode = iota
+
+	// BufferLine buffers output until a complete line is written.
+	BufferLine
+
+	// BufferBlock buffers output in large blocks. This gives the best
+	// throughput for commands that write a lot of output.
+	BufferBlock
+
+	// BufferNone does not buffer output.
+	BufferNone
+)
+
+// SetBuffering sets the buffering mode for the primary output of e (see
+// Stdout), and returns e. The setting is inherited by the descendants of e.
+func (e *Env) SetBuffering(mode BufferMode) *Env { e.bufMode = mode; return e }
+
+// outputBuffer is a buffered writer for the primary output of an invocation.
+type outputBuffer struct {
+	raw  io.Writer // the underlying writer
+	line bool      // flush after each complete line
+
+	mu  sync.Mutex
+	buf *bufio.Writer
+}
+
+func (b *outputBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, err := b.buf.Write(data)
+	if err == nil && b.line && bytes.IndexByte(data, '\n') >= 0 {
+		err = b.buf.Flush()
+	}
+	return n, err
+}
+
+func (b *outputBuffer) flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Flush()
+}
+
+// flushOutput flushes the buffered primary output of v, and returns err, or
+// if err == nil, the first error from flushing.
+func (v *invocation) flushOutput(err error) error {
+	v.mu.Lock()
+	outputs := v.outputs
+	v.mu.Unlock()
+	for _, b := range outputs {
+		if ferr := b.flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// sameWriter reports whether a and b are the same writer.
+func sameWriter(a, b io.Writer) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
+
+// ExitOnBrokenPipe sets whether a broken pipe on the primary output of e
+// causes the program to exit quietly with the given exit code, and returns e.
+// The default is false. The setting is inherited by the descendants of e.
+//
+// When this is enabled, a write to Stdout that fails because the reader has
+// gone away, as when the output is piped into "head", reports ErrBrokenPipe
+// to the command. However the command then fails, whether it reports this or
+// another error or panics, [Run] reports [ErrBrokenPipe], and [RunOrFail]
+// exits with the given code without printing an error report. Conventionally
+// the code is 0, or 141 (128 plus the number of SIGPIPE) to match the shell.
+//
+// On Unix-like systems, enabling this also causes the process to ignore
+// SIGPIPE, so that a broken pipe on [os.Stdout] does not terminate it.
+func (e *Env) ExitOnBrokenPipe(exit bool, code int) *Env {
+	e.onPipe, e.pipeCode = exit, code
+	if exit {
+		ignoreSIGPIPE()
+	}
+	return e
+}
+
+// stdoutWriter is the [io.Writer] for the primary output of an environment.
+type stdoutWriter struct {
+	env *Env
+	w   io.Writer
+}
+
+func (s stdoutWriter) Write(data []byte) (int, error) {
+	n, err := s.w.Write(data)
+	if err != nil && isBrokenPipe(err) {
+		inv := s.env.invocation()
+		inv.mu.Lock()
+		inv.brokenPipe = true
+		inv.mu.Unlock()
+		if s.env.onPipe {
+			return n, ErrBrokenPipe
+		}
+	}
+	return n, err
+}
+
+// checkBrokenPipe returns ErrBrokenPipe if the invocation of e failed with
+// err after a broken pipe on its primary output, and broken pipes are handled
+// by e (see ExitOnBrokenPipe). Otherwise it returns err.
+func (e *Env) checkBrokenPipe(err error) error {
+	if err == nil || !e.onPipe {
+		return err
+	}
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.brokenPipe {
+		return ErrBrokenPipe
+	}
+	return err
+}
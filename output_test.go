@@ -0,0 +1,134 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestBrokenPipe(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "dragonfly", "freebsd", "netbsd", "openbsd":
+	default:
+		t.Skipf("Broken pipes are not detected on %s", runtime.GOOS)
+	}
+	errOther := errors.New("other failure")
+	var writeErr error
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "fail",
+			Run: func(env *command.Env) error {
+				_, writeErr = fmt.Fprintln(env.Stdout(), "hello")
+				return fmt.Errorf("wrapped: %w", errOther)
+			},
+		}, {
+			Name: "panic",
+			Run: func(env *command.Env) error {
+				if _, err := fmt.Fprintln(env.Stdout(), "hello"); err != nil {
+					panic(err)
+				}
+				return nil
+			},
+		}, {
+			Name: "ok",
+			Run: func(env *command.Env) error {
+				fmt.Fprintln(env.Stdout(), "hello") // error ignored
+				return nil
+			},
+		}},
+	}
+	run := func(mode command.BufferMode, exit bool, args ...string) error {
+		t.Helper()
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pr.Close() // the reader goes away
+		defer pw.Close()
+
+		env := root.NewEnv(nil).SetStdout(pw).SetBuffering(mode).ExitOnBrokenPipe(exit, 0)
+		env.Log = io.Discard
+		return command.Run(env, args)
+	}
+
+	// Without the option, the command reports whatever it likes.
+	if err := run(command.BufferNone, false, "fail"); !errors.Is(err, errOther) {
+		t.Errorf("Run: got %v, want %v", err, errOther)
+	}
+	if errors.Is(writeErr, command.ErrBrokenPipe) || writeErr == nil {
+		t.Errorf("Write: got %v, want a system error", writeErr)
+	}
+
+	// With the option, the failure is reported as a broken pipe.
+	for _, name := range []string{"fail", "panic"} {
+		if err := run(command.BufferNone, true, name); err != command.ErrBrokenPipe {
+			t.Errorf("Run %q: got %v, want %v", name, err, command.ErrBrokenPipe)
+		}
+	}
+	if !errors.Is(writeErr, command.ErrBrokenPipe) {
+		t.Errorf("Write: got %v, want %v", writeErr, command.ErrBrokenPipe)
+	}
+
+	// A command that succeeds is not affected.
+	if err := run(command.BufferNone, true, "ok"); err != nil {
+		t.Errorf("Run: unexpected error: %v", err)
+	}
+
+	// Unless its buffered output cannot be flushed when it completes.
+	if err := run(command.BufferBlock, true, "ok"); err != command.ErrBrokenPipe {
+		t.Errorf("Run: got %v, want %v", err, command.ErrBrokenPipe)
+	}
+}
+
+func TestBuffering(t *testing.T) {
+	var flushed []string
+	var out strings.Builder
+	root := &command.C{
+		Name: "tool",
+		Run: func(env *command.Env) error {
+			env.Emit("first")
+			fmt.Fprint(env.Stdout(), "second")
+			flushed = append(flushed, out.String())
+			env.Flush()
+			flushed = append(flushed, out.String())
+			env.Emit("third")
+			return errors.New("failed")
+		},
+	}
+	tests := []struct {
+		mode command.BufferMode
+		want []string
+	}{
+		{command.BufferAuto, []string{"", "first\nsecond"}}, // not a terminal
+		{command.BufferBlock, []string{"", "first\nsecond"}},
+		{command.BufferLine, []string{"first\n", "first\nsecond"}},
+		{command.BufferNone, []string{"first\nsecond", "first\nsecond"}},
+	}
+	for _, tc := range tests {
+		flushed = nil
+		out.Reset()
+		env := root.NewEnv(nil).SetStdout(&out).SetBuffering(tc.mode)
+		env.Log = io.Discard
+		if err := command.Run(env, nil); err == nil {
+			t.Errorf("Mode %d: Run: got nil error, want error", tc.mode)
+		}
+		if !slices.Equal(flushed, tc.want) {
+			t.Errorf("Mode %d: got output %q, want %q", tc.mode, flushed, tc.want)
+		}
+
+		// Output is flushed when the command completes, even if it fails.
+		if got, want := out.String(), "first\nsecondthird\n"; got != want {
+			t.Errorf("Mode %d: final output is %q, want %q", tc.mode, got, want)
+		}
+	}
+}
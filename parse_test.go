@@ -3,14 +3,17 @@
 package command_test
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/creachadair/command"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 var flags struct {
@@ -168,3 +171,463 @@ func TestHelpFlag(t *testing.T) {
 		}
 	}
 }
+
+func TestPflagStyle(t *testing.T) {
+	var a, b bool
+	var out string
+	var n int
+	var gotArgs []string
+	root := &command.C{
+		Name: "root",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&a, "a", false, "a")
+			fs.BoolVar(&b, "b", false, "b")
+			fs.StringVar(&out, "o", "", "o")
+			fs.IntVar(&n, "count", 0, "count")
+		},
+		Run: func(env *command.Env) error { gotArgs = env.Args; return nil },
+	}
+
+	tests := []struct {
+		args    string
+		a, b    bool
+		out     string
+		n       int
+		rest    []string
+		wantErr string
+	}{
+		{"-a -b x", true, true, "", 0, []string{"x"}, ""},
+		{"-ab x", true, true, "", 0, []string{"x"}, ""},
+		{"-ba -o foo", true, true, "foo", 0, nil, ""},
+		{"-ofoo x", false, false, "foo", 0, []string{"x"}, ""},
+		{"-o=foo", false, false, "foo", 0, nil, ""},
+		{"-abofoo", true, true, "foo", 0, nil, ""},
+		{"-abo foo", true, true, "foo", 0, nil, ""},
+		{"-abo=foo", true, true, "foo", 0, nil, ""},
+		{"-o -ab", false, false, "-ab", 0, nil, ""},
+		{"--count 3 -a", true, false, "", 3, nil, ""},
+		{"-a -- -ab", true, false, "", 0, []string{"-ab"}, ""},
+		{"-ax", false, false, "", 0, nil, "flag provided but not defined: -ax"},
+		{"-abo", false, false, "", 0, nil, "missing value"},
+	}
+	for _, tc := range tests {
+		a, b, out, n, gotArgs = false, false, "", 0, nil
+		env := root.NewEnv(nil).PflagStyle(true)
+		env.Log = io.Discard
+		err := command.Run(env, strings.Fields(tc.args))
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Run %q: got error %v, want %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if a != tc.a || b != tc.b || out != tc.out || n != tc.n {
+			t.Errorf("Run %q: got a=%v b=%v o=%q count=%d, want a=%v b=%v o=%q count=%d",
+				tc.args, a, b, out, n, tc.a, tc.b, tc.out, tc.n)
+		}
+		if diff := cmp.Diff(gotArgs, tc.rest, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Run %q: args (-got, +want):\n%s", tc.args, diff)
+		}
+	}
+
+	// Without the option, clusters are not recognized.
+	env := root.NewEnv(nil)
+	env.Log = io.Discard
+	if err := command.Run(env, []string{"-ab"}); err == nil {
+		t.Error("Run -ab: got nil, want error")
+	}
+}
+
+func TestUnknownFlags(t *testing.T) {
+	var gotArgs, gotUnknown []string
+	var verbose bool
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.BoolVar(&verbose, "v", false, "Verbose")
+		},
+		Commands: []*command.C{{
+			Name: "wrap",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.String("name", "", "Name")
+			},
+			Run: func(env *command.Env) error {
+				gotArgs, gotUnknown = env.Args, env.UnknownFlags
+				return nil
+			},
+		}},
+	}
+	run := func(p command.UnknownFlagPolicy, args string) error {
+		gotArgs, gotUnknown, verbose = nil, nil, false
+		env := root.NewEnv(nil).SetUnknownFlags(p)
+		env.Log = io.Discard
+		return command.Run(env, strings.Fields(args))
+	}
+
+	if err := run(command.UnknownFlagError, "wrap --other x"); err == nil {
+		t.Error("UnknownFlagError: got nil, want error")
+	}
+
+	var buf strings.Builder
+	env := root.NewEnv(nil).SetUnknownFlags(command.UnknownFlagWarn)
+	env.Log = &buf
+	if err := command.Run(env, strings.Fields("--bogus=1 wrap --other --name q x")); err != nil {
+		t.Fatalf("UnknownFlagWarn: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(gotArgs, []string{"x"}); diff != "" {
+		t.Errorf("UnknownFlagWarn args (-got, +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(env.Warnings(), []string{
+		`ignoring unknown flag "--bogus=1"`, `ignoring unknown flag "--other"`,
+	}); diff != "" {
+		t.Errorf("UnknownFlagWarn warnings (-got, +want):\n%s", diff)
+	}
+
+	// An undefined flag after a free argument is not an error, so it remains
+	// a free argument.
+	if err := run(command.UnknownFlagCollect, "-x=1 -v wrap --other=2 x --name q -y -- -z"); err != nil {
+		t.Fatalf("UnknownFlagCollect: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(gotUnknown, []string{"-x=1", "--other=2"}); diff != "" {
+		t.Errorf("UnknownFlagCollect flags (-got, +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(gotArgs, []string{"x", "-y", "--", "-z"}); diff != "" {
+		t.Errorf("UnknownFlagCollect args (-got, +want):\n%s", diff)
+	}
+	if !verbose {
+		t.Error("UnknownFlagCollect: -v was not set")
+	}
+
+	// Help flags are not treated as unknown.
+	if err := run(command.UnknownFlagCollect, "wrap --help"); !errors.Is(err, command.ErrRequestHelp) {
+		t.Errorf("UnknownFlagCollect --help: got %v, want %v", err, command.ErrRequestHelp)
+	}
+}
+
+func TestCaptureUnknownFlags(t *testing.T) {
+	var gotArgs, gotExtra []string
+	var name string
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			fs.Bool("v", false, "Verbose")
+		},
+		Commands: []*command.C{{
+			Name:                "wrap",
+			CaptureUnknownFlags: true,
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.StringVar(&name, "name", "", "Name")
+			},
+			Run: func(env *command.Env) error {
+				gotArgs, gotExtra = env.Args, env.Extra
+				return nil
+			},
+		}},
+	}
+	tests := []struct {
+		args        string
+		merge       bool
+		name        string
+		extra, rest []string
+	}{
+		{"wrap", true, "", nil, nil},
+		{"wrap --color=auto x --name q -n=3 y", true, "q", []string{"--color=auto", "-n=3"}, []string{"x", "y"}},
+		{"-v wrap -a x -b -- -c --name z", true, "", []string{"-a", "-b"}, []string{"x", "--", "-c", "--name", "z"}},
+		{"wrap --name -x -y x", true, "-x", []string{"-y"}, []string{"x"}},
+		{"wrap -a --name q x -b", false, "q", []string{"-a", "-b"}, []string{"x"}},
+	}
+	for _, tc := range tests {
+		gotArgs, gotExtra, name = nil, nil, ""
+		env := root.NewEnv(nil).MergeFlags(tc.merge)
+		if err := command.Run(env, strings.Fields(tc.args)); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if name != tc.name {
+			t.Errorf("Run %q: name is %q, want %q", tc.args, name, tc.name)
+		}
+		if diff := cmp.Diff(gotExtra, tc.extra); diff != "" {
+			t.Errorf("Run %q: extra (-got, +want):\n%s", tc.args, diff)
+		}
+		if diff := cmp.Diff(gotArgs, tc.rest, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("Run %q: args (-got, +want):\n%s", tc.args, diff)
+		}
+	}
+}
+
+func TestNoImplicitHelp(t *testing.T) {
+	var gotExtra []string
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:                "wrap",
+			NoImplicitHelp:      true,
+			CaptureUnknownFlags: true,
+			Run: func(env *command.Env) error {
+				gotExtra = env.Extra
+				return nil
+			},
+		}, {
+			Name:           "strict",
+			NoImplicitHelp: true,
+			Run:            func(*command.Env) error { return nil },
+		}, {
+			Name: "normal",
+			Run:  func(*command.Env) error { return nil },
+		}},
+	}
+	run := func(args ...string) error {
+		env := root.NewEnv(nil)
+		env.Log = io.Discard
+		return command.Run(env, args)
+	}
+
+	if err := run("wrap", "-x", "--help", "-h"); err != nil {
+		t.Fatalf("Run wrap: unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(gotExtra, []string{"-x", "--help", "-h"}); diff != "" {
+		t.Errorf("Extra (-got, +want):\n%s", diff)
+	}
+
+	var uerr command.UsageError
+	if err := run("strict", "-h"); !errors.As(err, &uerr) {
+		t.Errorf("Run strict -h: got %v, want usage error", err)
+	} else if want := "flag provided but not defined: -h"; uerr.Message != want {
+		t.Errorf("Run strict -h: got %q, want %q", uerr.Message, want)
+	}
+	if err := run("normal", "--help"); !errors.Is(err, command.ErrRequestHelp) {
+		t.Errorf("Run normal --help: got %v, want %v", err, command.ErrRequestHelp)
+	}
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	var n int
+	var gotArgs, gotUnknown []string
+	newRoot := func() *command.C {
+		n, gotArgs, gotUnknown = 0, nil, nil
+		return &command.C{
+			Name: "calc",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.IntVar(&n, "n", 0, "A number")
+				fs.Bool("9", false, "A flag named with a digit")
+			},
+			Commands: []*command.C{{
+				Name: "add",
+				Run: func(env *command.Env) error {
+					gotArgs, gotUnknown = env.Args, env.UnknownFlags
+					return nil
+				},
+			}},
+			Run: func(env *command.Env) error {
+				gotArgs, gotUnknown = env.Args, env.UnknownFlags
+				return nil
+			},
+		}
+	}
+	tests := []struct {
+		args  string
+		merge bool
+		n     int
+		rest  []string
+	}{
+		{"-5 3", true, 0, []string{"-5", "3"}},
+		{"-5 -n 2 3", true, 2, []string{"-5", "3"}},
+		{"-n -2 -3.5", true, -2, []string{"-3.5"}},
+		{"-n 1 -.5", false, 1, []string{"-.5"}},
+		{"-9 -1", false, 0, []string{"-1"}},
+		{"add -5 -3", true, 0, []string{"-5", "-3"}},
+		{"-- -5", true, 0, []string{"-5"}},
+	}
+	for _, tc := range tests {
+		for _, policy := range []command.UnknownFlagPolicy{command.UnknownFlagError, command.UnknownFlagCollect} {
+			env := newRoot().NewEnv(nil).NegativeNumbers(true).MergeFlags(tc.merge).SetUnknownFlags(policy)
+			if err := command.Run(env, strings.Fields(tc.args)); err != nil {
+				t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+				continue
+			}
+			if n != tc.n || !slices.Equal(gotArgs, tc.rest) || len(gotUnknown) != 0 {
+				t.Errorf("Run %q: got n=%d args=%q unknown=%q, want n=%d args=%q",
+					tc.args, n, gotArgs, gotUnknown, tc.n, tc.rest)
+			}
+		}
+	}
+
+	// Without the option, negative numbers are flags.
+	env := newRoot().NewEnv(nil)
+	env.Log = io.Discard
+	if err := command.Run(env, []string{"-5"}); err == nil {
+		t.Error("Run -5: got nil error, want error")
+	}
+}
+
+func TestBoolNegation(t *testing.T) {
+	var color, verbose, noCache bool
+	var out string
+	var gotArgs []string
+	newRoot := func() *command.C {
+		color, verbose, noCache, out, gotArgs = true, false, false, "", nil
+		return &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				fs.BoolVar(&color, "color", true, "Colorize output")
+				fs.BoolVar(&verbose, "v", false, "Verbose output")
+				fs.BoolVar(&noCache, "no-cache", false, "Disable the cache")
+				fs.Bool("cache", true, "Enable the cache")
+				fs.StringVar(&out, "o", "", "Output file")
+			},
+			Run: func(env *command.Env) error { gotArgs = env.Args; return nil },
+		}
+	}
+	tests := []struct {
+		args       string
+		color, v   bool
+		noCache    bool
+		out        string
+		rest       []string
+		wantErrOff bool // whether the args fail without the option
+	}{
+		{"--no-color x", false, false, false, "", []string{"x"}, true},
+		{"x -no-color -no-v", false, false, false, "", []string{"x"}, false},
+		{"--no-color --color", true, false, false, "", nil, true},
+		{"--no-cache", true, false, true, "", nil, false}, // defined explicitly
+		{"-o --no-color", true, false, false, "--no-color", nil, false},
+		{"-- --no-color", true, false, false, "", []string{"--no-color"}, false},
+	}
+	for _, tc := range tests {
+		env := newRoot().NewEnv(nil).BoolNegation(true)
+		if err := command.Run(env, strings.Fields(tc.args)); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if color != tc.color || verbose != tc.v || noCache != tc.noCache || out != tc.out || !slices.Equal(gotArgs, tc.rest) {
+			t.Errorf("Run %q: got color=%v v=%v no-cache=%v o=%q args=%q, want %v, %v, %v, %q, %q",
+				tc.args, color, verbose, noCache, out, gotArgs, tc.color, tc.v, tc.noCache, tc.out, tc.rest)
+		}
+
+		env = newRoot().NewEnv(nil)
+		env.Log = io.Discard
+		if err := command.Run(env, strings.Fields(tc.args)); (err != nil) != tc.wantErrOff {
+			t.Errorf("Run %q without negation: got error %v, want error %v", tc.args, err, tc.wantErrOff)
+		}
+	}
+
+	root := newRoot()
+	root.SetFlags(nil, &root.Flags)
+	if help := renderHelp(root, 0); strings.Contains(help, "no-color") {
+		t.Errorf("Help output lists a negated flag:\n%s", help)
+	}
+}
+
+// shortParser is a [command.FlagParser] that accepts single-letter short names
+// for its long flags, standing in for another flag library.
+type shortParser struct {
+	flag.FlagSet
+	short map[string]string // short name → long name
+}
+
+func (p *shortParser) Parse(args []string) error {
+	var long []string
+	for _, arg := range args {
+		if name, ok := p.short[strings.TrimPrefix(arg, "-")]; ok {
+			arg = "--" + name
+		}
+		long = append(long, arg)
+	}
+	return p.FlagSet.Parse(long)
+}
+
+func (p *shortParser) Lookup(name string) *flag.Flag {
+	if long, ok := p.short[name]; ok {
+		if lf := p.FlagSet.Lookup(long); lf != nil {
+			f := *lf
+			f.Name = name
+			return &f
+		}
+		return nil
+	}
+	return p.FlagSet.Lookup(name)
+}
+
+// Changed implements [command.FlagChanger].
+func (p *shortParser) Changed(name string) bool {
+	if long, ok := p.short[name]; ok {
+		name = long
+	}
+	var changed bool
+	p.FlagSet.Visit(func(f *flag.Flag) { changed = changed || f.Name == name })
+	return changed
+}
+
+func (p *shortParser) VisitAll(f func(*flag.Flag)) {
+	p.FlagSet.VisitAll(f)
+	for name := range p.short {
+		if sf := p.Lookup(name); sf != nil {
+			f(sf)
+		}
+	}
+}
+
+func TestFlagParser(t *testing.T) {
+	var output, name string
+	newTree := func() *command.C {
+		output, name = "", ""
+		p := &shortParser{short: map[string]string{"o": "output"}}
+		p.StringVar(&name, "name", "", "Name of the thing")
+		return &command.C{
+			Name: "tool",
+			Commands: []*command.C{{
+				Name:       "sub",
+				FlagParser: p,
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					p.StringVar(&output, "output", "", "Output file")
+					command.MarkRequired(fs, "name") // defined by the parser
+				},
+				Run: func(*command.Env) error { return nil },
+			}},
+		}
+	}
+	run := func(args ...string) (*command.Env, string, error) {
+		t.Helper()
+		var log strings.Builder
+		env := newTree().NewEnv(nil)
+		env.Log = &log
+		got, err := command.RunE(env, args)
+		return got, log.String(), err
+	}
+
+	// Flags are parsed by the parser, and merged from later arguments.
+	env, _, err := run("sub", "arg", "-o", "out", "--name", "x")
+	if err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if output != "out" || name != "x" {
+		t.Errorf("Flags: got output %q, name %q; want out, x", output, name)
+	}
+	if diff := cmp.Diff([]string{"arg"}, env.Args); diff != "" {
+		t.Errorf("Args (-want, +got):\n%s", diff)
+	}
+
+	// Rules that depend on whether a flag is set apply.
+	if _, _, err := run("sub", "-o", "out"); !command.IsUsage(err) || !strings.Contains(err.Error(), "--name") {
+		t.Errorf("Run without --name: got %v, want missing flag error", err)
+	}
+
+	// A flag set explicitly to its default value is set.
+	if _, _, err := run("sub", "--name="); err != nil {
+		t.Errorf("Run with default --name: unexpected error: %v", err)
+	}
+
+	// Help lists the flags of the parser, and --help is handled.
+	_, log, err := run("sub", "--help")
+	if !errors.Is(err, command.ErrRequestHelp) {
+		t.Errorf("Run --help: got %v, want %v", err, command.ErrRequestHelp)
+	}
+	for _, want := range []string{"-o, --output string", "--name string"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Help does not contain %q:\n%s", want, log)
+		}
+	}
+}
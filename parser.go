@@ -0,0 +1,147 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"flag"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A FlagParser parses the flags of a command in place of its Flags field
+// (see C.FlagParser). A [*flag.FlagSet] satisfies this interface, and an
+// adapter allows a command to use another flag library, such as pflag or ff.
+type FlagParser interface {
+	// Parse parses flags from args, which do not include the command name.
+	Parse(args []string) error
+
+	// Args returns the arguments remaining after a call to Parse.
+	Args() []string
+
+	// Lookup returns the flag with the given name, or nil if there is none.
+	Lookup(name string) *flag.Flag
+
+	// VisitAll calls f for each flag, in any order. Flags with several names
+	// are reported once for each name, with the same Value.
+	VisitAll(f func(*flag.Flag))
+}
+
+// A FlagChanger is a [FlagParser] that reports which of its flags were set by
+// parsing. A parser that does not implement this interface, and does not have
+// a Visit method like [flag.FlagSet.Visit], is assumed to have set each flag
+// whose value was changed by parsing, which misses a flag set explicitly to
+// its current value.
+//
+// An adapter for another flag library should implement Changed by asking the
+// library. For example, an adapter for the pflag package, whose FlagSet does
+// not satisfy FlagParser directly, can report the Changed field of the
+// underlying pflag.Flag.
+type FlagChanger interface {
+	FlagParser
+
+	// Changed reports whether the named flag was set by parsing.
+	Changed(name string) bool
+}
+
+// mirrorFlags defines a flag in fs for each flag of p that fs does not
+// already define, sharing the value of the flag in p. Names that share a
+// value are defined as aliases of the first (see Alias). This allows help,
+// flag merging, and the other features that consult C.Flags to see the flags
+// of a command parsed by p.
+func mirrorFlags(p FlagParser, fs *flag.FlagSet) {
+	var all []*flag.Flag
+	p.VisitAll(func(f *flag.Flag) { all = append(all, f) })
+
+	// Prefer long names as the primary names of aliased flags.
+	slices.SortStableFunc(all, func(a, b *flag.Flag) int { return len(b.Name) - len(a.Name) })
+	primary := make(map[flag.Value]string)
+	for _, f := range all {
+		if fs.Lookup(f.Name) != nil {
+			continue
+		}
+		if name, ok := primary[f.Value]; ok {
+			Alias(fs, name, f.Name)
+			continue
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.DefValue
+		if reflect.TypeOf(f.Value).Comparable() {
+			primary[f.Value] = f.Name
+		}
+	}
+}
+
+// parseArgs parses the flags of c from args, using the flag parser of c if it
+// has one, and returns the remaining arguments.
+func (c *C) parseArgs(args []string) ([]string, error) {
+	p := c.FlagParser
+	if p == nil {
+		err := c.Flags.Parse(args)
+		return c.Flags.Args(), err
+	}
+
+	// Record which flags were set by p, so that the rules that depend on
+	// whether a flag is set (see MarkRequired) apply as they do to flags
+	// parsed by c.Flags (see FlagChanger).
+	before := make(map[string]string)
+	p.VisitAll(func(f *flag.Flag) { before[f.Name] = f.Value.String() })
+	if err := p.Parse(args); err != nil {
+		return nil, err
+	}
+	switch t := p.(type) {
+	case FlagChanger:
+		t.VisitAll(func(f *flag.Flag) {
+			if t.Changed(f.Name) {
+				markSet(&c.Flags, f.Name)
+			}
+		})
+	case interface{ Visit(func(*flag.Flag)) }:
+		t.Visit(func(f *flag.Flag) { markSet(&c.Flags, f.Name) })
+	default:
+		p.VisitAll(func(f *flag.Flag) {
+			if f.Value.String() != before[f.Name] {
+				markSet(&c.Flags, f.Name)
+			}
+		})
+	}
+	return p.Args(), nil
+}
+
+// hasHelpFlag reports whether args request help with -h or --help before the
+// first free argument, and fs does not define a flag by that name.
+func hasHelpFlag(fs *flag.FlagSet, args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return false
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := fs.Lookup(name); f != nil {
+			if !hasValue && !isBoolFlag(f) {
+				i++ // skip the value of the flag
+			}
+		} else if name == "h" || name == "help" {
+			return true
+		}
+	}
+	return false
+}
+
+// markSet marks the named flag of fs as set, without changing its value.
+func markSet(fs *flag.FlagSet, name string) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return
+	}
+	v := f.Value
+	f.Value = ignoreValue{}
+	fs.Set(name, "")
+	f.Value = v
+}
+
+// ignoreValue is a [flag.Value] that ignores the values it is set to.
+type ignoreValue struct{}
+
+func (ignoreValue) String() string   { return "" }
+func (ignoreValue) Set(string) error { return nil }
@@ -0,0 +1,12 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package command
+
+// isBrokenPipe reports whether err reports a write to a pipe whose reader has
+// gone away. On this platform, such errors are not recognized.
+func isBrokenPipe(err error) bool { return false }
+
+// ignoreSIGPIPE is a no-op on this platform.
+func ignoreSIGPIPE() {}
@@ -0,0 +1,19 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package command
+
+import (
+	"errors"
+	"os/signal"
+	"syscall"
+)
+
+// isBrokenPipe reports whether err reports a write to a pipe or socket whose
+// reader has gone away.
+func isBrokenPipe(err error) bool { return errors.Is(err, syscall.EPIPE) }
+
+// ignoreSIGPIPE arranges for writes to a broken pipe on stdout and stderr to
+// report EPIPE instead of terminating the process.
+func ignoreSIGPIPE() { signal.Ignore(syscall.SIGPIPE) }
@@ -0,0 +1,47 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// SetRandom sets the random source of e to src, and returns e. If src == nil,
+// it restores the default, the global random source of [math/rand/v2]. The
+// setting is inherited by the descendants of e.
+//
+// Commands should use Env.Rand rather than the global random functions, so
+// that tests can make random choices, such as generated IDs or retry delays,
+// deterministic by setting a seeded source, for example:
+//
+//	env.SetRandom(rand.NewPCG(1, 2))
+func (e *Env) SetRandom(src rand.Source) *Env { e.random = src; return e }
+
+// Rand returns a random generator using the random source of e (see
+// SetRandom). The generator is safe for concurrent use if the source is; the
+// default source is.
+func (e *Env) Rand() *rand.Rand {
+	if e.random == nil {
+		return rand.New(globalSource{})
+	}
+	return rand.New(e.random)
+}
+
+// Jitter returns a random duration in the half-open interval [d-f*d, d+f*d),
+// chosen using the random source of e, for spreading out retries and other
+// periodic work. The fraction f is clamped to [0, 1]. For example,
+// env.Jitter(time.Second, 0.25) returns a duration between 750ms and 1.25s.
+func (e *Env) Jitter(d time.Duration, f float64) time.Duration {
+	f = min(max(f, 0), 1)
+	delta := time.Duration(float64(d) * f)
+	if delta <= 0 {
+		return d
+	}
+	return d - delta + time.Duration(e.Rand().Int64N(int64(2*delta)))
+}
+
+// globalSource is a [rand.Source] that uses the global random source.
+type globalSource struct{}
+
+func (globalSource) Uint64() uint64 { return rand.Uint64() }
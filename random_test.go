@@ -0,0 +1,60 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestRandom(t *testing.T) {
+	var ids []uint64
+	var delays []time.Duration
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "gen",
+			Run: func(env *command.Env) error {
+				ids = append(ids, env.Rand().Uint64(), env.Rand().Uint64())
+				delays = append(delays, env.Jitter(time.Second, 0.5))
+				return nil
+			},
+		}},
+	}
+	run := func() ([]uint64, []time.Duration) {
+		t.Helper()
+		ids, delays = nil, nil
+		env := root.NewEnv(nil).SetRandom(rand.NewPCG(1, 2))
+		if err := command.Run(env, []string{"gen"}); err != nil {
+			t.Fatalf("Run: unexpected error: %v", err)
+		}
+		return ids, delays
+	}
+
+	// With the same seed, the results are the same.
+	ids1, delays1 := run()
+	ids2, delays2 := run()
+	if ids1[0] != ids2[0] || ids1[1] != ids2[1] || delays1[0] != delays2[0] {
+		t.Errorf("Results differ: %v %v, %v %v", ids1, delays1, ids2, delays2)
+	}
+	// The source advances between calls.
+	if ids1[0] == ids1[1] {
+		t.Errorf("Repeated value %v", ids1[0])
+	}
+	if d := delays1[0]; d < 500*time.Millisecond || d >= 1500*time.Millisecond {
+		t.Errorf("Jitter: got %v, want between 500ms and 1.5s", d)
+	}
+
+	env := root.NewEnv(nil)
+	if got := env.Jitter(time.Second, 0); got != time.Second {
+		t.Errorf("Jitter with no fraction: got %v, want 1s", got)
+	}
+	for range 100 {
+		if got := env.Jitter(time.Second, 2); got < 0 || got >= 2*time.Second {
+			t.Fatalf("Jitter: got %v, want between 0 and 2s", got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A HelpProvider supplies help text for commands from outside the command
+// tree, for use with [UseHelpProvider].
+type HelpProvider interface {
+	// CommandHelp returns the help text for the command at path, the names of
+	// the commands from the root separated by spaces. The path of the root
+	// itself is "". It returns "" if it has no text for the command.
+	CommandHelp(env *Env, path string) (string, error)
+}
+
+// UseHelpProvider attaches p to the commands in the tree rooted at root,
+// including help topics. Commands are matched by their path from the root.
+// Each command is given a HelpFunc that returns its text from p if p has
+// text for it, and otherwise the help text the command had before, from its
+// Help or HelpFunc. If p reports an error, the command falls back to its own
+// text in the same way, so that help remains available when p does not.
+func UseHelpProvider(root *C, p HelpProvider) {
+	walkTree(root, func(path string, c *C) {
+		local := *c // capture the original help of c
+		c.HelpFunc = func(env *Env) string {
+			if text, err := p.CommandHelp(env, path); err == nil && text != "" {
+				return text
+			}
+			return local.helpText(env)
+		}
+	})
+}
+
+// remoteHelpCacheDir is the name of the directory in the state directory of
+// the program where remote help text is cached (see RemoteHelp).
+const remoteHelpCacheDir = "help"
+
+// RemoteHelp returns a [HelpProvider] that fetches help text from url by HTTP
+// GET, using the HTTP client of the environment (see Env.HTTPClient). This
+// allows the help for a program to be updated without releasing the program.
+// The response must be a JSON object that maps the paths of commands to their
+// help text, as described for HelpProvider, for example:
+//
+//	{
+//	  "": "Manage deployments.\n\nThis tool ...",
+//	  "deploy": "Deploy a service.\n\nThe deploy command ...",
+//	  "deploy status": "Report the status of a deployment."
+//	}
+//
+// The text is fetched at most once per process, when help is first needed,
+// and is cached for the given time to live in the state directory of the
+// program (see Env.StateDir), according to the clock of the environment. If
+// the text cannot be fetched, for example because the network is not
+// available, the cached text is used however old it is. If there is no cached
+// text either, the provider reports an error.
+func RemoteHelp(url string, ttl time.Duration) HelpProvider {
+	return &remoteHelp{url: url, ttl: ttl}
+}
+
+// remoteHelp is a [HelpProvider] that fetches help text by HTTP (see
+// RemoteHelp).
+type remoteHelp struct {
+	url string
+	ttl time.Duration
+
+	mu   sync.Mutex
+	done bool              // whether text and err are loaded
+	text map[string]string // path → help text
+	err  error
+}
+
+func (r *remoteHelp) CommandHelp(env *Env, path string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.done {
+		r.text, r.err = r.load(env)
+		r.done = true
+	}
+	return r.text[path], r.err
+}
+
+// remoteHelpEntry is the format of a cached remote help file.
+type remoteHelpEntry struct {
+	Time time.Time         `json:"time"`
+	Text map[string]string `json:"text"`
+}
+
+// load returns the help text for r, from the cache if it is fresh, otherwise
+// from the server, or failing that from the cache regardless of age.
+func (r *remoteHelp) load(env *Env) (map[string]string, error) {
+	sum := sha256.Sum256([]byte(r.url))
+	path := filepath.Join(env.StateDir(), remoteHelpCacheDir, hex.EncodeToString(sum[:16])+".json")
+
+	var cached remoteHelpEntry
+	data, err := env.readFile(path)
+	haveCache := err == nil && json.Unmarshal(data, &cached) == nil
+	if haveCache {
+		if age := env.Since(cached.Time); age >= 0 && age < r.ttl {
+			return cached.Text, nil
+		}
+	}
+
+	text, err := r.fetch(env)
+	if err != nil {
+		if haveCache {
+			return cached.Text, nil
+		}
+		return nil, err
+	}
+	if data, err := json.Marshal(remoteHelpEntry{Time: env.Now(), Text: text}); err == nil {
+		env.writeFileAtomic(path, data) // failure to cache is not an error
+	}
+	return text, nil
+}
+
+// fetch retrieves the help text for r from its server.
+func (r *remoteHelp) fetch(env *Env) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(env.Context(), http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := env.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, rsp.Body)
+		return nil, fmt.Errorf("fetch help: %s", rsp.Status)
+	}
+	var text map[string]string
+	if err := json.NewDecoder(rsp.Body).Decode(&text); err != nil {
+		return nil, fmt.Errorf("decode help: %w", err)
+	}
+	return text, nil
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestRemoteHelp(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	remote := map[string]string{"": "Remote root help.", "one": "Remote help for one."}
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(remote)
+	}))
+	defer srv.Close()
+
+	check := func(ttl time.Duration, want map[string]string) {
+		t.Helper()
+		root := &command.C{
+			Name: "tool",
+			Help: "Local root help.",
+			Commands: []*command.C{
+				{Name: "one", Help: "Local help for one."},
+				{Name: "two", HelpFunc: func(*command.Env) string { return "Local help for two." }},
+			},
+		}
+		command.UseHelpProvider(root, command.RemoteHelp(srv.URL, ttl))
+		for _, c := range []*command.C{root, root.Commands[0], root.Commands[1]} {
+			if got := c.HelpInfo(0).Help; got != want[c.Name] {
+				t.Errorf("Help for %q: got %q, want %q", c.Name, got, want[c.Name])
+			}
+		}
+	}
+
+	// Remote text replaces local text, where there is some.
+	check(time.Hour, map[string]string{
+		"tool": "Remote root help.",
+		"one":  "Remote help for one.",
+		"two":  "Local help for two.",
+	})
+	if fetches != 1 {
+		t.Errorf("Got %d fetches, want 1", fetches)
+	}
+
+	// While the cache is fresh, changes on the server are not seen.
+	remote[""] = "Updated root help."
+	check(time.Hour, map[string]string{
+		"tool": "Remote root help.",
+		"one":  "Remote help for one.",
+		"two":  "Local help for two.",
+	})
+	if fetches != 1 {
+		t.Errorf("Got %d fetches, want 1", fetches)
+	}
+
+	// When the cache is stale, the text is fetched again.
+	check(0, map[string]string{
+		"tool": "Updated root help.",
+		"one":  "Remote help for one.",
+		"two":  "Local help for two.",
+	})
+
+	// When the server is unavailable, stale cached text is used.
+	srv.Close()
+	check(0, map[string]string{
+		"tool": "Updated root help.",
+		"one":  "Remote help for one.",
+		"two":  "Local help for two.",
+	})
+
+	// Without a cache either, the local text is used.
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	check(0, map[string]string{
+		"tool": "Local root help.",
+		"one":  "Local help for one.",
+		"two":  "Local help for two.",
+	})
+}
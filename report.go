@@ -0,0 +1,119 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ErrorReport describes an error reported by [RunOrFail]. It is the data
+// value used to execute the error template (see Env.SetErrorTemplate).
+type ErrorReport struct {
+	Err     error  // the error reported by Run
+	Message string // the text of the error message
+	Usage   string // for a usage error, the usage summary of the command
+	Hint    string // for a usage error, how to get help for the command
+	Stack   string // for a panic, the stack trace of the panic
+}
+
+// defaultErrorTemplate is the template used to render an error report when
+// no other template is set.
+var defaultErrorTemplate = template.Must(template.New("error").Parse(
+	`Error: {{.Message}}
+{{- with .Usage}}
+
+{{.}}
+{{- end}}
+{{- with .Hint}}
+
+{{.}}
+{{- end}}
+{{- with .Stack}}
+
+Stack trace from panic:
+{{.}}
+{{- end}}
+`))
+
+// SetErrorTemplate sets the template used by [RunOrFail] to render an error
+// report for e, and returns e. The template is executed with an [ErrorReport]
+// value. If t == nil, the default template is used, which renders:
+//
+//	Error: <message>
+//
+//	<usage, if any>
+//
+//	<hint, if any>
+//
+//	Stack trace from panic:
+//	<stack, if any>
+func (e *Env) SetErrorTemplate(t *template.Template) *Env { e.errTmpl = t; return e }
+
+// newErrorReport constructs an error report for err.
+func newErrorReport(err error) ErrorReport {
+	r := ErrorReport{Err: err, Message: err.Error()}
+	var uerr UsageError
+	var perr PanicError
+	if errors.As(err, &uerr) {
+		r.Message = uerr.Message
+		if uf := uerr.Env.Command.UsageFunc; uf != nil {
+			var buf strings.Builder
+			uf(uerr.Env, &buf)
+			r.Usage = strings.TrimSpace(buf.String())
+		} else {
+			r.Usage = strings.TrimSpace(uerr.Env.Command.helpInfo(uerr.Env, uerr.Env.hflag).Usage)
+		}
+		r.Hint = uerr.Env.helpHint()
+	} else if errors.As(err, &perr) {
+		r.Stack = strings.TrimSpace(perr.Stack())
+	}
+	return r
+}
+
+// HelpHint sets whether error output for e includes a hint describing how to
+// get help for the command that failed, and returns e. The default is true.
+//
+// The hint is shown after the report of a [UsageError] by [RunOrFail], and
+// when a command is not understood. For example:
+//
+//	See 'tool help sub cmd' for more information.
+//
+// If the root command does not have a runnable "help" subcommand, the hint
+// suggests the --help flag instead.
+func (e *Env) HelpHint(show bool) *Env { e.noHint = !show; return e }
+
+// helpHint returns the help hint for e, or "" if hints are disabled.
+func (e *Env) helpHint() string {
+	if e.noHint {
+		return ""
+	}
+	root := e
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	path := e.displayPath()
+	var help string
+	if hc := root.Command.FindSubcommand("help"); hc.Runnable() && !e.multi {
+		help = joinSpace(path[0]+" help", strings.Join(path[1:], " "))
+	} else {
+		help = strings.Join(path, " ") + " --help"
+	}
+	return fmt.Sprintf("See '%s' for more information.", help)
+}
+
+// writeErrorReport renders an error report for err to e.
+func (e *Env) writeErrorReport(err error) {
+	t := e.errTmpl
+	if t == nil {
+		t = defaultErrorTemplate
+	}
+	var buf strings.Builder
+	if terr := t.Execute(&buf, newErrorReport(err)); terr != nil {
+		fmt.Fprintf(e, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprint(e, buf.String())
+}
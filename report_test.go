@@ -0,0 +1,119 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/creachadair/command"
+)
+
+// runOrFailChild is the environment variable that tells a test binary to run
+// a command with RunOrFail, as a subprocess of TestRunOrFail.
+const runOrFailChild = "COMMAND_TEST_RUN_OR_FAIL"
+
+func newReportTestCommand() *command.C {
+	return &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:  "sub",
+			Usage: "<arg>",
+			Run:   command.Adapt(func(*command.Env, string) error { return nil }),
+		}, {
+			Name: "dyn",
+			UsageFunc: func(_ *command.Env, w io.Writer) {
+				fmt.Fprintln(w, "Usage: dyn <target>\n\nTargets: alpha, beta")
+			},
+			Run: command.Adapt(func(*command.Env, string) error { return nil }),
+		}, {
+			Name: "fail",
+			Run:  func(*command.Env) error { return errors.New("it went wrong") },
+		},
+			command.HelpCommand(nil),
+		},
+	}
+}
+
+func TestRunOrFail(t *testing.T) {
+	if args := os.Getenv(runOrFailChild); args != "" {
+		env := newReportTestCommand().NewEnv(nil)
+		if rest, ok := strings.CutPrefix(args, "custom "); ok {
+			args = rest
+			env.SetErrorTemplate(template.Must(template.New("x").Parse("[{{.Message}}]\n")))
+		} else if rest, ok := strings.CutPrefix(args, "nohint "); ok {
+			args = rest
+			env.HelpHint(false)
+		}
+		command.RunOrFail(env, strings.Fields(args))
+		os.Exit(0)
+	}
+
+	tests := []struct {
+		args     string
+		wantCode int
+		want     string
+	}{
+		{"sub x", 0, ""},
+		{"sub", 2, `Error: wrong number of arguments for "sub": got 0, want 1
+
+Usage:
+
+  sub <arg>
+
+See 'tool help sub' for more information.
+`},
+		{"sub --bad x", 2, `Error: flag provided but not defined: -bad
+
+Usage:
+
+  sub <arg>
+
+See 'tool help sub' for more information.
+`},
+		{"nohint sub", 2, `Error: wrong number of arguments for "sub": got 0, want 1
+
+Usage:
+
+  sub <arg>
+`},
+		{"nonesuch", 2, `Error: tool command "nonesuch" not understood
+See 'tool help' for more information.
+`},
+		{"dyn", 2, `Error: wrong number of arguments for "dyn": got 0, want 1
+
+Usage: dyn <target>
+
+Targets: alpha, beta
+
+See 'tool help dyn' for more information.
+`},
+		{"fail", 1, "Error: it went wrong\n"},
+		{"custom fail", 1, "[it went wrong]\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.args, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestRunOrFail$")
+			cmd.Env = append(os.Environ(), runOrFailChild+"="+tc.args)
+			out, err := cmd.CombinedOutput()
+			var code int
+			if xerr := (*exec.ExitError)(nil); errors.As(err, &xerr) {
+				code = xerr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("Run child: %v", err)
+			}
+			if code != tc.wantCode {
+				t.Errorf("Exit code: got %d, want %d", code, tc.wantCode)
+			}
+			if got := string(out); got != tc.want {
+				t.Errorf("Output: got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
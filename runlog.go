@@ -0,0 +1,190 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runLogDir is the name of the directory in the state directory of the
+// program where run logs are written (see Env.LogRuns).
+const runLogDir = "logs"
+
+// LogRuns sets the number of run logs the program keeps, and returns e. The
+// default is 0, meaning no logs are written. The setting is inherited by the
+// descendants of e.
+//
+// When keep > 0, each call to [Run] writes a copy of the primary output (see
+// Stdout) and the diagnostic output of the command to a new log file in the
+// "logs" directory of the state directory of the program (see Env.StateDir),
+// followed by the command line and the result of the command. Only the keep
+// most recent logs are retained, so that when a command fails, the user can
+// find its log and send it with a report of the problem. The path of the log
+// for the current invocation is reported by LogPath.
+//
+// Logs are kept in the file system of e (see Env.FS), and the contents of a
+// log are written when the invocation completes. The values of secret flags
+// (see [SecretVar]) are redacted from the command line written to the log.
+// Failures to write the log are ignored.
+func (e *Env) LogRuns(keep int) *Env { e.logKeep = keep; return e }
+
+// LogPath returns the name of the run log for the current invocation of
+// [Run] in the file system of e (see Env.FS), or "" if no log is being
+// written (see LogRuns).
+func (e *Env) LogPath() string {
+	if log := e.invocation().log.Load(); log != nil {
+		return log.name
+	}
+	return ""
+}
+
+// runLog is the log file for an invocation of Run (see Env.LogRuns). The
+// contents of the log are collected in memory, and written when the
+// invocation completes.
+type runLog struct {
+	fsys fs.FS
+	name string
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write adds data to the log.
+func (l *runLog) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(data)
+}
+
+// teeWriter is an [io.Writer] that copies the data written to w to a log.
+type teeWriter struct {
+	w   io.Writer
+	log *runLog
+}
+
+func (t teeWriter) Write(data []byte) (int, error) {
+	n, err := t.w.Write(data)
+	t.log.Write(data[:n])
+	return n, err
+}
+
+// teeLog returns w, or if v has a run log, a writer that also copies the data
+// written to w to the log. If w already copies its data to the log, it is
+// returned unchanged.
+func (v *invocation) teeLog(w io.Writer) io.Writer {
+	if log := v.log.Load(); log != nil {
+		if t, ok := w.(teeWriter); ok && t.log == log {
+			return w
+		}
+		return teeWriter{w: w, log: log}
+	}
+	return w
+}
+
+// openLog creates a new run log for the invocation of e, if run logs are
+// enabled, and removes old logs in excess of the limit.
+func (e *Env) openLog() {
+	if e.logKeep <= 0 {
+		return
+	}
+	fsys, dir := e.FS(), e.fsName(filepath.Join(e.StateDir(), runLogDir))
+	if err := MkdirAll(fsys, dir, 0700); err != nil {
+		return
+	}
+
+	// Log names sort in order of creation. Reserve the name of the new log,
+	// so that it counts toward the limit.
+	start := e.Now().UTC()
+	name := e.fsName(filepath.Join(e.StateDir(), runLogDir,
+		fmt.Sprintf("%s-%016x.log", start.Format("20060102T150405.000000000Z"), rand.Uint64())))
+	if err := WriteFile(fsys, name, nil, 0600); err != nil {
+		return
+	}
+	log := &runLog{fsys: fsys, name: name}
+	fmt.Fprintf(log, "# %s started at %s\n", e.ProgramName(), start.Format(time.RFC3339))
+	e.invocation().log.Store(log)
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return
+	}
+	var old []string
+	for _, ent := range entries {
+		if ent.Type().IsRegular() && strings.HasSuffix(ent.Name(), ".log") {
+			old = append(old, ent.Name())
+		}
+	}
+	slices.Sort(old)
+	for _, base := range old[:max(0, len(old)-e.logKeep)] {
+		Remove(fsys, e.fsName(filepath.Join(e.StateDir(), runLogDir, base)))
+	}
+}
+
+// closeLog writes the command line and the result of the invocation of e to
+// its run log, if it has one, and closes the log.
+func (e *Env) closeLog(rawArgs []string, err error) {
+	inv := e.invocation()
+	log := inv.log.Swap(nil)
+	if log == nil {
+		return
+	}
+	inv.mu.Lock()
+	reached := inv.reached
+	inv.mu.Unlock()
+	if reached == nil {
+		reached = e
+	}
+	args := redactArgs(reached, rawArgs)
+	fmt.Fprintf(log, "# command: %s\n", strings.Join(append([]string{e.ProgramName()}, args...), " "))
+	elapsed := e.Since(inv.start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(log, "# failed after %v: %v\n", elapsed, err)
+	} else {
+		fmt.Fprintf(log, "# succeeded after %v\n", elapsed)
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	WriteFile(log.fsys, log.name, log.buf.Bytes(), 0600)
+}
+
+// redactArgs returns a copy of args in which the values of the secret flags
+// of env and its ancestors are replaced by a placeholder.
+func redactArgs(env *Env, args []string) []string {
+	secret := make(map[string]bool)
+	for cur := env; cur != nil; cur = cur.Parent {
+		cur.Command.Flags.VisitAll(func(f *flag.Flag) {
+			if _, ok := unwrapValue(f.Value).(*secretValue); ok {
+				secret[f.Name] = true
+			}
+		})
+	}
+	out := slices.Clone(args)
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+		if arg == "--" {
+			break
+		} else if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !secret[name] {
+			continue
+		} else if hasValue {
+			out[i] = arg[:strings.Index(arg, "=")+1] + redactedSecret
+		} else if i+1 < len(out) {
+			i++
+			out[i] = redactedSecret
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/creachadair/command/commandtest"
+)
+
+func TestLogRuns(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var logPath string
+	newTree := func() *command.C {
+		return &command.C{
+			Name: "tool",
+			Commands: []*command.C{{
+				Name: "deploy",
+				SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+					command.SecretVar(fs, new(string), "token", "Access token")
+				},
+				Run: func(env *command.Env) error {
+					logPath = env.LogPath()
+					fmt.Fprintln(env.Stdout(), "primary output")
+					fmt.Fprintln(env, "diagnostic output")
+					return errors.New("deployment failed")
+				},
+			}},
+		}
+	}
+	var stdout strings.Builder
+	run := func(keep int, args ...string) error {
+		t.Helper()
+		env := newTree().NewEnv(nil).SetProgramName("tool").SetStdout(&stdout).LogRuns(keep)
+		env.Log = io.Discard
+		return command.Run(env, args)
+	}
+
+	// Without logging, there is no log.
+	logPath = "x"
+	if err := run(0, "deploy"); err == nil {
+		t.Fatal("Run: got nil error, want error")
+	} else if logPath != "" {
+		t.Errorf("LogPath: got %q, want empty", logPath)
+	}
+
+	if err := run(2, "deploy", "--token", "hunter2", "--token=hunter3", "arg"); err == nil {
+		t.Fatal("Run: got nil error, want error")
+
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Reading log: %v", err)
+	}
+	log := string(data)
+	for _, want := range []string{
+		"# tool started at ",
+		"primary output\n",
+		"diagnostic output\n",
+		"# command: tool deploy --token ******** --token=******** arg\n",
+		"deployment failed\n",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Log does not contain %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "hunter") {
+		t.Errorf("Log contains a secret:\n%s", log)
+	}
+	if got, want := stdout.String(), "primary output\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("Output: got %q, want %q", got, want)
+	}
+
+	// Only the most recent logs are kept.
+	first := logPath
+	for range 3 {
+		run(2, "deploy")
+	}
+	logs, err := filepath.Glob(filepath.Join(filepath.Dir(logPath), "*.log"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(logs) != 2 {
+		t.Errorf("Got %d logs, want 2: %q", len(logs), logs)
+	}
+	if _, err := os.Stat(first); err == nil {
+		t.Errorf("Old log %q was not removed", first)
+	}
+
+	// Logs are written to the file system of the environment.
+	fsys := make(commandtest.MapFS)
+	env := newTree().NewEnv(nil).SetProgramName("tool").LogRuns(1).SetFS(fsys).
+		SetEnviron(map[string]string{"XDG_STATE_HOME": "/state"})
+	env.Log = io.Discard
+	command.Run(env, []string{"deploy"})
+	if !strings.HasPrefix(logPath, "state/tool/logs/") {
+		t.Errorf("LogPath: got %q, want a name in state/tool/logs", logPath)
+	} else if f, ok := fsys[logPath]; !ok || !strings.Contains(string(f.Data), "# command: tool deploy\n") {
+		t.Errorf("Log %q is missing or incomplete", logPath)
+	}
+}
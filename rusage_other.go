@@ -0,0 +1,12 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package command
+
+import "time"
+
+// processUsage reports the user and system CPU time consumed by the process,
+// and its peak resident set size in bytes. On this platform the usage is not
+// available, and it reports false.
+func processUsage() (user, sys time.Duration, maxRSS int64, ok bool) { return 0, 0, 0, false }
@@ -0,0 +1,25 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package command
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// processUsage reports the user and system CPU time consumed by the process,
+// and its peak resident set size in bytes.
+func processUsage() (user, sys time.Duration, maxRSS int64, ok bool) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0, 0, false
+	}
+	maxRSS = int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" {
+		maxRSS *= 1024 // reported in KiB
+	}
+	return time.Duration(ru.Utime.Nano()), time.Duration(ru.Stime.Nano()), maxRSS, true
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrSecretNotFound is reported by a [SecretStore] when the requested secret
+// does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// A SecretStore stores and retrieves named secrets, such as access tokens or
+// credentials. Implementations backed by a platform keychain can be installed
+// on an [Env] using its SetSecrets method.
+type SecretStore interface {
+	// Get returns the value of the secret with the given name.
+	// If no such secret exists, Get must report [ErrSecretNotFound].
+	Get(name string) ([]byte, error)
+
+	// Put stores value as the secret with the given name, replacing any
+	// previous value.
+	Put(name string, value []byte) error
+
+	// Delete removes the secret with the given name. Deleting a secret that
+	// does not exist is not an error.
+	Delete(name string) error
+}
+
+// FileSecrets is a [SecretStore] that stores each secret as a separate file in
+// a directory. The directory and files are created with permissions that
+// restrict access to the current user.
+type FileSecrets struct {
+	Dir string // the directory where secrets are stored
+}
+
+// Get implements part of the [SecretStore] interface.
+func (f FileSecrets) Get(name string) ([]byte, error) {
+	path, err := f.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("get %q: %w", name, ErrSecretNotFound)
+	}
+	return data, err
+}
+
+// Put implements part of the [SecretStore] interface.
+func (f FileSecrets) Put(name string, value []byte) error {
+	path, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	// Write the new value to a temporary file and rename it into place, so
+	// that a failed write does not clobber the existing value.
+	tmp, err := os.CreateTemp(f.Dir, "."+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op on success
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete implements part of the [SecretStore] interface.
+func (f FileSecrets) Delete(name string) error {
+	path, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f FileSecrets) path(name string) (string, error) {
+	if name == "" || name[0] == '.' || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	return filepath.Join(f.Dir, name), nil
+}
+
+// Secrets returns the secret store associated with e. If no store has been set
+// by SetSecrets, it returns a [FileSecrets] value rooted in a "secrets"
+// directory under the configuration directory for the program (see
+// Env.ConfigDir).
+func (e *Env) Secrets() SecretStore {
+	if e.secrets != nil {
+		return e.secrets
+	}
+	return FileSecrets{Dir: filepath.Join(e.ConfigDir(), "secrets")}
+}
+
+// SetSecrets sets the secret store for e and returns e. If s == nil, it
+// restores the default store (see Secrets). The setting is inherited by
+// the descendants of e.
+func (e *Env) SetSecrets(s SecretStore) *Env { e.secrets = s; return e }
+
+// SecretVar defines a flag on fs with the specified name and usage, for a
+// secret value such as an access token. The initial value of *p is the
+// default. The value of the flag names a source for the secret:
+//
+//	env:NAME   the value of environment variable NAME
+//	file:PATH  the contents of the file at PATH, without a trailing newline
+//	prompt     a line read from the terminal, without echo where supported
+//	otherwise  the value itself
+//
+// The secret is never shown: Help output does not show its default, and
+// the flag reports its value as "********" if it is set (for example, to
+// Explain), and as "" otherwise. Errors from the flag name the source of the
+// secret, but not the secret. A literal secret that begins with one of the
+// prefixes above must be given via another source, such as a file.
+//
+// A source other than the value itself is resolved when the command is run
+// (see [Run]), after its flags are parsed and before its Init and Run
+// functions are called. Environment variables are read from the environment
+// of the command (see Env.SetEnviron), and files from its file system (see
+// Env.SetFS). If a source cannot be read, Run reports a [UsageError] naming
+// the flag. The prompt is written to the diagnostic output of the command, and
+// reads a line from its input (see Env.SetStdin). If the input is a file, it
+// must be a terminal, and echo is disabled where supported. Sources are not
+// resolved by [Resolve] or [Explain], which report the value of the flag as
+// "********".
+func SecretVar(fs *flag.FlagSet, p *string, name, usage string) {
+	fs.Var(&secretValue{p: p, name: name}, name, usage)
+	DefaultText(fs, name, "")
+}
+
+// redactedSecret is the string reported for a secret flag that is set.
+const redactedSecret = "********"
+
+// secretValue is a [flag.Value] for a secret (see SecretVar).
+type secretValue struct {
+	p      *string
+	name   string // the name of the flag, for the prompt
+	source string // a source to be resolved (see resolveSecrets)
+}
+
+func (s *secretValue) String() string {
+	if s == nil || s.p == nil || (*s.p == "" && s.source == "") {
+		return ""
+	}
+	return redactedSecret
+}
+
+func (s *secretValue) Set(text string) error {
+	if strings.HasPrefix(text, "env:") || strings.HasPrefix(text, "file:") || text == "prompt" {
+		s.source = text
+	} else {
+		*s.p, s.source = text, ""
+	}
+	return nil
+}
+
+// resolve reads the secret from the pending source of s, if any, using the
+// environment and file system of env.
+func (s *secretValue) resolve(env *Env) error {
+	text := s.source
+	s.source = ""
+	switch {
+	case strings.HasPrefix(text, "env:"):
+		name := strings.TrimPrefix(text, "env:")
+		v, ok := env.LookupEnv(name)
+		if !ok {
+			return fmt.Errorf("environment variable %s is not set", name)
+		}
+		*s.p = v
+	case strings.HasPrefix(text, "file:"):
+		data, err := fs.ReadFile(env.FS(), strings.TrimPrefix(text, "file:"))
+		if err != nil {
+			return err
+		}
+		*s.p = strings.TrimRight(string(data), "\r\n")
+	case text == "prompt":
+		in := env.Stdin()
+		f, isFile := in.(*os.File)
+		if isFile && !isTerminal(f) {
+			return errors.New("cannot prompt: input is not a terminal")
+		}
+		fmt.Fprintf(env, "Enter value for --%s: ", s.name)
+		var v string
+		var err error
+		if isFile {
+			v, err = readNoEcho(f)
+		} else {
+			v, err = readLine(in)
+		}
+		fmt.Fprintln(env)
+		if err != nil {
+			return err
+		}
+		*s.p = v
+	}
+	return nil
+}
+
+// resolveSecrets reads the secret flags of the command for e whose values
+// name a source (see SecretVar), and reports a [UsageError] naming each flag
+// whose source cannot be read.
+func (e *Env) resolveSecrets() error {
+	var problems []string
+	e.Command.Flags.VisitAll(func(f *flag.Flag) {
+		if s, ok := unwrapValue(f.Value).(*secretValue); ok && s.source != "" {
+			if err := s.resolve(e); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid value for flag --%s: %v", f.Name, err))
+			}
+		}
+	})
+	if len(problems) != 0 {
+		return e.Usagef("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *secretValue) Get() any { return *s.p }
+
+// restart discards a source that was not resolved, for example by Resolve, so
+// that it is not resolved by a later invocation (see restartFlags).
+func (s *secretValue) restart() { s.source = "" }
+
+func (s *secretValue) typeName() string { return "secret" }
+
+// rawValue and setRaw allow the secret to be saved and restored by
+// SnapshotFlags and RestoreFlags, which cannot use String.
+func (s *secretValue) rawValue() any { return *s.p }
+func (s *secretValue) setRaw(v any)  { *s.p, s.source = v.(string), "" }
+
+// readLine reads a line of input from r, one byte at a time so that no input
+// after the line is consumed. The line is returned without its trailing
+// newline.
+func readLine(r io.Reader) (string, error) {
+	var buf []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			buf = append(buf, b[0])
+		}
+		if err == io.EOF && len(buf) != 0 {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(buf), "\r"), nil
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/creachadair/command"
+)
+
+func TestFileSecrets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	env := (&command.C{Name: "test"}).NewEnv(nil).SetSecrets(command.FileSecrets{Dir: dir})
+	s := env.Secrets()
+
+	if _, err := s.Get("token"); !errors.Is(err, command.ErrSecretNotFound) {
+		t.Errorf("Get missing: got %v, want %v", err, command.ErrSecretNotFound)
+	}
+	if err := s.Put("token", []byte("hunter2")); err != nil {
+		t.Fatalf("Put: unexpected error: %v", err)
+	}
+	if got, err := s.Get("token"); err != nil || string(got) != "hunter2" {
+		t.Errorf("Get: got %q, %v; want hunter2, nil", got, err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "token")); err != nil {
+		t.Errorf("Stat: %v", err)
+	} else if m := fi.Mode().Perm(); m&0077 != 0 {
+		t.Errorf("Secret file mode is %v, want user-only", m)
+	}
+
+	for _, bad := range []string{"", ".", "..", "../x", "a/b", ".hidden"} {
+		if err := s.Put(bad, nil); err == nil {
+			t.Errorf("Put %q: got nil, want error", bad)
+		}
+	}
+
+	if err := s.Delete("token"); err != nil {
+		t.Errorf("Delete: unexpected error: %v", err)
+	}
+	if err := s.Delete("token"); err != nil {
+		t.Errorf("Delete again: unexpected error: %v", err)
+	}
+	if _, err := s.Get("token"); !errors.Is(err, command.ErrSecretNotFound) {
+		t.Errorf("Get deleted: got %v, want %v", err, command.ErrSecretNotFound)
+	}
+}
+
+func TestSecretVar(t *testing.T) {
+	const secret = "hunter2"
+
+	// Sources are resolved using the environment and file system of the Env.
+	environ := map[string]string{"TEST_TOKEN": secret}
+	fsys := fstest.MapFS{"keys/token": {Data: []byte(secret + "\n")}}
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{secret, secret, false},
+		{"env:TEST_TOKEN", secret, false},
+		{"env:NONESUCH_TOKEN", "", true},
+		{"file:keys/token", secret, false},
+		{"file:keys/missing", "", true},
+		{"prompt", secret, false},
+	}
+	for _, tc := range tests {
+		var got string
+		root := &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.SecretVar(fs, &got, "token", "Access token")
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+		env := root.NewEnv(nil).SetEnviron(environ).SetFS(fsys).SetStdin(strings.NewReader(secret + "\n"))
+		var log strings.Builder
+		env.Log = &log
+		err := command.Run(env, []string{"--token", tc.input})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Run %q: got error %v, want error %v", tc.input, err, tc.wantErr)
+		} else if err != nil {
+			if !command.IsUsage(err) || !strings.Contains(err.Error(), "--token") {
+				t.Errorf("Run %q: got %v, want usage error naming --token", tc.input, err)
+			}
+		} else if got != tc.want {
+			t.Errorf("Run %q: got %q, want %q", tc.input, got, tc.want)
+		}
+		if prompted := strings.Contains(log.String(), "Enter value for --token"); prompted != (tc.input == "prompt") {
+			t.Errorf("Run %q: prompted is %v, log:\n%s", tc.input, prompted, log.String())
+		}
+	}
+
+	// Resolve does not read sources, and an unread source is not read by a
+	// later run of the same tree.
+	var got string
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			command.SecretVar(fs, &got, "token", "Access token")
+		},
+		Run: func(*command.Env) error { return nil },
+	}
+	env := root.NewEnv(nil).SetEnviron(environ).SetStdin(strings.NewReader(""))
+	for _, src := range []string{"env:TEST_TOKEN", "prompt"} {
+		if _, err := command.Resolve(env, []string{"--token", src}); err != nil {
+			t.Errorf("Resolve %q: unexpected error: %v", src, err)
+		} else if got != "" {
+			t.Errorf("Resolve %q: got %q, want empty", src, got)
+		}
+	}
+	if err := command.Run(env, nil); err != nil {
+		t.Errorf("Run after Resolve: unexpected error: %v", err)
+	} else if got != "" {
+		t.Errorf("Run after Resolve: got %q, want empty", got)
+	}
+
+	// The secret does not appear in help, or in the value of the flag.
+	token := secret
+	root = &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			command.SecretVar(fs, &token, "token", "Access token")
+		},
+		Run: func(env *command.Env) error { return nil },
+	}
+	env = root.NewEnv(nil)
+	if err := command.Run(env, nil); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	help := renderHelp(root, 0)
+	if !strings.Contains(help, "--token secret") {
+		t.Errorf("Help does not describe the flag:\n%s", help)
+	} else if strings.Contains(help, secret) {
+		t.Errorf("Help contains the secret:\n%s", help)
+	}
+	if got, want := root.Flags.Lookup("token").Value.String(), "********"; got != want {
+		t.Errorf("Flag value: got %q, want %q", got, want)
+	}
+
+	// The secret survives a snapshot.
+	snap := env.SnapshotFlags()
+	root.Flags.Set("token", "other")
+	if err := env.RestoreFlags(snap); err != nil {
+		t.Fatalf("RestoreFlags: unexpected error: %v", err)
+	}
+	if token != secret {
+		t.Errorf("After restore: got %q, want %q", token, secret)
+	}
+}
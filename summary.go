@@ -0,0 +1,58 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"strings"
+	"time"
+)
+
+// A Summary describes the result of a successful invocation of [Run], for use
+// by a summary hook (see Env.SetSummaryHook).
+type Summary struct {
+	Command string        // the path of the command that set the summary
+	Elapsed time.Duration // the elapsed wall time of the invocation
+	Value   any           // the value passed to SetSummary
+}
+
+// SetSummary records v as the result of the current invocation of [Run]. If
+// the invocation succeeds, the summary hook of the environment (if any) is
+// called with v when Run completes (see SetSummaryHook). If SetSummary is
+// called more than once, the last value is used.
+//
+// This allows commands to report results, such as a count of items
+// processed, that the application presents in a consistent way.
+func (e *Env) SetSummary(v any) {
+	inv := e.invocation()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.summary = &Summary{Command: strings.Join(e.path(), " "), Value: v}
+	inv.sumEnv = e
+}
+
+// SetSummaryHook sets a function to be called when a call to [Run] with e
+// succeeds after a command has called SetSummary, and returns e. The hook is
+// called with the environment of the command that set the summary, after the
+// Shutdown hook and before any functions registered by Defer. For example:
+//
+//	env.SetSummaryHook(func(env *command.Env, s command.Summary) {
+//	   fmt.Fprintf(env, "Done in %v, %d items processed\n",
+//	      s.Elapsed.Round(time.Millisecond), s.Value)
+//	})
+//
+// If f == nil, no hook is called. The setting is inherited by the descendants
+// of e.
+func (e *Env) SetSummaryHook(f func(env *Env, s Summary)) *Env { e.onSummary = f; return e }
+
+// summarize calls the summary hook, if a summary was set for the invocation
+// and the environment that set it has a hook.
+func (v *invocation) summarize() {
+	v.mu.Lock()
+	s, senv := v.summary, v.sumEnv
+	v.mu.Unlock()
+	if s == nil || senv.onSummary == nil {
+		return
+	}
+	s.Elapsed = senv.Since(v.start)
+	senv.onSummary(senv, *s)
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestSummary(t *testing.T) {
+	var failed bool
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "process",
+			Run: func(env *command.Env) error {
+				env.SetSummary(14)
+				if failed {
+					return errors.New("failed")
+				}
+				return nil
+			},
+		}, {
+			Name: "quiet",
+			Run:  func(*command.Env) error { return nil },
+		}},
+	}
+
+	var buf strings.Builder
+	env := root.NewEnv(nil).SetSummaryHook(func(env *command.Env, s command.Summary) {
+		if s.Elapsed <= 0 {
+			t.Errorf("Summary elapsed time is %v, want positive", s.Elapsed)
+		}
+		fmt.Fprintf(env, "%s: done, %v items processed\n", s.Command, s.Value)
+	})
+	env.Log = &buf
+
+	if err := command.Run(env, []string{"process"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "tool process: done, 14 items processed\n"; got != want {
+		t.Errorf("Summary: got %q, want %q", got, want)
+	}
+
+	// A command that does not set a summary does not call the hook.
+	buf.Reset()
+	if err := command.Run(env, []string{"quiet"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "" {
+		t.Errorf("Summary: got %q, want none", got)
+	}
+
+	// A failed invocation does not call the hook.
+	failed = true
+	if err := command.Run(env, []string{"process"}); err == nil {
+		t.Fatal("Run: got nil, want error")
+	}
+	if got := buf.String(); got != "" {
+		t.Errorf("Summary: got %q, want none", got)
+	}
+}
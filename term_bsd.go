@@ -0,0 +1,34 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package command
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGETA, uintptr(unsafe.Pointer(&t)))
+	return errno == 0
+}
+
+// readNoEcho reads a line of input from f, a terminal, with echo disabled.
+// The line is returned without its trailing newline.
+func readNoEcho(f *os.File) (string, error) {
+	var old syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGETA, uintptr(unsafe.Pointer(&old))); errno != 0 {
+		return "", errno
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCSETA, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return "", errno
+	}
+	defer syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCSETA, uintptr(unsafe.Pointer(&old)))
+	return readLine(f)
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package command
+
+import "os"
+
+// isTerminal reports whether f appears to be a terminal. On this platform,
+// any character device is treated as a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readNoEcho reads a line of input from f, a terminal. On this platform,
+// echo is not disabled.
+func readNoEcho(f *os.File) (string, error) { return readLine(f) }
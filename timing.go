@@ -0,0 +1,121 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFlag registers a private --time flag on fs. When the flag is set, Run
+// prints a summary of the resources used by the invocation to the diagnostic
+// output of the environment when the command completes. The summary includes
+// the elapsed wall time and, where the platform supports it, the user and
+// system CPU time and the peak memory use of the process, followed by the
+// time spent in the Startup, Init, and Run functions of each command.
+//
+// The flag applies only to the invocation of [Run] in which it is given; it
+// does not remain set for later invocations of the same command tree.
+//
+// TimeFlag is suitable for use as the SetFlags field of a [C], or may be
+// called from another SetFlags function. It is most useful on the root.
+func TimeFlag(_ *Env, fs *flag.FlagSet) {
+	fs.Var(new(timeFlag), "time", "Print a summary of time and memory use on exit")
+	HideFlags(fs, "time")
+}
+
+// timeFlag is a [flag.Value] for a boolean flag that enables timing.
+type timeFlag bool
+
+func (t *timeFlag) String() string   { return strconv.FormatBool(bool(*t)) }
+func (t *timeFlag) IsBoolFlag() bool { return true }
+func (t *timeFlag) Get() any         { return bool(*t) }
+
+func (t *timeFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*t = timeFlag(v)
+	return nil
+}
+
+// checkTimeFlag enables timing for the current invocation if the command for
+// e has a --time flag registered by TimeFlag, and it is set. The flag is reset
+// once it is read, so that it does not carry over to later invocations.
+// Outside a call to Run (for example in Resolve), the flag is reset without
+// enabling timing.
+func (e *Env) checkTimeFlag() {
+	if f := e.Command.Flags.Lookup("time"); f != nil {
+		if t, ok := unwrapValue(f.Value).(*timeFlag); ok && bool(*t) {
+			*t = false
+			inv := e.invocation()
+			inv.mu.Lock()
+			defer inv.mu.Unlock()
+			if inv.active {
+				inv.timed = true
+			}
+		}
+	}
+}
+
+// A phaseTime records the time spent in one phase of an invocation.
+type phaseTime struct {
+	name    string
+	elapsed time.Duration
+}
+
+// timePhase begins timing the named phase of the command for e, and returns
+// a function that ends it. If timing is not enabled, no time is recorded.
+func (e *Env) timePhase(name string) func() {
+	inv := e.invocation()
+	inv.mu.Lock()
+	timed := inv.timed
+	inv.mu.Unlock()
+	if !timed {
+		return func() {}
+	}
+	label := name + " " + strings.Join(e.path(), " ")
+	start := e.Now()
+	return func() {
+		elapsed := e.Since(start)
+		inv.mu.Lock()
+		defer inv.mu.Unlock()
+		inv.phases = append(inv.phases, phaseTime{name: label, elapsed: elapsed})
+	}
+}
+
+// writeTimings writes a summary of resource usage to env, if timing was
+// enabled for the invocation.
+func (v *invocation) writeTimings(env *Env) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.timed {
+		return
+	}
+	usage := []phaseTime{{"wall time", env.Since(v.start)}}
+	user, sys, maxRSS, ok := processUsage()
+	if ok {
+		usage = append(usage, phaseTime{"user time", user}, phaseTime{"system time", sys})
+	}
+
+	width := len("peak memory")
+	for _, r := range append(usage, v.phases...) {
+		width = max(width, len(r.name))
+	}
+	row := func(name string, value any) { fmt.Fprintf(env, "  %-*s  %v\n", width, name, value) }
+
+	fmt.Fprintln(env, "Timing:")
+	for _, r := range usage {
+		row(r.name, r.elapsed.Round(time.Microsecond))
+	}
+	if ok {
+		row("peak memory", byteSize(maxRSS))
+	}
+	for _, r := range v.phases {
+		row(r.name, r.elapsed.Round(time.Microsecond))
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestTimeFlag(t *testing.T) {
+	root := &command.C{
+		Name:     "tool",
+		SetFlags: command.TimeFlag,
+		Startup:  func(*command.Env) error { return nil },
+		Init:     func(*command.Env) error { return nil },
+		Commands: []*command.C{{
+			Name: "sub",
+			Run:  func(*command.Env) error { return nil },
+		}},
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var log strings.Builder
+		env := root.NewEnv(nil)
+		env.Log = &log
+		if err := command.Run(env, args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+		return log.String()
+	}
+
+	out := run("--time", "sub")
+	for _, want := range []string{"Timing:", "wall time", "startup tool", "init tool", "run tool sub"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Timing summary is missing %q:\n%s", want, out)
+		}
+	}
+
+	// The flag does not remain set for later runs.
+	if out := run("sub"); out != "" {
+		t.Errorf("Without --time: got output %q, want none", out)
+	}
+
+	// Resolving a command line with --time does not enable timing for a later
+	// run of the same environment.
+	env := root.NewEnv(nil)
+	var log strings.Builder
+	env.Log = &log
+	if _, err := command.Resolve(env, []string{"--time", "sub"}); err != nil {
+		t.Fatalf("Resolve: unexpected error: %v", err)
+	}
+	if err := command.Run(env, []string{"sub"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if out := log.String(); out != "" {
+		t.Errorf("Run after Resolve --time: got output %q, want none", out)
+	}
+	if h := root.HelpInfo(0); strings.Contains(h.Flags, "-time") {
+		t.Errorf("The --time flag is listed in help:\n%s", h.Flags)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"cmp"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// usageFile is the name of the file in the state directory of the program
+// that records usage counts (see Env.TrackUsage).
+const usageFile = "usage.json"
+
+// mostUsedLimit is the maximum number of commands listed as most used in
+// help output.
+const mostUsedLimit = 5
+
+// TrackUsage sets whether the program records how often each of its commands
+// is run, and returns e. The default is false. The setting is inherited by
+// the descendants of e.
+//
+// When this is enabled, each time the Run function of a command is called,
+// unless it reports a usage error or a request for help, the count for the
+// command is incremented in a file in the state directory
+// of the program (see Env.StateDir). The long help of a command with
+// subcommands then begins with the most used of its descendants, completion
+// offers the most used subcommands first (see [Complete]), and the counts are
+// available from UsageCounts.
+// Failures to read or update the counts are ignored.
+func (e *Env) TrackUsage(enable bool) *Env { e.usage = enable; return e }
+
+// UsageCounts returns the number of times each command of the program has
+// been run, as recorded when usage tracking is enabled (see TrackUsage). The
+// keys are the paths of the commands below the root, separated by spaces, for
+// example "remote add". If no counts are recorded, it returns nil.
+func (e *Env) UsageCounts() map[string]int {
+	data, err := e.readFile(filepath.Join(e.StateDir(), usageFile))
+	if err != nil {
+		return nil
+	}
+	var counts map[string]int
+	if json.Unmarshal(data, &counts) != nil {
+		return nil
+	}
+	return counts
+}
+
+// recordUsage increments the usage count for the command of e, if usage
+// tracking is enabled.
+func (e *Env) recordUsage() {
+	if !e.usage || e.Parent == nil {
+		return
+	}
+	counts := e.UsageCounts()
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[strings.Join(e.path()[1:], " ")]++
+	if data, err := json.Marshal(counts); err == nil {
+		e.writeFileAtomic(filepath.Join(e.StateDir(), usageFile), data)
+	}
+}
+
+// rankByUsage sorts names, the names of subcommands of the command of e, in
+// decreasing order of use, if usage tracking is enabled. The use of a
+// subcommand includes the use of its descendants. Names with equal counts
+// keep their order.
+func (e *Env) rankByUsage(names []string) {
+	if !e.usage || len(names) < 2 {
+		return
+	}
+	base := strings.Join(append(e.path()[1:], ""), " ")
+	total := make(map[string]int)
+	for path, n := range e.UsageCounts() {
+		if rest, ok := strings.CutPrefix(path, base); ok && n > 0 {
+			name, _, _ := strings.Cut(rest, " ")
+			total[name] += n
+		}
+	}
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(total[b], total[a])
+	})
+}
+
+// mostUsed returns help for the most used descendants of the command of e,
+// in decreasing order of use, if usage tracking is enabled. The names of the
+// results are the paths of the commands relative to e.
+func (e *Env) mostUsed(flags HelpFlags) []HelpInfo {
+	if !e.usage {
+		return nil
+	}
+	base := strings.Join(e.path()[1:], " ")
+	type entry struct {
+		path  string
+		count int
+	}
+	var top []entry
+	for path, n := range e.UsageCounts() {
+		if n <= 0 {
+			continue
+		}
+		if base != "" {
+			rest, ok := strings.CutPrefix(path, base+" ")
+			if !ok {
+				continue
+			}
+			path = rest
+		}
+		top = append(top, entry{path, n})
+	}
+	slices.SortFunc(top, func(a, b entry) int {
+		if c := cmp.Compare(b.count, a.count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.path, b.path)
+	})
+
+	var out []HelpInfo
+	for _, t := range top {
+		// Skip commands that are no longer in the tree, or not listed.
+		cur := e
+		for _, name := range strings.Fields(t.path) {
+			sub := cur.Command.FindSubcommand(name)
+			if sub == nil || !sub.Supported() || (sub.Unlisted && !flags.wantUnlisted()) {
+				cur = nil
+				break
+			}
+			cur = cur.newChild(sub, nil)
+		}
+		if cur == nil {
+			continue
+		}
+		h := cur.Command.helpInfo(cur, flags&^IncludeCommands)
+		h.Name = t.path
+		out = append(out, h)
+		if len(out) == mostUsedLimit {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrackUsage(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	run := func(*command.Env) error { return nil }
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name: "remote",
+			Help: "Manage remotes.",
+			Commands: []*command.C{
+				{Name: "add", Help: "Add a remote.", Run: run},
+				{Name: "list", Help: "List remotes.", Run: run},
+			},
+		}, {
+			Name: "status", Help: "Show status.", Run: run,
+		}, {
+			Name: "secret", Help: "Hidden.", Unlisted: true, Run: run,
+		},
+			command.HelpCommand(nil),
+		},
+	}
+	newEnv := func(track bool) *command.Env {
+		return root.NewEnv(nil).SetProgramName("tool").TrackUsage(track)
+	}
+	for _, args := range [][]string{
+		{"status"}, {"remote", "add"}, {"remote", "list"}, {"remote", "list"},
+		{"secret"}, {"remote", "list"},
+	} {
+		if err := command.Run(newEnv(true), args); err != nil {
+			t.Fatalf("Run %q: unexpected error: %v", args, err)
+		}
+	}
+	// Runs without tracking are not counted.
+	if err := command.Run(newEnv(false), []string{"status"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	}
+
+	want := map[string]int{"status": 1, "remote add": 1, "remote list": 3, "secret": 1}
+	if diff := cmp.Diff(want, newEnv(false).UsageCounts()); diff != "" {
+		t.Errorf("UsageCounts (-want, +got):\n%s", diff)
+	}
+
+	// Completion offers the most used subcommands first, counting the use of
+	// their descendants, but only when usage is tracked.
+	for _, tc := range []struct {
+		track bool
+		words []string
+		want  []string
+	}{
+		{true, []string{""}, []string{"remote", "status", "help"}},
+		{true, []string{"remote", ""}, []string{"list", "add"}},
+		{false, []string{"remote", ""}, []string{"add", "list"}},
+	} {
+		got := command.Complete(newEnv(tc.track), tc.words)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("Complete %q (track=%v) (-want, +got):\n%s", tc.words, tc.track, diff)
+		}
+	}
+
+	help := func(track bool, path ...string) string {
+		t.Helper()
+		return captureStdout(t, func() {
+			command.Run(newEnv(track), append([]string{"help"}, path...))
+		})
+	}
+
+	const rootWant = `Most used:
+  tool remote list :   List remotes.
+  tool remote add  :   Add a remote.
+  tool status      :   Show status.
+`
+	if got := help(true); !strings.Contains(got, rootWant) {
+		t.Errorf("Root help does not contain:\n%s\ngot:\n%s", rootWant, got)
+	}
+	const subWant = `Most used:
+  remote list :   List remotes.
+  remote add  :   Add a remote.
+`
+	if got := help(true, "remote"); !strings.Contains(got, subWant) {
+		t.Errorf("Subcommand help does not contain:\n%s\ngot:\n%s", subWant, got)
+	}
+	if got := help(false); strings.Contains(got, "Most used") {
+		t.Errorf("Help without tracking lists usage:\n%s", got)
+	}
+
+	// The HelpInfo of a command does not depend on the recorded counts.
+	if h := root.HelpInfo(command.IncludeCommands); len(h.MostUsed) != 0 {
+		t.Errorf("HelpInfo: got MostUsed %+v, want none", h.MostUsed)
+	}
+}
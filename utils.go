@@ -5,7 +5,10 @@ package command
 import (
 	"flag"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Flags returns a SetFlags function that calls bind(fs, v) for each v and the
@@ -18,6 +21,50 @@ func Flags(bind func(*flag.FlagSet, any), vs ...any) func(*Env, *flag.FlagSet) {
 	}
 }
 
+// TimeoutFlag registers a --timeout flag on fs that overrides the Timeout
+// field of the command for env when it is set. The Timeout field is not
+// modified, and the value of the flag applies only to the run in which it is
+// given. It is suitable for use as the SetFlags field of a [C], or may be
+// called from another SetFlags function.
+func TimeoutFlag(env *Env, fs *flag.FlagSet) {
+	fs.Var(&timeoutValue{d: env.Command.Timeout, def: env.Command.Timeout}, "timeout",
+		"Time limit for the command to complete (0 means no limit)")
+}
+
+// timeoutValue is a [flag.Value] for a command timeout (see TimeoutFlag).
+type timeoutValue struct {
+	d, def time.Duration
+	set    bool // whether the flag was set since its value was last taken
+}
+
+func (t *timeoutValue) String() string   { return t.d.String() }
+func (t *timeoutValue) Get() any         { return t.d }
+func (t *timeoutValue) typeName() string { return "duration" }
+
+func (t *timeoutValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	t.d, t.set = d, true
+	return nil
+}
+
+// checkTimeoutFlag returns the timeout for the command of e: the value of its
+// --timeout flag registered by TimeoutFlag if that was set, otherwise the
+// Timeout field of the command. The flag is reset to its default, so that its
+// value does not carry over to a later run of the command.
+func (e *Env) checkTimeoutFlag() time.Duration {
+	if f := e.Command.Flags.Lookup("timeout"); f != nil {
+		if t, ok := unwrapValue(f.Value).(*timeoutValue); ok && t.set {
+			d := t.d
+			t.d, t.set = t.def, false
+			return d
+		}
+	}
+	return e.Command.Timeout
+}
+
 // usageLines parses and normalizes usage lines. The command name is stripped
 // from the head of each line if it is present.
 func (c *C) usageLines(flags HelpFlags) []string {
@@ -67,9 +114,14 @@ func indent(first, prefix, text string) string {
 }
 
 // FailWithUsage is a run function that logs a usage message for the command
-// and returns [ErrRequestHelp].
+// and returns [ErrRequestHelp]. If the command has a UsageFunc, it is used to
+// write the message.
 func FailWithUsage(env *Env) error {
-	env.Command.HelpInfo(0).WriteUsage(env)
+	if uf := env.Command.UsageFunc; uf != nil {
+		uf(env, env)
+	} else {
+		env.Command.helpInfo(env, 0).WriteUsage(env)
+	}
 	return ErrRequestHelp
 }
 
@@ -77,10 +129,10 @@ func FailWithUsage(env *Env) error {
 // and their arguments matched by fs, the second containing all the other free
 // arguments. Flag values are not parsed. Flag-shaped strings not matched by fs
 // are treated as free arguments.  An error is reported if a flag lacks its
-// argument.
+// argument. The argument "--" and all the arguments after it are free.
 func splitFlags(fs *flag.FlagSet, args []string) (flags, free []string, _ error) {
 	var wantArg bool
-	for _, s := range args {
+	for i, s := range args {
 		// Case 1: The previous argument is a flag that needs a value.
 		if wantArg {
 			flags = append(flags, s)
@@ -88,8 +140,14 @@ func splitFlags(fs *flag.FlagSet, args []string) (flags, free []string, _ error)
 			continue
 		}
 
-		// Treat "-" and "--" as free arguments to simplify the logic below.
-		if s == "-" || s == "--" {
+		// The argument "--" ends flag processing.
+		if s == "--" {
+			free = append(free, args[i:]...)
+			break
+		}
+
+		// Treat "-" as a free argument to simplify the logic below.
+		if s == "-" {
 			free = append(free, s)
 			continue
 		}
@@ -124,6 +182,186 @@ func splitFlags(fs *flag.FlagSet, args []string) (flags, free []string, _ error)
 	return flags, free, nil
 }
 
+// expandShortFlags returns a copy of args in which clusters of single-letter
+// flags defined by fs are split into separate arguments, and single-letter
+// flags with attached values are separated from their values, as described
+// by the PflagStyle method of [Env].
+func expandShortFlags(fs *flag.FlagSet, args []string) []string {
+	var out []string
+	var wantArg bool
+	for i, s := range args {
+		if s == "--" {
+			return append(out, args[i:]...)
+		} else if wantArg {
+			out = append(out, s) // the value of the previous flag
+			wantArg = false
+			continue
+		}
+		rest, ok := strings.CutPrefix(s, "-")
+		name, _, hasValue := strings.Cut(rest, "=")
+		if !ok || strings.HasPrefix(rest, "-") || len(rest) == 0 {
+			out = append(out, s)
+			continue
+		} else if f := fs.Lookup(name); f != nil {
+			out = append(out, s) // an ordinary flag
+			wantArg = !hasValue && !isBoolFlag(f)
+			continue
+		}
+
+		// Check whether rest is a cluster of single-letter flags, possibly
+		// ending with a flag that has an attached value.
+		var split []string
+		for j, r := range rest {
+			f := fs.Lookup(string(r))
+			if f == nil {
+				split = nil
+				break
+			} else if isBoolFlag(f) {
+				split = append(split, "-"+string(r))
+				continue
+			}
+			split = append(split, "-"+string(r))
+			if val := rest[j+len(string(r)):]; val != "" {
+				split = append(split, strings.TrimPrefix(val, "="))
+			} else {
+				wantArg = true
+			}
+			break
+		}
+		if split == nil {
+			out = append(out, s) // not a cluster; leave it alone
+		} else {
+			out = append(out, split...)
+		}
+	}
+	return out
+}
+
+// negateBoolFlags returns a copy of args in which each argument "--no-name"
+// before "--", where name is a Boolean flag of fs and "no-name" is not a flag
+// of fs, is replaced by "--name=false", as described by the BoolNegation
+// method of [Env].
+func negateBoolFlags(fs *flag.FlagSet, args []string) []string {
+	out := slices.Clone(args)
+	var wantArg bool
+	for i, s := range out {
+		if wantArg {
+			wantArg = false // the value of the previous flag
+			continue
+		} else if s == "--" {
+			break
+		}
+		rest, ok := strings.CutPrefix(s, "-")
+		if !ok {
+			continue
+		}
+		rest = strings.TrimPrefix(rest, "-") // accept -name or --name
+		name, _, hasValue := strings.Cut(rest, "=")
+		if f := fs.Lookup(name); f != nil {
+			wantArg = !hasValue && !isBoolFlag(f)
+		} else if base, ok := strings.CutPrefix(name, "no-"); ok && !hasValue {
+			if f := fs.Lookup(base); f != nil && isBoolFlag(f) {
+				out[i] = "--" + base + "=false"
+			}
+		}
+	}
+	return out
+}
+
+// splitUnknownFlags separates from args the flag arguments that are not
+// defined by fs, which would cause parsing args with fs to fail. It returns
+// the remaining arguments and the undefined flags, in their original order.
+// Only the flags that precede the first free argument are considered, as in
+// parsing. If keepHelp is true, the help flags are kept, since parsing
+// handles them. If numbers is true, negative numbers are free arguments.
+func splitUnknownFlags(fs *flag.FlagSet, args []string, keepHelp, numbers bool) (kept, unknown []string) {
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if s == "--" || s == "-" || !strings.HasPrefix(s, "-") || (numbers && isNumberArg(fs, s)) {
+			return append(kept, args[i:]...), unknown
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(s[1:], "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && !(keepHelp && isHelpFlag(name)) {
+			unknown = append(unknown, s)
+			continue
+		}
+		kept = append(kept, s)
+		if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			kept = append(kept, args[i])
+		}
+	}
+	return kept, unknown
+}
+
+// captureUnknownFlags separates from args the flag arguments before "--"
+// that are not defined by fs, other than the help flags if keepHelp is true.
+// If numbers is true, negative numbers are not flags. It returns the
+// remaining arguments and the undefined flags, in their original order.
+func captureUnknownFlags(fs *flag.FlagSet, args []string, keepHelp, numbers bool) (kept, unknown []string) {
+	var wantArg bool
+	for i, s := range args {
+		if wantArg {
+			kept = append(kept, s)
+			wantArg = false
+			continue
+		} else if s == "--" {
+			return append(kept, args[i:]...), unknown
+		} else if s == "-" || !strings.HasPrefix(s, "-") || (numbers && isNumberArg(fs, s)) {
+			kept = append(kept, s)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(s[1:], "-"), "=")
+		if f := fs.Lookup(name); f != nil {
+			kept = append(kept, s)
+			wantArg = !hasValue && !isBoolFlag(f)
+		} else if keepHelp && isHelpFlag(name) {
+			kept = append(kept, s)
+		} else {
+			unknown = append(unknown, s)
+		}
+	}
+	return kept, unknown
+}
+
+// isNumberArg reports whether s is a negative number, such as "-5" or "-2.5",
+// that does not name a flag of fs.
+func isNumberArg(fs *flag.FlagSet, s string) bool {
+	if len(s) < 2 || s[0] != '-' || !(s[1] == '.' || (s[1] >= '0' && s[1] <= '9')) {
+		return false
+	} else if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	name, _, _ := strings.Cut(s[1:], "=")
+	return fs.Lookup(name) == nil
+}
+
+// protectNumbers returns args with "--" inserted before the first negative
+// number (see isNumberArg) that parsing args with fs would otherwise treat as
+// a flag, so that it and the arguments after it are parsed as free arguments.
+func protectNumbers(fs *flag.FlagSet, args []string) []string {
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if s == "--" || s == "-" || !strings.HasPrefix(s, "-") {
+			break // parsing stops here
+		} else if isNumberArg(fs, s) {
+			return slices.Concat(args[:i], []string{"--"}, args[i:])
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(s[1:], "-"), "=")
+		if f := fs.Lookup(name); f == nil {
+			break // parsing reports an error
+		} else if !hasValue && !isBoolFlag(f) {
+			i++ // skip the value
+		}
+	}
+	return args
+}
+
+// isHelpFlag reports whether name is one of the flag names that the flag
+// package treats as a request for help when they are not defined.
+func isHelpFlag(name string) bool { return name == "help" || name == "h" }
+
 func isBoolFlag(f *flag.Flag) bool {
 	v, ok := f.Value.(interface {
 		IsBoolFlag() bool
@@ -0,0 +1,531 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StringsVar defines a repeatable string flag on fs with the specified name
+// and usage. Each occurrence of the flag appends its value to *p, so that, for
+// example, "--tag a --tag b" sets *p to ["a", "b"]. The initial contents of *p
+// are the default, which is replaced by the first occurrence of the flag in
+// each invocation of [Run].
+//
+// A value may also list several elements separated by commas, as in
+// "--tag a,b", which is the form in which the flag reports its value and
+// default. An element that contains a comma or a quotation mark is quoted as
+// in CSV, so the shell argument '"a,b"' is the single element a,b. An empty
+// value clears the list.
+func StringsVar(fs *flag.FlagSet, p *[]string, name, usage string) {
+	fs.Var(&sliceValue[string]{p: p, typ: "string", parse: func(s string) (string, error) {
+		return s, nil
+	}}, name, usage)
+}
+
+// IntsVar defines a repeatable integer flag on fs with the specified name and
+// usage. Each occurrence of the flag appends its value to *p, as described for
+// StringsVar, including lists of comma-separated values.
+func IntsVar(fs *flag.FlagSet, p *[]int, name, usage string) {
+	fs.Var(&sliceValue[int]{p: p, typ: "int", parse: func(s string) (int, error) {
+		v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", s)
+		}
+		return int(v), nil
+	}}, name, usage)
+}
+
+// MapVar defines a repeatable key-value flag on fs with the specified name and
+// usage. Each occurrence of the flag has a value of the form "key=value" and
+// sets that key in *p, so that, for example, "--label a=1 --label b=2" sets *p
+// to {"a": "1", "b": "2"}. The initial contents of *p are the default, which
+// is replaced by the first occurrence of the flag in each invocation of [Run].
+// If *p is nil, a new map is allocated when the flag is first set. A value may
+// also list several pairs separated by commas, as in "--label a=1,b=2", as
+// described for StringsVar.
+func MapVar(fs *flag.FlagSet, p *map[string]string, name, usage string) {
+	fs.Var(&mapValue{p: p}, name, usage)
+}
+
+// EnumVar defines a string flag on fs with the specified name and usage,
+// whose value must be one of the given choices. For example:
+//
+//	command.EnumVar(fs, &format, "format", "Output format", "text", "json", "csv")
+//
+// accepts "--format json", but reports an error for "--format xml". The
+// initial value of *p is the default; if it is not one of the choices, *p is
+// set to the first choice. Help output lists the choices in place of the type
+// of the flag, and they are available to other tools, such as shell
+// completion, via FlagChoices. EnumVar panics if no choices are given.
+func EnumVar(fs *flag.FlagSet, p *string, name, usage string, choices ...string) {
+	if len(choices) == 0 {
+		panic(fmt.Sprintf("flag %q has no choices", name))
+	}
+	if !slices.Contains(choices, *p) {
+		*p = choices[0]
+	}
+	fs.Var(&enumValue{p: p, choices: slices.Clone(choices)}, name, usage)
+}
+
+// FlagChoices returns the values permitted for f, if it accepts only a fixed
+// set of values (see EnumVar), or nil.
+func FlagChoices(f *flag.Flag) []string {
+	if c, ok := unwrapValue(f.Value).(interface{ Choices() []string }); ok {
+		return c.Choices()
+	}
+	return nil
+}
+
+// enumValue is a [flag.Value] for a string flag with a fixed set of choices
+// (see EnumVar).
+type enumValue struct {
+	p       *string
+	choices []string
+}
+
+func (e *enumValue) String() string {
+	if e == nil || e.p == nil {
+		return ""
+	}
+	return *e.p
+}
+
+func (e *enumValue) Set(text string) error {
+	if !slices.Contains(e.choices, text) {
+		return fmt.Errorf("invalid choice %q (want %s)", text, strings.Join(e.choices, ", "))
+	}
+	*e.p = text
+	return nil
+}
+
+func (e *enumValue) Get() any { return *e.p }
+
+// Choices returns a copy of the choices for e.
+func (e *enumValue) Choices() []string { return slices.Clone(e.choices) }
+
+func (e *enumValue) typeName() string { return strings.Join(e.choices, "|") }
+
+// DurationVar defines a duration flag on fs with the specified name, default
+// value, and usage. In addition to the syntax of [time.ParseDuration], the
+// value may use the units "d" for days of 24 hours and "w" for weeks of 7
+// days, as in "1d" or "2w3d12h".
+func DurationVar(fs *flag.FlagSet, p *time.Duration, name string, value time.Duration, usage string) {
+	*p = value
+	fs.Var((*durationValue)(p), name, usage)
+}
+
+// durationValue is a [flag.Value] for a duration (see DurationVar).
+type durationValue time.Duration
+
+func (d *durationValue) String() string { return (*time.Duration)(d).String() }
+
+func (d *durationValue) Set(text string) error {
+	v, err := parseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+func (d *durationValue) Get() any { return time.Duration(*d) }
+
+func (d *durationValue) typeName() string { return "duration" }
+
+// parseDuration parses s as a duration, as described for DurationVar.
+func parseDuration(s string) (time.Duration, error) {
+	var buf strings.Builder
+	rest := s
+	for rest != "" {
+		// Copy each number with its unit, converting days and weeks to hours.
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i < 0 {
+			buf.WriteString(rest)
+			break
+		}
+		num, unit := rest[:i], rest[i:i+1]
+		if scale, ok := longUnits[unit]; ok && num != "" {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			buf.WriteString(strconv.FormatFloat(v*scale, 'f', -1, 64) + "h")
+		} else {
+			buf.WriteString(rest[:i+1])
+		}
+		rest = rest[i+1:]
+	}
+	d, err := time.ParseDuration(buf.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// longUnits are the duration units accepted by parseDuration beyond those of
+// time.ParseDuration, in hours.
+var longUnits = map[string]float64{"d": 24, "w": 7 * 24}
+
+// TimeVar defines a timestamp flag on fs with the specified name and usage.
+// The initial value of *p is the default. The value may be given in any of the
+// following forms:
+//
+//	2026-01-02T15:04:05Z07:00  RFC 3339, with optional fractional seconds
+//	2026-01-02T15:04:05        local time
+//	2026-01-02 15:04:05        local time
+//	2026-01-02                 midnight local time
+//	1767366245                 seconds since the Unix epoch
+func TimeVar(fs *flag.FlagSet, p *time.Time, name, usage string) {
+	fs.Var((*timeValue)(p), name, usage)
+}
+
+// timeValue is a [flag.Value] for a timestamp (see TimeVar).
+type timeValue time.Time
+
+// timeLayouts are the layouts accepted by TimeVar, other than Unix seconds.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+}
+
+func (t *timeValue) String() string {
+	if t == nil || time.Time(*t).IsZero() {
+		return ""
+	}
+	return time.Time(*t).Format(time.RFC3339)
+}
+
+func (t *timeValue) Set(text string) error {
+	if sec, err := strconv.ParseInt(text, 10, 64); err == nil {
+		*t = timeValue(time.Unix(sec, 0))
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			*t = timeValue(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q", text)
+}
+
+func (t *timeValue) Get() any { return time.Time(*t) }
+
+func (t *timeValue) typeName() string { return "time" }
+
+// PathCheck is a constraint on the value of a path flag (see PathVar).
+type PathCheck int
+
+const (
+	PathAny       PathCheck = iota // any path is accepted
+	PathExists                     // the path must exist
+	PathNotExists                  // the path must not exist
+	PathFile                       // the path must exist and not be a directory
+	PathDir                        // the path must exist and be a directory
+)
+
+// PathVar defines a file path flag on fs with the specified name and usage.
+// The initial value of *p is the default. When the flag is set, a leading "~"
+// in its value is replaced by the home directory of the user, and references
+// to environment variables such as $HOME or ${HOME} are expanded. The
+// resulting path must then satisfy check, or the flag reports an error. An
+// empty value is always accepted, and means no path.
+//
+// The value is expanded and checked when the command is run (see [Run]) or
+// resolved (see [Resolve]), after its flags are parsed and before its Init and
+// Run functions are called. Variables are read from the environment of the
+// command (see Env.SetEnviron), and the path is checked in its file system
+// (see Env.SetFS). If the path does not satisfy check, Run reports a
+// [UsageError] naming the flag.
+//
+// Shell completion for the flag offers file names (see Complete), or only
+// directory names if check is PathDir. Use CompleteFlag to customize this,
+// for example to offer only files with certain extensions.
+func PathVar(fs *flag.FlagSet, p *string, name, usage string, check PathCheck) {
+	fs.Var(&pathValue{p: p, check: check}, name, usage)
+}
+
+// pathValue is a [flag.Value] for a file path (see PathVar).
+type pathValue struct {
+	p       *string
+	check   PathCheck
+	pending bool // whether *p is to be expanded and checked (see resolvePaths)
+}
+
+func (v *pathValue) String() string {
+	if v == nil || v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *pathValue) Set(text string) error {
+	*v.p, v.pending = text, true
+	return nil
+}
+
+// resolve expands and checks the pending value of v, if any, using the
+// environment and file system of env.
+func (v *pathValue) resolve(env *Env) error {
+	if !v.pending {
+		return nil
+	}
+	v.pending = false
+	path, err := env.expandPath(*v.p)
+	if err != nil {
+		return err
+	}
+	if v.check != PathAny && path != "" {
+		fi, err := fs.Stat(env.FS(), env.fsName(path))
+		switch {
+		case v.check == PathNotExists && err == nil:
+			return fmt.Errorf("path %q already exists", path)
+		case v.check == PathNotExists && errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return err
+		case v.check == PathFile && fi.IsDir():
+			return fmt.Errorf("path %q is a directory", path)
+		case v.check == PathDir && !fi.IsDir():
+			return fmt.Errorf("path %q is not a directory", path)
+		}
+	}
+	*v.p = path
+	return nil
+}
+
+// resolvePaths expands and checks the pending values of the path flags of the
+// command for e (see PathVar). Problems are reported as a UsageError.
+func (e *Env) resolvePaths() error {
+	var problems []string
+	e.Command.Flags.VisitAll(func(f *flag.Flag) {
+		if v, ok := unwrapValue(f.Value).(*pathValue); ok {
+			if err := v.resolve(e); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid value for flag --%s: %v", f.Name, err))
+			}
+		}
+	})
+	if len(problems) != 0 {
+		return e.Usagef("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (v *pathValue) Get() any { return *v.p }
+
+// restart discards a pending value that was not resolved, for example because
+// the invocation that set it failed (see restartFlags).
+func (v *pathValue) restart() { v.pending = false }
+
+func (v *pathValue) typeName() string {
+	switch v.check {
+	case PathFile:
+		return "file"
+	case PathDir:
+		return "dir"
+	default:
+		return "path"
+	}
+}
+
+// Complete offers completions for the value of the flag (see Complete).
+func (v *pathValue) Complete(env *Env, word string) []string {
+	return completePath(env, word, v.check == PathDir, nil)
+}
+
+// expandPath returns s with a leading "~" replaced by the home directory of
+// the user, and references to environment variables expanded, using the
+// environment of e (see SetEnviron).
+func (e *Env) expandPath(s string) (string, error) {
+	if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+		home, err := e.homeDir()
+		if err != nil {
+			return "", err
+		}
+		s = home + s[1:]
+	}
+	return os.Expand(s, e.Getenv), nil
+}
+
+// homeDir returns the home directory of the user, from the environment of e
+// (see SetEnviron), as [os.UserHomeDir] does from the environment of the
+// process.
+func (e *Env) homeDir() (string, error) {
+	if e.environ == nil {
+		return os.UserHomeDir()
+	}
+	key := "HOME"
+	switch runtime.GOOS {
+	case "windows":
+		key = "USERPROFILE"
+	case "plan9":
+		key = "home"
+	}
+	if home := e.Getenv(key); home != "" {
+		return home, nil
+	}
+	return "", fmt.Errorf("$%s is not defined", key)
+}
+
+// sliceValue is a [flag.Value] for a repeatable flag whose values are
+// collected in a slice (see StringsVar).
+type sliceValue[T any] struct {
+	p     *[]T
+	typ   string // the type name shown in help
+	parse func(string) (T, error)
+	set   bool // whether the default has been replaced
+}
+
+func (s *sliceValue[T]) String() string {
+	if s == nil || s.p == nil {
+		return ""
+	}
+	strs := make([]string, len(*s.p))
+	for i, v := range *s.p {
+		strs[i] = fmt.Sprint(v)
+	}
+	return joinList(strs)
+}
+
+func (s *sliceValue[T]) Set(text string) error {
+	elts, err := splitList(text)
+	if err != nil {
+		return err
+	}
+	vs := make([]T, len(elts))
+	for i, elt := range elts {
+		vs[i], err = s.parse(elt)
+		if err != nil {
+			return err
+		}
+	}
+	if !s.set {
+		*s.p, s.set = nil, true
+	}
+	*s.p = append(*s.p, vs...)
+	return nil
+}
+
+func (s *sliceValue[T]) Get() any { return *s.p }
+
+func (s *sliceValue[T]) typeName() string { return s.typ }
+
+// rawValue and setRaw allow the slice to be saved and restored exactly by
+// SnapshotFlags and RestoreFlags, since Set appends to it.
+func (s *sliceValue[T]) rawValue() any { return slices.Clone(*s.p) }
+func (s *sliceValue[T]) setRaw(v any)  { *s.p, s.set = slices.Clone(v.([]T)), false }
+
+// restart arranges for the next call to Set to replace the contents of the
+// slice, as at the start of an invocation (see restartFlags).
+func (s *sliceValue[T]) restart() { s.set = false }
+
+// mapValue is a [flag.Value] for a repeatable flag whose key-value pairs are
+// collected in a map (see MapVar).
+type mapValue struct {
+	p   *map[string]string
+	set bool // whether the default has been replaced
+}
+
+func (m *mapValue) String() string {
+	if m == nil || m.p == nil {
+		return ""
+	}
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(*m.p)) {
+		pairs = append(pairs, key+"="+(*m.p)[key])
+	}
+	return joinList(pairs)
+}
+
+func (m *mapValue) Set(text string) error {
+	pairs, err := splitList(text)
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		if key, _, ok := strings.Cut(pair, "="); !ok || key == "" {
+			return errors.New("value must have the form key=value")
+		}
+	}
+	if !m.set {
+		*m.p, m.set = make(map[string]string), true
+	}
+	for _, pair := range pairs {
+		key, val, _ := strings.Cut(pair, "=")
+		(*m.p)[key] = val
+	}
+	return nil
+}
+
+func (m *mapValue) Get() any { return *m.p }
+
+func (m *mapValue) typeName() string { return "key=value" }
+
+// rawValue and setRaw allow the map to be saved and restored exactly by
+// SnapshotFlags and RestoreFlags, since Set adds to it.
+func (m *mapValue) rawValue() any { return maps.Clone(*m.p) }
+func (m *mapValue) setRaw(v any)  { *m.p, m.set = maps.Clone(v.(map[string]string)), false }
+
+// restart arranges for the next call to Set to replace the contents of the
+// map, as at the start of an invocation (see restartFlags).
+func (m *mapValue) restart() { m.set = false }
+
+// restartFlags restarts the flags defined by fs that keep state from one parse
+// to the next, other than those inherited from an ancestor. The first
+// occurrence of a repeatable flag (see StringsVar) in the next parse replaces
+// its value, as it does for other flags, rather than adding to the values of
+// an earlier invocation, and a secret source left by an earlier parse is
+// discarded (see SecretVar).
+func restartFlags(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if r, ok := unwrapValue(f.Value).(interface{ restart() }); ok && !getFlagInfo(f).inherited {
+			r.restart()
+		}
+	})
+}
+
+// splitList splits text into the comma-separated elements of a list, which
+// are quoted as in CSV if necessary (see joinList). An empty text has no
+// elements.
+func splitList(text string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	var out []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid list %q", text)
+		}
+		out = append(out, rec...)
+	}
+}
+
+// joinList formats elts as a comma-separated list, in the form accepted by
+// splitList.
+func joinList(elts []string) string {
+	if len(elts) == 1 && elts[0] == "" {
+		return `""` // distinguish a single empty element from no elements
+	}
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	w.Write(elts)
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,305 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/creachadair/command"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepeatableFlags(t *testing.T) {
+	type values struct {
+		Tags   []string
+		Ports  []int
+		Labels map[string]string
+	}
+	var got values
+	newCmd := func() *command.C {
+		got = values{Tags: []string{"default"}, Labels: map[string]string{"env": "dev"}}
+		return &command.C{
+			Name: "test",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.StringsVar(fs, &got.Tags, "tag", "Add a tag")
+				command.IntsVar(fs, &got.Ports, "port", "Listen on this port")
+				command.MapVar(fs, &got.Labels, "label", "Add a label")
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want values
+	}{
+		{nil, values{Tags: []string{"default"}, Labels: map[string]string{"env": "dev"}}},
+		{[]string{"--tag", "a", "--tag=b", "--port", "80", "--port", "0x1bb"},
+			values{Tags: []string{"a", "b"}, Ports: []int{80, 443}, Labels: map[string]string{"env": "dev"}}},
+		{[]string{"--label", "a=1", "--label", "b=", "--label", "a=2"},
+			values{Tags: []string{"default"}, Labels: map[string]string{"a": "2", "b": ""}}},
+		{[]string{"--tag", `a,"b,c"`, "--tag", "d", "--port=1,2", "--label", "x=1,y=2"},
+			values{Tags: []string{"a", "b,c", "d"}, Ports: []int{1, 2}, Labels: map[string]string{"x": "1", "y": "2"}}},
+		{[]string{"--tag="}, values{Labels: map[string]string{"env": "dev"}}},
+	}
+	for _, tc := range tests {
+		if err := command.Run(newCmd().NewEnv(nil), tc.args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		} else if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("Run %q: values (-want, +got):\n%s", tc.args, diff)
+		}
+	}
+
+	for _, args := range [][]string{{"--port", "x"}, {"--label", "nokey"}, {"--label", "=v"}} {
+		env := newCmd().NewEnv(nil)
+		env.Log = io.Discard
+		if err := command.Run(env, args); err == nil {
+			t.Errorf("Run %q: got nil error, want error", args)
+		}
+	}
+
+	// Each flag accepts its reported value, and a new invocation replaces the
+	// values of an earlier one.
+	cmd := newCmd()
+	env := cmd.NewEnv(nil)
+	snap := env.SnapshotFlags()
+	got.Tags, got.Ports, got.Labels = []string{"x,y", ""}, []int{1, 2}, map[string]string{"a": "1", "b": "2,3"}
+	var args []string
+	cmd.Flags.VisitAll(func(f *flag.Flag) { args = append(args, "--"+f.Name+"="+f.Value.String()) })
+	want := got
+	if err := command.Run(env, args); err != nil {
+		t.Fatalf("Run %q: unexpected error: %v", args, err)
+	} else if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run %q: values (-want, +got):\n%s", args, diff)
+	}
+	if err := command.Run(env, []string{"--tag", "c", "--port", "3"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if want := []string{"c"}; !slices.Equal(got.Tags, want) {
+		t.Errorf("Second run: tags are %q, want %q", got.Tags, want)
+	} else if want := []int{3}; !slices.Equal(got.Ports, want) {
+		t.Errorf("Second run: ports are %v, want %v", got.Ports, want)
+	}
+
+	// Restoring a snapshot restores the defaults exactly.
+	if err := env.RestoreFlags(snap); err != nil {
+		t.Fatalf("RestoreFlags: unexpected error: %v", err)
+	}
+	def := values{Tags: []string{"default"}, Labels: map[string]string{"env": "dev"}}
+	if diff := cmp.Diff(def, got); diff != "" {
+		t.Errorf("After restore: values (-want, +got):\n%s", diff)
+	}
+	if err := command.Run(env, []string{"--tag", "c", "--label", "k=v"}); err != nil {
+		t.Fatalf("Run: unexpected error: %v", err)
+	} else if diff := cmp.Diff(values{Tags: []string{"c"}, Labels: map[string]string{"k": "v"}}, got); diff != "" {
+		t.Errorf("Run after restore: values (-want, +got):\n%s", diff)
+	}
+
+	cmd = newCmd()
+	cmd.SetFlags(nil, &cmd.Flags)
+	help := renderHelp(cmd, 0)
+	for _, want := range []string{
+		"--label key=value\n    \tAdd a label (default env=dev)\n",
+		"--port int\n    \tListen on this port\n",
+		"--tag string\n    \tAdd a tag (default default)\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("Help output is missing %q:\n%s", want, help)
+		}
+	}
+}
+
+func TestEnumVar(t *testing.T) {
+	var format string
+	newCmd := func() *command.C {
+		format = ""
+		return &command.C{
+			Name: "test",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.EnumVar(fs, &format, "format", "Output format", "text", "json", "csv")
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "text"},
+		{[]string{"--format", "json"}, "json"},
+		{[]string{"--format=csv"}, "csv"},
+	}
+	for _, tc := range tests {
+		if err := command.Run(newCmd().NewEnv(nil), tc.args); err != nil {
+			t.Errorf("Run %q: unexpected error: %v", tc.args, err)
+		} else if format != tc.want {
+			t.Errorf("Run %q: format is %q, want %q", tc.args, format, tc.want)
+		}
+	}
+
+	env := newCmd().NewEnv(nil)
+	env.Log = io.Discard
+	err := command.Run(env, []string{"--format", "xml"})
+	if err == nil || !strings.Contains(err.Error(), `invalid choice "xml" (want text, json, csv)`) {
+		t.Errorf("Run: got error %v, want invalid choice", err)
+	}
+
+	cmd := newCmd()
+	cmd.SetFlags(nil, &cmd.Flags)
+	if got, want := command.FlagChoices(cmd.Flags.Lookup("format")), []string{"text", "json", "csv"}; !slices.Equal(got, want) {
+		t.Errorf("FlagChoices: got %q, want %q", got, want)
+	}
+	if help, want := renderHelp(cmd, 0), " --format text|json|csv\n    \tOutput format (default text)\n"; !strings.Contains(help, want) {
+		t.Errorf("Help output is missing %q:\n%s", want, help)
+	}
+}
+
+func TestDurationVar(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"90s", 90 * time.Second},
+		{"2h30m", 150 * time.Minute},
+		{"1d", 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"2w3d12h", (14*24 + 3*24 + 12) * time.Hour},
+		{"-1d", -24 * time.Hour},
+		{"250ms", 250 * time.Millisecond},
+		{"0", 0},
+	}
+	for _, tc := range tests {
+		var fs flag.FlagSet
+		var got time.Duration
+		command.DurationVar(&fs, &got, "wait", time.Minute, "Wait time")
+		if got != time.Minute {
+			t.Errorf("Default: got %v, want 1m", got)
+		}
+		if err := fs.Parse([]string{"--wait", tc.input}); err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.input, err)
+		} else if got != tc.want {
+			t.Errorf("Parse %q: got %v, want %v", tc.input, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "1", "d", "1x", "1..5d"} {
+		var fs flag.FlagSet
+		fs.SetOutput(io.Discard)
+		command.DurationVar(&fs, new(time.Duration), "wait", 0, "Wait time")
+		if err := fs.Parse([]string{"--wait", bad}); err == nil {
+			t.Errorf("Parse %q: got nil error, want error", bad)
+		}
+	}
+}
+
+func TestTimeVar(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2026-01-02T15:04:05.5-05:00", time.Date(2026, 1, 2, 20, 4, 5, 5e8, time.UTC)},
+		{"2026-01-02T15:04:05", time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)},
+		{"2026-01-02 15:04:05", time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)},
+		{"1767366245", time.Unix(1767366245, 0)},
+	}
+	for _, tc := range tests {
+		var fs flag.FlagSet
+		var got time.Time
+		command.TimeVar(&fs, &got, "since", "Start time")
+		if err := fs.Parse([]string{"--since", tc.input}); err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.input, err)
+		} else if !got.Equal(tc.want) {
+			t.Errorf("Parse %q: got %v, want %v", tc.input, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "2026-13-01", "1.5"} {
+		var fs flag.FlagSet
+		fs.SetOutput(io.Discard)
+		command.TimeVar(&fs, new(time.Time), "since", "Start time")
+		if err := fs.Parse([]string{"--since", bad}); err == nil {
+			t.Errorf("Parse %q: got nil error, want error", bad)
+		}
+	}
+}
+
+func TestPathVar(t *testing.T) {
+	// Paths are expanded from the environment of the command, and checked in
+	// its file system, not those of the process.
+	t.Setenv("DATA", "/elsewhere")
+	environ := map[string]string{"HOME": "/home/user", "DATA": "/home/user/data"}
+	fsys := fstest.MapFS{"home/user/data/input.txt": {}}
+
+	tests := []struct {
+		check   command.PathCheck
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{command.PathAny, "~/nonesuch", "/home/user/nonesuch", false},
+		{command.PathAny, "$DATA/x", "/home/user/data/x", false},
+		{command.PathExists, "~/data/input.txt", "/home/user/data/input.txt", false},
+		{command.PathExists, "${DATA}/nonesuch", "", true},
+		{command.PathNotExists, "$DATA/new", "/home/user/data/new", false},
+		{command.PathNotExists, "/home/user/data/input.txt", "", true},
+		{command.PathFile, "$DATA/input.txt", "/home/user/data/input.txt", false},
+		{command.PathFile, "~/data", "", true},
+		{command.PathDir, "~/data", "/home/user/data", false},
+		{command.PathDir, "$DATA/input.txt", "", true},
+	}
+	for _, tc := range tests {
+		var got string
+		root := &command.C{
+			Name: "tool",
+			SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+				command.PathVar(fs, &got, "path", "A path", tc.check)
+			},
+			Run: func(*command.Env) error { return nil },
+		}
+		env := root.NewEnv(nil).SetEnviron(environ).SetFS(fsys)
+		env.Log = io.Discard
+		err := command.Run(env, []string{"--path", tc.input})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Run %q (check %d): got error %v, want error %v", tc.input, tc.check, err, tc.wantErr)
+		} else if err != nil && !command.IsUsage(err) {
+			t.Errorf("Run %q (check %d): got error %v, want usage error", tc.input, tc.check, err)
+		} else if err == nil && got != tc.want {
+			t.Errorf("Run %q (check %d): got %q, want %q", tc.input, tc.check, got, tc.want)
+		}
+	}
+
+	// Path flags complete file names, or directory names for PathDir.
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0700); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(filepath.Join(dir, "data", "input.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	root := &command.C{
+		Name: "tool",
+		SetFlags: func(_ *command.Env, fs *flag.FlagSet) {
+			command.PathVar(fs, new(string), "input", "Input file", command.PathFile)
+			command.PathVar(fs, new(string), "out", "Output directory", command.PathDir)
+		},
+	}
+	for _, tc := range []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"--input", "~/data/"}, []string{"~/data/input.txt"}},
+		{[]string{"--out", "~/"}, []string{"~/data/"}},
+		{[]string{"--out", "~/data/"}, nil},
+	} {
+		got := command.Complete(root.NewEnv(nil), tc.words)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Complete %q: got %q, want %q", tc.words, got, tc.want)
+		}
+	}
+}
@@ -22,6 +22,50 @@ func ProgramName() string {
 	return filepath.Base(os.Args[0])
 }
 
+// ProgramName returns the name of the program as presented to the user by e.
+// If no name has been set by SetProgramName, it returns the base name of
+// os.Args[0], which for a multi-call binary is the name it was invoked by.
+func (e *Env) ProgramName() string {
+	if e.prog != "" {
+		return e.prog
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// SetProgramName sets the name of the program presented to the user by e, and
+// returns e. If name == "", it restores the default (see Env.ProgramName).
+// The setting is inherited by the descendants of e.
+//
+// When a name is set, it is used in place of the name of the root command in
+// usage summaries, help placeholders, and help hints, and it is reported as
+// the name of the program by the "version" command. It is also used to name
+// the temporary directory (see Env.TempDir) and the default secret store
+// (see Env.Secrets).
+func (e *Env) SetProgramName(name string) *Env { e.prog = name; return e }
+
+// rootName returns the name presented for the root command of e.
+func (e *Env) rootName() string {
+	if e.prog != "" {
+		return e.prog
+	}
+	for e.Parent != nil {
+		e = e.Parent
+	}
+	return e.Command.Name
+}
+
+// displayPath returns the names of the commands from the root to e, with the
+// root named as by rootName. For a multi-call program (see RunAs) the root is
+// omitted, since the program is invoked by the name of its subcommand.
+func (e *Env) displayPath() []string {
+	path := e.path()
+	if e.multi && len(path) > 1 {
+		return path[1:]
+	}
+	path[0] = e.rootName()
+	return path
+}
+
 // VersionCommand constructs a standardized version command that prints version
 // metadata from the running binary to stdout. The caller can safely modify the
 // returned command to customize its behavior.
@@ -35,11 +79,11 @@ func VersionCommand() *C {
 		},
 		Run: Adapt(func(env *Env) error {
 			vi := GetVersionInfo()
+			vi.Name = env.ProgramName()
 			if doJSON {
-				json.NewEncoder(os.Stdout).Encode(vi)
-				return nil
+				return json.NewEncoder(env.Stdout()).Encode(vi)
 			}
-			fmt.Println(vi)
+			fmt.Fprintln(env.Stdout(), vi)
 			return ErrRequestHelp
 		}),
 	}
@@ -96,6 +140,11 @@ func GetVersionInfo() VersionInfo {
 	if !ok {
 		return VersionInfo{Name: filepath.Base(os.Args[0])}
 	}
+	return versionInfo(bi)
+}
+
+// versionInfo returns a VersionInfo record extracted from bi.
+func versionInfo(bi *debug.BuildInfo) VersionInfo {
 	vi := VersionInfo{
 		Name:       filepath.Base(os.Args[0]),
 		ImportPath: bi.Path,
@@ -116,6 +165,7 @@ func GetVersionInfo() VersionInfo {
 		case "vcs.time":
 			ts, err := time.Parse(time.RFC3339, s.Value)
 			if err == nil {
+				ts = ts.UTC()
 				vi.Time = &ts
 			}
 		case "vcs.modified":
@@ -185,13 +235,30 @@ func (v *VersionInfo) parseModule(m *debug.Module) bool {
 
 // parsePseudoVersion reports whether s appears to be a Go module pseudoversion
 // marker, and if so returns the timestamp and commit digest extracted from it.
+//
+// A pseudo-version has one of the forms
+//
+//	vX.0.0-yyyymmddhhmmss-abcdefabcdef
+//	vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef
+//	vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdefabcdef
+//
+// optionally followed by a "+incompatible" suffix.
 func parsePseudoVersion(s string) (time.Time, string, bool) {
+	s, _, _ = strings.Cut(s, "+")
 	ps := strings.Split(s, "-")
-	if len(ps) == 3 {
+	if len(ps) >= 3 {
 		// A valid pseudo-version has a timestamp and hex commit.
-		ts, terr := time.Parse(time.RFC3339, ps[1])
-		_, herr := hex.DecodeString(ps[2])
-		return ts, ps[2], terr == nil && herr == nil
+		stamp, commit := ps[len(ps)-2], ps[len(ps)-1]
+		if i := strings.LastIndex(stamp, "."); i >= 0 {
+			stamp = stamp[i+1:]
+		}
+		ts, terr := time.Parse(pseudoTimeFormat, stamp)
+		_, herr := hex.DecodeString(commit)
+		return ts, commit, terr == nil && herr == nil
 	}
 	return time.Time{}, "", false
 }
+
+// pseudoTimeFormat is the layout of the timestamp in a pseudo-version.
+// Pseudo-version timestamps are always in UTC.
+const pseudoTimeFormat = "20060102150405"
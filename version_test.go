@@ -0,0 +1,72 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/creachadair/command"
+)
+
+func TestParsePseudoVersion(t *testing.T) {
+	stamp := time.Date(2024, 3, 5, 17, 4, 9, 0, time.UTC)
+	tests := []struct {
+		input  string
+		commit string
+		ok     bool
+	}{
+		// The three pseudo-version forms.
+		{"v0.0.0-20240305170409-abcdef012345", "abcdef012345", true},
+		{"v1.2.3-pre.0.20240305170409-abcdef012345", "abcdef012345", true},
+		{"v1.2.4-0.20240305170409-abcdef012345", "abcdef012345", true},
+
+		// An incompatible major version.
+		{"v2.0.1-0.20240305170409-abcdef012345+incompatible", "abcdef012345", true},
+
+		// Not pseudo-versions.
+		{"v1.2.3", "", false},
+		{"v1.2.3-rc1", "", false},
+		{"(devel)", "", false},
+		{"v0.0.0-2024030517040-abcdef012345", "", false},  // short stamp
+		{"v0.0.0-20241305170409-abcdef012345", "", false}, // bad month
+		{"v0.0.0-2024030517040x-abcdef012345", "", false}, // non-digit
+		{"v0.0.0-20240305170409-not-hex", "", false},
+		{"v0.0.0-20240305170409-xyz012345678", "", false},
+	}
+	for _, tc := range tests {
+		ts, commit, ok := command.ParsePseudoVersion(tc.input)
+		if ok != tc.ok {
+			t.Errorf("Parse %q: got ok=%v, want %v", tc.input, ok, tc.ok)
+			continue
+		} else if !ok {
+			continue
+		}
+		if commit != tc.commit {
+			t.Errorf("Parse %q: got commit %q, want %q", tc.input, commit, tc.commit)
+		}
+		if !ts.Equal(stamp) || ts.Location() != time.UTC {
+			t.Errorf("Parse %q: got time %v, want %v", tc.input, ts, stamp)
+		}
+	}
+}
+
+func TestVersionInfoTime(t *testing.T) {
+	vi := command.VersionInfoFrom(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/tool", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abcdef012345"},
+			{Key: "vcs.time", Value: "2024-03-05T12:04:09-05:00"},
+		},
+	})
+	want := time.Date(2024, 3, 5, 17, 4, 9, 0, time.UTC)
+	if vi.Time == nil {
+		t.Fatal("Time is nil, want a value")
+	} else if !vi.Time.Equal(want) || vi.Time.Location() != time.UTC {
+		t.Errorf("Time: got %v, want %v", vi.Time, want)
+	}
+	if vi.Commit != "abcdef012345" {
+		t.Errorf("Commit: got %q, want abcdef012345", vi.Commit)
+	}
+}
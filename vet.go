@@ -0,0 +1,66 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// VetError describes a problem with the definition of a command, reported by
+// the Vet method of [C].
+type VetError struct {
+	Path    string // the path of the command, e.g. "tool sub cmd"
+	Message string // a description of the problem
+}
+
+func (v VetError) Error() string { return v.Path + ": " + v.Message }
+
+// Vet checks the command tree rooted at c for problems in its definition.  It
+// returns nil if no problems are found; otherwise it returns an error joining
+// one [VetError] for each problem. Vet checks that:
+//
+//   - Every command has a non-empty name without whitespace.
+//   - The subcommands of each command have distinct names.
+//   - The Default of each command, if set, names a runnable subcommand.
+//   - No known value of the first argument to a runnable command, as reported
+//     by its ArgValues function, collides with the name of a subcommand,
+//     unless the command has a PreferSubcommand hook to resolve it.
+func (c *C) Vet() error {
+	var errs []error
+	var walk func(path string, cmd *C)
+	walk = func(path string, cmd *C) {
+		report := func(msg string, args ...any) {
+			errs = append(errs, VetError{Path: path, Message: fmt.Sprintf(msg, args...)})
+		}
+		if cmd.Name == "" {
+			report("command name is empty")
+		} else if strings.ContainsAny(cmd.Name, " \t\r\n") {
+			report("command name %q contains whitespace", cmd.Name)
+		}
+
+		seen := make(map[string]bool)
+		for _, sub := range cmd.Commands {
+			if seen[sub.Name] {
+				report("duplicate subcommand %q", sub.Name)
+			}
+			seen[sub.Name] = true
+		}
+		if cmd.Default != "" && !cmd.FindSubcommand(cmd.Default).Runnable() {
+			report("default %q is not a runnable subcommand", cmd.Default)
+		}
+		if cmd.Run != nil && cmd.ArgValues != nil && cmd.PreferSubcommand == nil {
+			for _, arg := range cmd.ArgValues() {
+				if seen[arg] {
+					report("argument value %q collides with a subcommand name", arg)
+				}
+			}
+		}
+		for _, sub := range cmd.Commands {
+			walk(joinSpace(path, sub.Name), sub)
+		}
+	}
+	walk(c.Name, c)
+	return errors.Join(errs...)
+}
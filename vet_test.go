@@ -0,0 +1,60 @@
+// Copyright (C) 2026 Michael J. Fromberger. All Rights Reserved.
+
+package command_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/creachadair/command"
+)
+
+func TestVet(t *testing.T) {
+	run := func(*command.Env) error { return nil }
+	root := &command.C{
+		Name: "tool",
+		Commands: []*command.C{{
+			Name:      "get",
+			Run:       run,
+			ArgValues: func() []string { return []string{"alpha", "list", "bravo"} },
+			Commands:  []*command.C{{Name: "list", Run: run}},
+		}, {
+			Name:             "put",
+			Run:              run,
+			ArgValues:        func() []string { return []string{"list"} },
+			PreferSubcommand: func(string) bool { return true },
+			Commands:         []*command.C{{Name: "list", Run: run}},
+		}, {
+			Name: "bad name",
+		}, {
+			Name: "dup",
+			Commands: []*command.C{
+				{Name: "x"}, {Name: ""}, {Name: "x"},
+			},
+		}},
+	}
+
+	err := root.Vet()
+	var got []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ve command.VetError
+		if !errors.As(e, &ve) {
+			t.Fatalf("Vet: error %v is not a VetError", e)
+		}
+		got = append(got, ve.Error())
+	}
+	want := []string{
+		`tool get: argument value "list" collides with a subcommand name`,
+		`tool bad name: command name "bad name" contains whitespace`,
+		`tool dup: duplicate subcommand "x"`,
+		`tool dup: command name is empty`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Vet: got %q, want %q", got, want)
+	}
+
+	if err := root.Commands[1].Vet(); err != nil {
+		t.Errorf("Vet: unexpected error: %v", err)
+	}
+}